@@ -0,0 +1,78 @@
+package address_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/address"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := []struct {
+		raw     string
+		country string
+		e164    string
+		phone   string
+	}{
+		{"8 (912) 345-67-89", "", "+79123456789", address.Russia},
+		{"+7 701 123 45 67", "", "+77011234567", address.Kazakhstan},
+		{"87011234567", address.Russia, "+77011234567", address.Kazakhstan},
+		{"9123456789", "", "+79123456789", address.Russia},
+		{"0555 123 456", address.Kyrgyzstan, "+996555123456", address.Kyrgyzstan},
+		{"00996555123456", "", "+996555123456", address.Kyrgyzstan},
+		{"90 123 45 67", address.Uzbekistan, "+998901234567", address.Uzbekistan},
+		{"+998 90 123-45-67", "", "+998901234567", address.Uzbekistan},
+	}
+	for _, c := range cases {
+		p, err := address.NormalizePhone(c.raw, c.country)
+		if assert.NoError(t, err, c.raw) {
+			assert.Equal(t, c.e164, p.E164, c.raw)
+			assert.Equal(t, c.phone, p.Country, c.raw)
+		}
+	}
+
+	_, err := address.NormalizePhone("+86 138 0013 8000", "")
+	assert.Equal(t, address.ErrUnsupportedPhoneCountry, err)
+	_, err = address.NormalizePhone("12345", "")
+	assert.Equal(t, address.ErrInvalidPhone, err)
+	_, err = address.NormalizePhone("+7 912 345 67", "")
+	assert.Equal(t, address.ErrInvalidPhone, err)
+}
+
+func TestTransliteration(t *testing.T) {
+	assert.Equal(t, "Zhukov Sergei Ivanovich", address.ToLatin("Жуков Сергей Иванович"))
+	assert.Equal(t, "SHCHUKIN", address.ToLatin("ЩУКИН"))
+	assert.Equal(t, "Nurlan Abenov", address.ToLatin("Нұрлан Әбенов"))
+	assert.Equal(t, "Жуков Николай", address.ToCyrillic("Zhukov Nikolay"))
+	assert.Equal(t, "Мария Щукина", address.ToCyrillic("Mariia Shchukina"))
+	assert.True(t, address.HasCyrillic("Иван Ivanov"))
+	assert.False(t, address.HasCyrillic("Ivan"))
+}
+
+func TestMatchCity(t *testing.T) {
+	m, ok := address.MatchCity("г. Алматы, ул. Абая 10")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Алматы", m.City.NameRu)
+		assert.False(t, m.Fuzzy)
+	}
+
+	m, ok = address.MatchCity("Москва 莫斯科")
+	assert.True(t, ok)
+	assert.Equal(t, address.Russia, m.City.Country)
+
+	m, ok = address.MatchCity("发往阿拉木图")
+	assert.True(t, ok)
+	assert.Equal(t, "Almaty", m.City.NameEn)
+
+	m, ok = address.MatchCity("Нижний Новгород, ул. Ленина")
+	assert.True(t, ok)
+	assert.Equal(t, "Nizhny Novgorod", m.City.NameEn)
+
+	m, ok = address.MatchCity("Новосибрск")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Новосибирск", m.City.NameRu)
+		assert.True(t, m.Fuzzy)
+	}
+
+	_, ok = address.MatchCity("Париж")
+	assert.False(t, ok)
+}
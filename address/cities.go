@@ -0,0 +1,62 @@
+package address
+
+// cities are the usual destinations of shipments in
+// Russia, Kazakhstan, Kyrgyzstan and Uzbekistan
+var cities = []City{
+	{NameRu: "Москва", NameEn: "Moscow", NameZh: "莫斯科", Country: Russia, Aliases: []string{"Moskva", "Мск", "Msk"}},
+	{NameRu: "Санкт-Петербург", NameEn: "Saint Petersburg", NameZh: "圣彼得堡", Country: Russia, Aliases: []string{"Sankt-Peterburg", "St Petersburg", "СПб", "Питер", "Петербург"}},
+	{NameRu: "Новосибирск", NameEn: "Novosibirsk", NameZh: "新西伯利亚", Country: Russia},
+	{NameRu: "Екатеринбург", NameEn: "Yekaterinburg", NameZh: "叶卡捷琳堡", Country: Russia, Aliases: []string{"Ekaterinburg", "Екб"}},
+	{NameRu: "Казань", NameEn: "Kazan", NameZh: "喀山", Country: Russia},
+	{NameRu: "Нижний Новгород", NameEn: "Nizhny Novgorod", NameZh: "下诺夫哥罗德", Country: Russia, Aliases: []string{"Nizhniy Novgorod"}},
+	{NameRu: "Челябинск", NameEn: "Chelyabinsk", NameZh: "车里雅宾斯克", Country: Russia},
+	{NameRu: "Самара", NameEn: "Samara", NameZh: "萨马拉", Country: Russia},
+	{NameRu: "Омск", NameEn: "Omsk", NameZh: "鄂木斯克", Country: Russia},
+	{NameRu: "Ростов-на-Дону", NameEn: "Rostov-on-Don", NameZh: "顿河畔罗斯托夫", Country: Russia, Aliases: []string{"Rostov-na-Donu", "Ростов"}},
+	{NameRu: "Уфа", NameEn: "Ufa", NameZh: "乌法", Country: Russia},
+	{NameRu: "Красноярск", NameEn: "Krasnoyarsk", NameZh: "克拉斯诺亚尔斯克", Country: Russia},
+	{NameRu: "Пермь", NameEn: "Perm", NameZh: "彼尔姆", Country: Russia},
+	{NameRu: "Воронеж", NameEn: "Voronezh", NameZh: "沃罗涅日", Country: Russia},
+	{NameRu: "Волгоград", NameEn: "Volgograd", NameZh: "伏尔加格勒", Country: Russia},
+	{NameRu: "Краснодар", NameEn: "Krasnodar", NameZh: "克拉斯诺达尔", Country: Russia},
+	{NameRu: "Тюмень", NameEn: "Tyumen", NameZh: "秋明", Country: Russia},
+	{NameRu: "Иркутск", NameEn: "Irkutsk", NameZh: "伊尔库茨克", Country: Russia},
+	{NameRu: "Хабаровск", NameEn: "Khabarovsk", NameZh: "哈巴罗夫斯克", Country: Russia},
+	{NameRu: "Владивосток", NameEn: "Vladivostok", NameZh: "符拉迪沃斯托克", Country: Russia, Aliases: []string{"海参崴"}},
+	{NameRu: "Уссурийск", NameEn: "Ussuriysk", NameZh: "乌苏里斯克", Country: Russia, Aliases: []string{"双城子"}},
+	{NameRu: "Чита", NameEn: "Chita", NameZh: "赤塔", Country: Russia},
+	{NameRu: "Забайкальск", NameEn: "Zabaykalsk", NameZh: "后贝加尔斯克", Country: Russia},
+	{NameRu: "Благовещенск", NameEn: "Blagoveshchensk", NameZh: "布拉戈维申斯克", Country: Russia, Aliases: []string{"海兰泡"}},
+	{NameRu: "Улан-Удэ", NameEn: "Ulan-Ude", NameZh: "乌兰乌德", Country: Russia},
+	{NameRu: "Барнаул", NameEn: "Barnaul", NameZh: "巴尔瑙尔", Country: Russia},
+	{NameRu: "Томск", NameEn: "Tomsk", NameZh: "托木斯克", Country: Russia},
+	{NameRu: "Кемерово", NameEn: "Kemerovo", NameZh: "克麦罗沃", Country: Russia},
+	{NameRu: "Новокузнецк", NameEn: "Novokuznetsk", NameZh: "新库兹涅茨克", Country: Russia},
+	{NameRu: "Саратов", NameEn: "Saratov", NameZh: "萨拉托夫", Country: Russia},
+	{NameRu: "Оренбург", NameEn: "Orenburg", NameZh: "奥伦堡", Country: Russia},
+	{NameRu: "Якутск", NameEn: "Yakutsk", NameZh: "雅库茨克", Country: Russia},
+	{NameRu: "Алматы", NameEn: "Almaty", NameZh: "阿拉木图", Country: Kazakhstan, Aliases: []string{"Алма-Ата", "Alma-Ata"}},
+	{NameRu: "Астана", NameEn: "Astana", NameZh: "阿斯塔纳", Country: Kazakhstan, Aliases: []string{"Нур-Султан", "Nur-Sultan", "努尔苏丹"}},
+	{NameRu: "Шымкент", NameEn: "Shymkent", NameZh: "奇姆肯特", Country: Kazakhstan, Aliases: []string{"Чимкент"}},
+	{NameRu: "Караганда", NameEn: "Karaganda", NameZh: "卡拉干达", Country: Kazakhstan, Aliases: []string{"Қарағанды", "Karagandy"}},
+	{NameRu: "Актобе", NameEn: "Aktobe", NameZh: "阿克托别", Country: Kazakhstan},
+	{NameRu: "Тараз", NameEn: "Taraz", NameZh: "塔拉兹", Country: Kazakhstan},
+	{NameRu: "Павлодар", NameEn: "Pavlodar", NameZh: "巴甫洛达尔", Country: Kazakhstan},
+	{NameRu: "Усть-Каменогорск", NameEn: "Ust-Kamenogorsk", NameZh: "乌斯季卡缅诺戈尔斯克", Country: Kazakhstan, Aliases: []string{"Өскемен", "Oskemen"}},
+	{NameRu: "Семей", NameEn: "Semey", NameZh: "塞梅伊", Country: Kazakhstan, Aliases: []string{"Семипалатинск"}},
+	{NameRu: "Костанай", NameEn: "Kostanay", NameZh: "科斯塔奈", Country: Kazakhstan},
+	{NameRu: "Атырау", NameEn: "Atyrau", NameZh: "阿特劳", Country: Kazakhstan},
+	{NameRu: "Актау", NameEn: "Aktau", NameZh: "阿克套", Country: Kazakhstan},
+	{NameRu: "Хоргос", NameEn: "Khorgos", NameZh: "霍尔果斯", Country: Kazakhstan, Aliases: []string{"Қорғас"}},
+	{NameRu: "Бишкек", NameEn: "Bishkek", NameZh: "比什凯克", Country: Kyrgyzstan},
+	{NameRu: "Ош", NameEn: "Osh", NameZh: "奥什", Country: Kyrgyzstan},
+	{NameRu: "Джалал-Абад", NameEn: "Jalal-Abad", NameZh: "贾拉拉巴德", Country: Kyrgyzstan, Aliases: []string{"Жалал-Абад"}},
+	{NameRu: "Каракол", NameEn: "Karakol", NameZh: "卡拉科尔", Country: Kyrgyzstan},
+	{NameRu: "Ташкент", NameEn: "Tashkent", NameZh: "塔什干", Country: Uzbekistan, Aliases: []string{"Toshkent"}},
+	{NameRu: "Самарканд", NameEn: "Samarkand", NameZh: "撒马尔罕", Country: Uzbekistan, Aliases: []string{"Samarqand"}},
+	{NameRu: "Наманган", NameEn: "Namangan", NameZh: "纳曼干", Country: Uzbekistan},
+	{NameRu: "Андижан", NameEn: "Andijan", NameZh: "安集延", Country: Uzbekistan, Aliases: []string{"Andijon"}},
+	{NameRu: "Фергана", NameEn: "Fergana", NameZh: "费尔干纳", Country: Uzbekistan, Aliases: []string{"Farg'ona"}},
+	{NameRu: "Бухара", NameEn: "Bukhara", NameZh: "布哈拉", Country: Uzbekistan, Aliases: []string{"Buxoro"}},
+	{NameRu: "Нукус", NameEn: "Nukus", NameZh: "努库斯", Country: Uzbekistan},
+}
@@ -0,0 +1,156 @@
+package address
+
+import (
+	"strings"
+	"unicode"
+)
+
+// City is the destination city of the bundled city list
+type City struct {
+	NameRu  string `json:"name_ru"`
+	NameEn  string `json:"name_en"`
+	NameZh  string `json:"name_zh"`
+	Country string `json:"country"`
+	// Aliases are other spellings and old names of the city
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+func (c City) names() []string {
+	return append([]string{c.NameRu, c.NameEn, c.NameZh}, c.Aliases...)
+}
+
+// CityMatch is the city found in the destination. Fuzzy match
+// means destination has the city name with a typo
+type CityMatch struct {
+	City  City `json:"city"`
+	Fuzzy bool `json:"fuzzy"`
+}
+
+// Cities returns the bundled city list
+func Cities() []City {
+	return append([]City{}, cities...)
+}
+
+// cityStopWords are removed from the destination before matching
+var cityStopWords = map[string]bool{
+	"г": true, "гор": true, "город": true, "city": true, "g": true,
+	"обл": true, "область": true, "респ": true, "республика": true,
+}
+
+// normalizeCityText lowercases text, replaces punctuation
+// with spaces and removes stop words
+func normalizeCityText(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, "ё", "е"), "Ё", "Е"))
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if !cityStopWords[f] {
+			words = append(words, f)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// MatchCity finds the city in the destination, e.g. "г. Алматы, ул. Абая 10".
+// The longest matched name wins. If no name matches exactly, words of the
+// destination are compared with the city names allowing one or two typos
+func MatchCity(destination string) (CityMatch, bool) {
+	text := normalizeCityText(destination)
+	if text == "" {
+		return CityMatch{}, false
+	}
+	padded := " " + text + " "
+
+	var best CityMatch
+	bestLen := 0
+	for _, c := range cities {
+		for _, name := range c.names() {
+			n := normalizeCityText(name)
+			if n == "" {
+				continue
+			}
+			// chinese names are not separated with spaces
+			found := strings.Contains(padded, " "+n+" ")
+			if !found && !isASCII(n) && !HasCyrillic(n) {
+				found = strings.Contains(text, n)
+			}
+			if found && len(n) > bestLen {
+				best, bestLen = CityMatch{City: c}, len(n)
+			}
+		}
+	}
+	if bestLen > 0 {
+		return best, true
+	}
+
+	bestDistance := -1
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		if len(w) < 4 {
+			continue
+		}
+		for _, c := range cities {
+			for _, name := range c.names() {
+				n := []rune(normalizeCityText(name))
+				if len(n) < 4 {
+					continue
+				}
+				maxDistance := 1
+				if len(n) >= 8 {
+					maxDistance = 2
+				}
+				d := levenshtein(w, n)
+				if d <= maxDistance && (bestDistance < 0 || d < bestDistance) {
+					best, bestDistance = CityMatch{City: c, Fuzzy: true}, d
+				}
+			}
+		}
+	}
+
+	return best, bestDistance >= 0
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+
+	return true
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
@@ -0,0 +1,115 @@
+// Package address normalizes recipient contacts of CIS destinations:
+// phone numbers, transliteration of names and destination cities
+package address
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// ISO 3166 alpha-2 codes of supported countries
+const (
+	Russia     = "RU"
+	Kazakhstan = "KZ"
+	Kyrgyzstan = "KG"
+	Uzbekistan = "UZ"
+)
+
+var (
+	ErrInvalidPhone            = errors.New("address: invalid phone number")
+	ErrUnsupportedPhoneCountry = errors.New("address: phone number country is not supported")
+)
+
+// Phone is the normalized phone number
+type Phone struct {
+	// E164 is the number in E.164 format, e.g. +79123456789
+	E164    string `json:"e164"`
+	Country string `json:"country"`
+}
+
+// NormalizePhone converts the phone number written in any of the usual
+// ways to E.164. Local numbers without country code, like 0555123456 of
+// Kyrgyzstan, are resolved by the defaultCountry
+func NormalizePhone(raw string, defaultCountry string) (Phone, error) {
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+")
+	var b strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+	if digits == "" {
+		return Phone{}, ErrInvalidPhone
+	}
+
+	if !international {
+		digits = withCountryCode(digits, defaultCountry)
+	}
+
+	country, err := phoneCountry(digits)
+	if err != nil {
+		return Phone{}, err
+	}
+
+	return Phone{E164: "+" + digits, Country: country}, nil
+}
+
+// withCountryCode adds country code to the number dialed locally
+func withCountryCode(digits string, defaultCountry string) string {
+	switch {
+	case len(digits) == 11 && (digits[0] == '8' || digits[0] == '7'):
+		// 8 912 345 67 89 is the domestic format of Russia and Kazakhstan
+		return "7" + digits[1:]
+	case len(digits) == 10 && strings.ContainsRune("34679", rune(digits[0])):
+		return "7" + digits
+	case len(digits) == 10 && digits[0] == '0' && defaultCountry == Kyrgyzstan:
+		return "996" + digits[1:]
+	case len(digits) == 9 && defaultCountry == Kyrgyzstan:
+		return "996" + digits
+	case len(digits) == 9 && defaultCountry == Uzbekistan:
+		return "998" + digits
+	}
+
+	return digits
+}
+
+// phoneCountry checks the number with country code
+// and returns the country it belongs to
+func phoneCountry(digits string) (string, error) {
+	switch {
+	case strings.HasPrefix(digits, "7"):
+		if len(digits) != 11 {
+			return "", ErrInvalidPhone
+		}
+		// +7 6xx and +7 7xx are numbers of Kazakhstan
+		switch digits[1] {
+		case '6', '7':
+			return Kazakhstan, nil
+		case '3', '4', '8', '9':
+			return Russia, nil
+		}
+		return "", ErrInvalidPhone
+	case strings.HasPrefix(digits, "996"):
+		if len(digits) != 12 {
+			return "", ErrInvalidPhone
+		}
+		return Kyrgyzstan, nil
+	case strings.HasPrefix(digits, "998"):
+		if len(digits) != 12 {
+			return "", ErrInvalidPhone
+		}
+		return Uzbekistan, nil
+	}
+
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", ErrInvalidPhone
+	}
+
+	return "", ErrUnsupportedPhoneCountry
+}
@@ -0,0 +1,106 @@
+package address
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillicToLatin follows ICAO Doc 9303 used in passports of Russia,
+// letters of Kazakh, Kyrgyz and Uzbek alphabets are added
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	'ә': "a", 'ғ': "g", 'қ': "q", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u",
+	'һ': "h", 'і': "i", 'ў': "o", 'ҳ': "h",
+}
+
+// latinToCyrillic is ordered by length, longer combinations go first
+var latinToCyrillic = []struct {
+	latin    string
+	cyrillic string
+}{
+	{"shch", "щ"},
+	{"iia", "ия"}, {"iiu", "ию"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"iu", "ю"}, {"yu", "ю"}, {"ia", "я"}, {"ya", "я"}, {"yo", "ё"},
+	{"a", "а"}, {"b", "б"}, {"v", "в"}, {"g", "г"}, {"d", "д"}, {"e", "е"},
+	{"z", "з"}, {"i", "и"}, {"j", "й"}, {"k", "к"}, {"l", "л"}, {"m", "м"},
+	{"n", "н"}, {"o", "о"}, {"p", "п"}, {"r", "р"}, {"s", "с"}, {"t", "т"},
+	{"u", "у"}, {"f", "ф"}, {"h", "х"}, {"c", "к"}, {"q", "к"}, {"w", "в"},
+	{"x", "кс"}, {"y", "ы"},
+}
+
+// ToLatin transliterates cyrillic letters, other characters are kept
+func ToLatin(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		lat, ok := cyrillicToLatin[unicode.ToLower(r)]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		if unicode.IsUpper(r) && lat != "" {
+			// whole word in upper case stays in upper case
+			if i+1 < len(runes) && unicode.IsUpper(runes[i+1]) || i > 0 && unicode.IsUpper(runes[i-1]) {
+				lat = strings.ToUpper(lat)
+			} else {
+				lat = strings.ToUpper(lat[:1]) + lat[1:]
+			}
+		}
+		b.WriteString(lat)
+	}
+
+	return b.String()
+}
+
+// ToCyrillic transliterates latin letters back to russian, the result
+// is best effort as latin spelling of the names is ambiguous
+func ToCyrillic(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			b.WriteRune(r)
+			i++
+			continue
+		}
+
+		rest := strings.ToLower(string(runes[i:]))
+		for _, p := range latinToCyrillic {
+			if !strings.HasPrefix(rest, p.latin) {
+				continue
+			}
+			cyr := p.cyrillic
+			// y after vowel is й: Nikolay, Dmitriy
+			if p.latin == "y" && i > 0 && strings.ContainsRune("aeiouy", unicode.ToLower(runes[i-1])) {
+				cyr = "й"
+			}
+			if unicode.IsUpper(r) {
+				cr := []rune(cyr)
+				cr[0] = unicode.ToUpper(cr[0])
+				cyr = string(cr)
+			}
+			b.WriteString(cyr)
+			i += len(p.latin)
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// HasCyrillic checks if string contains any cyrillic letter
+func HasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -9,12 +9,27 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
 type KDNiaoConfig struct {
-	KdnBusinessId string `split_words:"true" required:"true"`
-	KdnApiSecret  string `split_words:"true" required:"true"`
+	KdnBusinessId string        `split_words:"true" required:"true"`
+	KdnApiSecret  string        `split_words:"true" required:"true"`
+	KdnScheme     string        `split_words:"true" default:"https"`
+	KdnHost       string        `split_words:"true" default:"api.kdniao.com"`
+	KdnPath       string        `split_words:"true" default:"/Ebusiness/EbusinessOrderHandle.aspx"`
+	KdnTimeout    time.Duration `split_words:"true" default:"5s"`
+	// KdnIdentifyRequestType is the RequestType of 单号识别 API
+	KdnIdentifyRequestType int           `split_words:"true" default:"2002"`
+	KdnCacheTtl            time.Duration `split_words:"true" default:"72h"`
+}
+
+// SourceCache keeps results of track code identification
+// to not ask 快递鸟 for the same track code over and over again
+type SourceCache interface {
+	GetSource(code string) (*SourceResponse, bool)
+	SetSource(code string, resp *SourceResponse) error
 }
 
 // KDNNiaoApi provides some methods to get parcel info from
@@ -22,14 +37,28 @@ type KDNiaoConfig struct {
 type KDNiaoApi struct {
 	KDNiaoConfig
 	httpC *http.Client
+	cache SourceCache
 }
 
-func NewKDNiaoApi(config KDNiaoConfig) *KDNiaoApi {
+// NewKDNiaoApi creates KDNiaoApi. cache could be nil,
+// then every request will be sent to 快递鸟
+func NewKDNiaoApi(config KDNiaoConfig, cache SourceCache) *KDNiaoApi {
+	if config.KdnScheme == "" {
+		config.KdnScheme = "https"
+	}
+	if config.KdnTimeout == 0 {
+		config.KdnTimeout = 5 * time.Second
+	}
+	if config.KdnIdentifyRequestType == 0 {
+		config.KdnIdentifyRequestType = 2002
+	}
+
 	return &KDNiaoApi{
 		KDNiaoConfig: config,
 		httpC: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout: config.KdnTimeout,
 		},
+		cache: cache,
 	}
 }
 
@@ -42,29 +71,56 @@ func (kd KDNiaoApi) SignedRequest(req []byte) string {
 	return signedData
 }
 
-// GetSourceByTrack fetches information of the parcel by its code
+// requestUrl builds url of 快递鸟 API for the given request type and data
+func (kd KDNiaoApi) requestUrl(requestType int, data []byte) string {
+	params := url.Values{}
+	params.Set("EBusinessID", kd.KdnBusinessId)
+	params.Set("DataType", "2")
+	params.Set("DataSign", kd.SignedRequest(data))
+	params.Set("RequestType", strconv.Itoa(requestType))
+	params.Set("RequestData", string(data))
+
+	u := url.URL{
+		Scheme:   kd.KdnScheme,
+		Host:     kd.KdnHost,
+		Path:     kd.KdnPath,
+		RawQuery: params.Encode(),
+	}
+
+	return u.String()
+}
+
+// GetSourceByTrack fetches information of the parcel by its code.
+// Successful responses are cached
 func (kd KDNiaoApi) GetSourceByTrack(code string) (*SourceResponse, error) {
+	if kd.cache != nil {
+		cached, ok := kd.cache.GetSource(code)
+		if ok {
+			return cached, nil
+		}
+	}
+
 	reqData := map[string]string{
 		"LogisticCode": code,
 	}
 
 	jsonReq, _ := json.Marshal(reqData)
-	reqStr := "http://api.kdniao.com/Ebusiness/EbusinessOrderHandle.aspx?EBusinessID=%s&DataType=%d&DataSign=%s&RequestType=%d&RequestData=%s"
-	reqUrl := fmt.Sprintf(reqStr, kd.KdnBusinessId, 2, url.QueryEscape(kd.SignedRequest(jsonReq)), 2002, url.QueryEscape(string(jsonReq)))
 
-	u, err := url.Parse(reqUrl)
+	req, err := http.NewRequest(http.MethodPost, kd.requestUrl(kd.KdnIdentifyRequestType, jsonReq), nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, "wrong url")
 	}
 
-	req, _ := http.NewRequest("POST", u.String(), nil)
-
 	resp, err := kd.httpC.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("kdniao responded with status %d", resp.StatusCode)
+	}
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -77,6 +133,10 @@ func (kd KDNiaoApi) GetSourceByTrack(code string) (*SourceResponse, error) {
 		return nil, err
 	}
 
+	if kd.cache != nil && jsonResp.Success && len(jsonResp.Shippers) > 0 {
+		_ = kd.cache.SetSource(code, &jsonResp)
+	}
+
 	return &jsonResp, nil
 }
 
@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/api/kdniaotest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type memSourceCache map[string]*api.SourceResponse
+
+func (m memSourceCache) GetSource(code string) (*api.SourceResponse, bool) {
+	r, ok := m[code]
+	return r, ok
+}
+
+func (m memSourceCache) SetSource(code string, resp *api.SourceResponse) error {
+	m[code] = resp
+	return nil
+}
+
+func newTestKDNiaoApi(srv *kdniaotest.Server, cache api.SourceCache) *api.KDNiaoApi {
+	return api.NewKDNiaoApi(api.KDNiaoConfig{
+		KdnBusinessId: "1237100",
+		KdnApiSecret:  "secret",
+		KdnScheme:     "http",
+		KdnHost:       srv.Host(),
+		KdnPath:       "/Ebusiness/EbusinessOrderHandle.aspx",
+	}, cache)
+}
+
+func TestKDNiaoApi_GetSourceByTrack(t *testing.T) {
+	srv := kdniaotest.NewServer()
+	defer srv.Close()
+
+	kd := newTestKDNiaoApi(srv, nil)
+	resp, err := kd.GetSourceByTrack("SF1241923123")
+	if assert.NoError(t, err) {
+		assert.True(t, resp.Success)
+		assert.Equal(t, "SF", resp.Shippers[0].ShipperCode)
+	}
+
+	resp, err = kd.GetSourceByTrack("unknown")
+	if assert.NoError(t, err) {
+		assert.False(t, resp.Success)
+		assert.Empty(t, resp.Shippers)
+	}
+}
+
+func TestKDNiaoApi_GetSourceByTrackCached(t *testing.T) {
+	srv := kdniaotest.NewServer()
+	defer srv.Close()
+
+	cache := memSourceCache{}
+	kd := newTestKDNiaoApi(srv, cache)
+
+	for i := 0; i < 3; i++ {
+		resp, err := kd.GetSourceByTrack("YT4536238912033")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "YTO", resp.Shippers[0].ShipperCode)
+		}
+	}
+	assert.Equal(t, 1, srv.Requests())
+
+	// unidentified track codes are not cached
+	for i := 0; i < 2; i++ {
+		_, err := kd.GetSourceByTrack("unknown")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, srv.Requests())
+}
@@ -0,0 +1,98 @@
+// Package kdniaotest provides a stub of 快递鸟 API which responds
+// with recorded responses, so KDNiaoApi could be used without network
+package kdniaotest
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+// Recordings are responses of the real 快递鸟 API keyed by the track code
+var Recordings = map[string]string{
+	"SF1241923123":    `{"EBusinessID":"1237100","Success":true,"LogisticCode":"SF1241923123","Shippers":[{"ShipperCode":"SF","ShipperName":"顺丰速运"}]}`,
+	"YT4536238912033": `{"EBusinessID":"1237100","Success":true,"LogisticCode":"YT4536238912033","Shippers":[{"ShipperCode":"YTO","ShipperName":"圆通速递"}]}`,
+	"773049312340011": `{"EBusinessID":"1237100","Success":true,"LogisticCode":"773049312340011","Shippers":[{"ShipperCode":"STO","ShipperName":"申通快递"},{"ShipperCode":"HTKY","ShipperName":"百世快递"}]}`,
+}
+
+// Server is a stub of 快递鸟 API
+type Server struct {
+	*httptest.Server
+	mu         sync.Mutex
+	recordings map[string]string
+	requests   int
+}
+
+// NewServer starts stub server with default Recordings
+func NewServer() *Server {
+	s := &Server{
+		recordings: make(map[string]string),
+	}
+	for k, v := range Recordings {
+		s.recordings[k] = v
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Record adds or replaces response for the track code
+func (s *Server) Record(code string, response string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordings[code] = response
+}
+
+// Requests returns count of requests the server received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Host returns host of the server to be used as KdnHost
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Host
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	if r.URL.Query().Get("EBusinessID") == "" || r.URL.Query().Get("DataSign") == "" {
+		http.Error(w, "missing EBusinessID or DataSign", http.StatusBadRequest)
+		return
+	}
+
+	var data struct {
+		LogisticCode string `json:"LogisticCode"`
+	}
+	err := json.Unmarshal([]byte(r.URL.Query().Get("RequestData")), &data)
+	if err != nil {
+		http.Error(w, "wrong RequestData", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	resp, ok := s.recordings[data.LogisticCode]
+	s.mu.Unlock()
+	if !ok {
+		// 快递鸟 responds this way when track code could not be identified
+		b, _ := json.Marshal(api.SourceResponse{
+			LogisticCode: data.LogisticCode,
+			Shippers:     []api.Shipper{},
+			EBusinessID:  r.URL.Query().Get("EBusinessID"),
+			Code:         "201",
+			Success:      false,
+		})
+		resp = string(b)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write([]byte(resp))
+}
@@ -0,0 +1,7 @@
+// Package boltdb contains stores of the data which is kept
+// locally by warehouse client and is not a part of FileMaker database
+package boltdb
+
+import "github.com/pkg/errors"
+
+var ErrNotFound = errors.New("record_not_found")
@@ -0,0 +1,129 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	bolt "go.etcd.io/bbolt"
+)
+
+// deliveryLog keeps deliveries by id with two indexes, so neither
+// resuming on start nor listing has to read the whole log: pending
+// deliveries and deliveries by owner (shipment or subscription)
+type deliveryLog struct {
+	// prefix of the ids, e.g. PD
+	prefix  string
+	bucket  []byte
+	pending []byte
+	byOwner []byte
+}
+
+// init creates buckets of the log. Indexes of the log created before
+// them are built once, owner is read from the JSON field ownerField
+func (l deliveryLog) init(tx *bolt.Tx, ownerField string) error {
+	fresh := tx.Bucket(l.pending) == nil
+	for _, name := range [][]byte{l.bucket, l.pending, l.byOwner} {
+		_, err := tx.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+	}
+	if !fresh {
+		return nil
+	}
+
+	return tx.Bucket(l.bucket).ForEach(func(k, v []byte) error {
+		var fields map[string]interface{}
+		err := json.Unmarshal(v, &fields)
+		if err != nil {
+			return err
+		}
+		owner, _ := fields[ownerField].(string)
+		status, _ := fields["status"].(string)
+
+		return l.index(tx, string(k), owner, delivery.Status(status))
+	})
+}
+
+func (l deliveryLog) nextID(tx *bolt.Tx) (string, error) {
+	seq, err := tx.Bucket(l.bucket).NextSequence()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%06d", l.prefix, seq), nil
+}
+
+// put saves delivery and updates indexes
+func (l deliveryLog) put(tx *bolt.Tx, id string, owner string, status delivery.Status, d interface{}) error {
+	err := putJSON(tx.Bucket(l.bucket), id, d)
+	if err != nil {
+		return err
+	}
+
+	return l.index(tx, id, owner, status)
+}
+
+func (l deliveryLog) index(tx *bolt.Tx, id string, owner string, status delivery.Status) error {
+	err := tx.Bucket(l.byOwner).Put(ownerKey(owner, id), nil)
+	if err != nil {
+		return err
+	}
+
+	pending := tx.Bucket(l.pending)
+	if status == delivery.Pending {
+		return pending.Put([]byte(id), nil)
+	}
+
+	return pending.Delete([]byte(id))
+}
+
+func (l deliveryLog) pendingIDs(tx *bolt.Tx) []string {
+	var ids []string
+	c := tx.Bucket(l.pending).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		ids = append(ids, string(k))
+	}
+
+	return ids
+}
+
+// pageIDs returns ids on the page from the newest to the oldest
+// and the total count, owner filters deliveries if it's not empty
+func (l deliveryLog) pageIDs(tx *bolt.Tx, owner string, page int, perPage int) ([]string, int) {
+	skip := (page - 1) * perPage
+	var ids []string
+	total := 0
+	add := func(id []byte) {
+		if total >= skip && len(ids) < perPage {
+			ids = append(ids, string(id))
+		}
+		total++
+	}
+
+	if owner == "" {
+		c := tx.Bucket(l.bucket).Cursor()
+		for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+			add(k)
+		}
+		return ids, total
+	}
+
+	prefix := ownerKey(owner, "")
+	var owned [][]byte
+	c := tx.Bucket(l.byOwner).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		owned = append(owned, k[len(prefix):])
+	}
+	for i := len(owned) - 1; i >= 0; i-- {
+		add(owned[i])
+	}
+
+	return ids, total
+}
+
+// ownerKey is <owner>/<id>
+func ownerKey(owner string, id string) []byte {
+	return []byte(owner + "/" + id)
+}
@@ -0,0 +1,145 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"time"
+)
+
+var DispatchesBucket = []byte("dispatches")
+
+// DispatchStore keeps dispatches keyed by dispatch id
+type DispatchStore struct {
+	db *bolt.DB
+}
+
+func NewDispatchStore(db *bolt.DB) (*DispatchStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(DispatchesBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DispatchStore{db: db}, nil
+}
+
+// CreateDispatch saves new dispatch with the next id, e.g. DSP000012
+func (s *DispatchStore) CreateDispatch(d logistics.Dispatch) (logistics.Dispatch, error) {
+	err := d.Validate()
+	if err != nil {
+		return logistics.Dispatch{}, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(DispatchesBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		d.ID = fmt.Sprintf("DSP%06d", seq)
+
+		return putJSON(b, d.ID, d)
+	})
+
+	return d, err
+}
+
+func (s *DispatchStore) GetDispatch(id string) (logistics.Dispatch, error) {
+	var d logistics.Dispatch
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(DispatchesBucket), id, &d)
+	})
+
+	return d, err
+}
+
+// ListDispatches returns dispatches from the newest to the oldest
+func (s *DispatchStore) ListDispatches() ([]logistics.Dispatch, error) {
+	dispatches := []logistics.Dispatch{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(DispatchesBucket).ForEach(func(k, v []byte) error {
+			var d logistics.Dispatch
+			err := json.Unmarshal(v, &d)
+			if err != nil {
+				return err
+			}
+			dispatches = append(dispatches, d)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(dispatches, func(i, j int) bool {
+		return dispatches[i].ID > dispatches[j].ID
+	})
+
+	return dispatches, nil
+}
+
+// AssignShipment adds shipment to the dispatch. Shipment
+// could be assigned only to one dispatch
+func (s *DispatchStore) AssignShipment(id string, sm logistics.Shipment, now time.Time) (logistics.Dispatch, error) {
+	var d logistics.Dispatch
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(DispatchesBucket)
+		err := getJSON(b, id, &d)
+		if err != nil {
+			return err
+		}
+
+		err = b.ForEach(func(k, v []byte) error {
+			if string(k) == id {
+				return nil
+			}
+			var other logistics.Dispatch
+			err := json.Unmarshal(v, &other)
+			if err != nil {
+				return err
+			}
+			if other.HasShipment(sm.Code) {
+				return errors.WithMessagef(logistics.ErrDispatchShipmentAssigned, "dispatch %s", other.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = d.Assign(sm, now)
+		if err != nil {
+			return err
+		}
+
+		return putJSON(b, d.ID, d)
+	})
+
+	return d, err
+}
+
+func (s *DispatchStore) UnassignShipment(id, code string) (logistics.Dispatch, error) {
+	var d logistics.Dispatch
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(DispatchesBucket)
+		err := getJSON(b, id, &d)
+		if err != nil {
+			return err
+		}
+
+		err = d.Unassign(code)
+		if err != nil {
+			return err
+		}
+
+		return putJSON(b, d.ID, d)
+	})
+
+	return d, err
+}
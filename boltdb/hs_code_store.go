@@ -0,0 +1,92 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	bolt "go.etcd.io/bbolt"
+)
+
+var HSCodesBucket = []byte("hs_codes")
+
+// HSCodeStore keeps HS codes reference table keyed by code
+type HSCodeStore struct {
+	db *bolt.DB
+}
+
+func NewHSCodeStore(db *bolt.DB) (*HSCodeStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(HSCodesBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &HSCodeStore{db: db}, nil
+}
+
+// ReplaceHSCodes removes all codes and saves the given ones
+func (s *HSCodeStore) ReplaceHSCodes(codes []customs.HSCode) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(HSCodesBucket)
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		b, err := tx.CreateBucket(HSCodesBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, h := range codes {
+			err = putJSON(b, h.Code, h)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *HSCodeStore) GetHSCode(code string) (customs.HSCode, error) {
+	var h customs.HSCode
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(HSCodesBucket), customs.NormalizeHSCode(code), &h)
+	})
+
+	return h, err
+}
+
+// IsEmpty returns true if reference table is not imported yet
+func (s *HSCodeStore) IsEmpty() (bool, error) {
+	var empty bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(HSCodesBucket).Cursor().First()
+		empty = k == nil
+		return nil
+	})
+
+	return empty, err
+}
+
+// SearchHSCodes returns up to limit codes matching the query
+// in order of codes, see customs.HSCode.Matches
+func (s *HSCodeStore) SearchHSCodes(query string, limit int) ([]customs.HSCode, error) {
+	codes := []customs.HSCode{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(HSCodesBucket).Cursor()
+		for k, v := c.First(); k != nil && len(codes) < limit; k, v = c.Next() {
+			var h customs.HSCode
+			err := json.Unmarshal(v, &h)
+			if err != nil {
+				return err
+			}
+			if h.Matches(query) {
+				codes = append(codes, h)
+			}
+		}
+		return nil
+	})
+
+	return codes, err
+}
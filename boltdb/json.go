@@ -0,0 +1,24 @@
+package boltdb
+
+import (
+	"encoding/json"
+	bolt "go.etcd.io/bbolt"
+)
+
+func putJSON(b *bolt.Bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(key), data)
+}
+
+func getJSON(b *bolt.Bucket, key string, v interface{}) error {
+	data := b.Get([]byte(key))
+	if data == nil {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,104 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+)
+
+var LoadingSessionsBucket = []byte("loading_sessions")
+
+// LoadingStore keeps loading sessions keyed by session id
+type LoadingStore struct {
+	db *bolt.DB
+}
+
+func NewLoadingStore(db *bolt.DB) (*LoadingStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(LoadingSessionsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadingStore{db: db}, nil
+}
+
+// CreateSession saves new session with the next id, e.g. LS000012
+func (s *LoadingStore) CreateSession(ls logistics.LoadingSession) (logistics.LoadingSession, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(LoadingSessionsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		ls.ID = fmt.Sprintf("LS%06d", seq)
+
+		return putJSON(b, ls.ID, ls)
+	})
+
+	return ls, err
+}
+
+func (s *LoadingStore) GetSession(id string) (logistics.LoadingSession, error) {
+	var ls logistics.LoadingSession
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(LoadingSessionsBucket), id, &ls)
+	})
+
+	return ls, err
+}
+
+// UpdateSession applies fn to the session and saves it in one transaction,
+// so concurrent scans of the same session are not lost
+func (s *LoadingStore) UpdateSession(id string, fn func(ls *logistics.LoadingSession) error) (logistics.LoadingSession, error) {
+	var ls logistics.LoadingSession
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(LoadingSessionsBucket)
+		err := getJSON(b, id, &ls)
+		if err != nil {
+			return err
+		}
+
+		err = fn(&ls)
+		if err != nil {
+			return err
+		}
+
+		return putJSON(b, ls.ID, ls)
+	})
+
+	return ls, err
+}
+
+// ListSessions returns sessions from the newest to the oldest,
+// only open ones if openOnly is set
+func (s *LoadingStore) ListSessions(openOnly bool) ([]logistics.LoadingSession, error) {
+	sessions := []logistics.LoadingSession{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(LoadingSessionsBucket).ForEach(func(k, v []byte) error {
+			var ls logistics.LoadingSession
+			err := json.Unmarshal(v, &ls)
+			if err != nil {
+				return err
+			}
+			if openOnly && ls.IsClosed() {
+				return nil
+			}
+			sessions = append(sessions, ls)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
@@ -0,0 +1,137 @@
+package boltdb
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/integration"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	PartnerDeliveriesBucket        = []byte("partner_deliveries")
+	PendingPartnerDeliveriesBucket = []byte("partner_deliveries_pending")
+	ShipmentPartnerDeliveryBucket  = []byte("partner_deliveries_by_shipment")
+)
+
+var partnerDeliveries = deliveryLog{
+	prefix:  "PD",
+	bucket:  PartnerDeliveriesBucket,
+	pending: PendingPartnerDeliveriesBucket,
+	byOwner: ShipmentPartnerDeliveryBucket,
+}
+
+// PartnerDeliveryStore is the log of manifests pushed to partners
+type PartnerDeliveryStore struct {
+	db *bolt.DB
+}
+
+func NewPartnerDeliveryStore(db *bolt.DB) (*PartnerDeliveryStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		return partnerDeliveries.init(tx, "shipment_code")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartnerDeliveryStore{db: db}, nil
+}
+
+// CreateDelivery saves new delivery with the next id, e.g. PD000012
+func (s *PartnerDeliveryStore) CreateDelivery(d integration.Delivery) (integration.Delivery, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		id, err := partnerDeliveries.nextID(tx)
+		if err != nil {
+			return err
+		}
+		d.ID = id
+
+		return partnerDeliveries.put(tx, d.ID, d.ShipmentCode, d.Status, d)
+	})
+
+	return d, err
+}
+
+func (s *PartnerDeliveryStore) SaveDelivery(d integration.Delivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return partnerDeliveries.put(tx, d.ID, d.ShipmentCode, d.Status, d)
+	})
+}
+
+func (s *PartnerDeliveryStore) GetDelivery(id string) (integration.Delivery, error) {
+	var d integration.Delivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(PartnerDeliveriesBucket), id, &d)
+	})
+
+	return d, err
+}
+
+// RequeueDelivery sets status of the delivered or failed delivery
+// back to pending, delivery.ErrInProgress is returned for pending one
+func (s *PartnerDeliveryStore) RequeueDelivery(id string, at time.Time) (integration.Delivery, error) {
+	var d integration.Delivery
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		err := getJSON(tx.Bucket(PartnerDeliveriesBucket), id, &d)
+		if err != nil {
+			return err
+		}
+		if d.Status == delivery.Pending {
+			return delivery.ErrInProgress
+		}
+		d.Status = delivery.Pending
+		d.UpdatedAt = at
+
+		return partnerDeliveries.put(tx, d.ID, d.ShipmentCode, d.Status, d)
+	})
+	if err != nil {
+		return integration.Delivery{}, err
+	}
+
+	return d, nil
+}
+
+// ListPendingDeliveries returns deliveries to be continued after restart
+func (s *PartnerDeliveryStore) ListPendingDeliveries() ([]integration.Delivery, error) {
+	var deliveries []integration.Delivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		deliveries, err = s.getDeliveries(tx, partnerDeliveries.pendingIDs(tx))
+		return err
+	})
+
+	return deliveries, err
+}
+
+// ListDeliveries returns the page of deliveries from the newest to the oldest
+// and the total count, filtered by shipment code if it's not empty
+func (s *PartnerDeliveryStore) ListDeliveries(shipmentCode string, page int, perPage int) ([]integration.Delivery, int, error) {
+	var deliveries []integration.Delivery
+	var total int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var ids []string
+		ids, total = partnerDeliveries.pageIDs(tx, shipmentCode, page, perPage)
+		var err error
+		deliveries, err = s.getDeliveries(tx, ids)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (s *PartnerDeliveryStore) getDeliveries(tx *bolt.Tx, ids []string) ([]integration.Delivery, error) {
+	b := tx.Bucket(PartnerDeliveriesBucket)
+	deliveries := make([]integration.Delivery, 0, len(ids))
+	for _, id := range ids {
+		var d integration.Delivery
+		err := getJSON(b, id, &d)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,134 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var PartnersBucket = []byte("partners")
+
+// PartnerStore keeps partners registry keyed by upper case partner code
+type PartnerStore struct {
+	db *bolt.DB
+}
+
+func NewPartnerStore(db *bolt.DB) (*PartnerStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(PartnersBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartnerStore{db: db}, nil
+}
+
+func partnerKey(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *PartnerStore) CreatePartner(p logistics.Partner) (logistics.Partner, error) {
+	p.Normalize()
+	err := p.Validate()
+	if err != nil {
+		return logistics.Partner{}, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PartnersBucket)
+		if b.Get([]byte(partnerKey(p.Code))) != nil {
+			return logistics.ErrPartnerExists
+		}
+		p.CreatedAt = time.Now()
+		p.UpdatedAt = p.CreatedAt
+
+		return putJSON(b, partnerKey(p.Code), p)
+	})
+
+	return p, err
+}
+
+// UpdatePartner replaces partner with the same code,
+// integration secret is kept if the new one is empty
+func (s *PartnerStore) UpdatePartner(p logistics.Partner) (logistics.Partner, error) {
+	p.Normalize()
+	err := p.Validate()
+	if err != nil {
+		return logistics.Partner{}, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PartnersBucket)
+		var existing logistics.Partner
+		err := getJSON(b, partnerKey(p.Code), &existing)
+		if err == ErrNotFound {
+			return logistics.ErrPartnerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		p.Code = existing.Code
+		if p.Integration.Secret == "" {
+			p.Integration.Secret = existing.Integration.Secret
+		}
+		p.CreatedAt = existing.CreatedAt
+		p.UpdatedAt = time.Now()
+
+		return putJSON(b, partnerKey(p.Code), p)
+	})
+
+	return p, err
+}
+
+func (s *PartnerStore) GetPartner(code string) (logistics.Partner, error) {
+	var p logistics.Partner
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(PartnersBucket), partnerKey(code), &p)
+	})
+	if err == ErrNotFound {
+		return p, logistics.ErrPartnerNotFound
+	}
+
+	return p, err
+}
+
+func (s *PartnerStore) DeletePartner(code string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PartnersBucket)
+		if b.Get([]byte(partnerKey(code))) == nil {
+			return logistics.ErrPartnerNotFound
+		}
+
+		return b.Delete([]byte(partnerKey(code)))
+	})
+}
+
+// ListPartners returns partners sorted by code
+func (s *PartnerStore) ListPartners() ([]logistics.Partner, error) {
+	partners := []logistics.Partner{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(PartnersBucket).ForEach(func(k, v []byte) error {
+			var p logistics.Partner
+			err := json.Unmarshal(v, &p)
+			if err != nil {
+				return err
+			}
+			partners = append(partners, p)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(partners, func(i, j int) bool {
+		return partners[i].Code < partners[j].Code
+	})
+
+	return partners, nil
+}
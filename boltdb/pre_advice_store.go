@@ -0,0 +1,230 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var PreAdvicesBucket = []byte("pre_advices")
+
+// PreAdviceStore keeps parcels expected by customers. Parcels are
+// keyed by customer code and normalized track code, as different
+// customers may send parcels with the same track code
+type PreAdviceStore struct {
+	db *bolt.DB
+}
+
+func NewPreAdviceStore(db *bolt.DB) (*PreAdviceStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(PreAdvicesBucket)
+		if err != nil {
+			return err
+		}
+		return migrateParcelKeys(b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreAdviceStore{db: db}, nil
+}
+
+// parcelKeySeparator can not be a part of normalized track code
+const parcelKeySeparator = "|"
+
+func parcelKey(customerCode, trackCode string) string {
+	return strings.TrimSpace(customerCode) + parcelKeySeparator + warehouse.NormalizeTrackCode(trackCode)
+}
+
+// migrateParcelKeys moves parcels saved by track code only
+// to the keys with customer code
+func migrateParcelKeys(b *bolt.Bucket) error {
+	old := make(map[string][]byte)
+	err := b.ForEach(func(k, v []byte) error {
+		if !strings.Contains(string(k), parcelKeySeparator) {
+			old[string(k)] = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range old {
+		var p warehouse.ExpectedParcel
+		err := json.Unmarshal(v, &p)
+		if err != nil {
+			return err
+		}
+		err = b.Put([]byte(parcelKey(p.CustomerCode, p.TrackCode)), v)
+		if err != nil {
+			return err
+		}
+		err = b.Delete([]byte(k))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveParcels creates or updates expected parcels. Match with
+// the entry is kept if parcel has already arrived
+func (s *PreAdviceStore) SaveParcels(parcels ...warehouse.ExpectedParcel) ([]warehouse.ExpectedParcel, error) {
+	var saved []warehouse.ExpectedParcel
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PreAdvicesBucket)
+		for _, p := range parcels {
+			err := p.Validate()
+			if err != nil {
+				return err
+			}
+
+			var existing warehouse.ExpectedParcel
+			v := b.Get([]byte(parcelKey(p.CustomerCode, p.TrackCode)))
+			if v != nil {
+				err = json.Unmarshal(v, &existing)
+				if err != nil {
+					return err
+				}
+				p.CreatedAt = existing.CreatedAt
+				p.EntryID = existing.EntryID
+				p.ArrivedAt = existing.ArrivedAt
+			} else {
+				p.CreatedAt = time.Now()
+			}
+
+			err = putJSON(b, parcelKey(p.CustomerCode, p.TrackCode), p)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// FindParcels returns parcels with the track code, filtered
+// by customer code if it's not empty
+func (s *PreAdviceStore) FindParcels(customerCode, trackCode string) ([]warehouse.ExpectedParcel, error) {
+	trackCode = warehouse.NormalizeTrackCode(trackCode)
+	parcels, err := s.ListParcels(customerCode)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []warehouse.ExpectedParcel{}
+	for _, p := range parcels {
+		if p.TrackCode == trackCode {
+			found = append(found, p)
+		}
+	}
+
+	return found, nil
+}
+
+// ListParcels returns parcels of the customer, or all parcels
+// if customerCode is empty, in order of creation
+func (s *PreAdviceStore) ListParcels(customerCode string) ([]warehouse.ExpectedParcel, error) {
+	parcels := []warehouse.ExpectedParcel{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(PreAdvicesBucket).ForEach(func(k, v []byte) error {
+			var p warehouse.ExpectedParcel
+			err := json.Unmarshal(v, &p)
+			if err != nil {
+				return err
+			}
+			if customerCode == "" || p.CustomerCode == customerCode {
+				parcels = append(parcels, p)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(parcels, func(i, j int) bool {
+		return parcels[i].CreatedAt.Before(parcels[j].CreatedAt)
+	})
+
+	return parcels, nil
+}
+
+func (s *PreAdviceStore) DeleteParcel(customerCode, trackCode string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PreAdvicesBucket)
+		key := []byte(parcelKey(customerCode, trackCode))
+		if b.Get(key) == nil {
+			return ErrNotFound
+		}
+		return b.Delete(key)
+	})
+}
+
+// Report matches parcels of the customer with the given entries
+// without saving the result
+func (s *PreAdviceStore) Report(customerCode string, entries ...warehouse.Entry) (warehouse.PreAdviceReport, error) {
+	parcels, err := s.ListParcels(customerCode)
+	if err != nil {
+		return warehouse.PreAdviceReport{}, err
+	}
+
+	return warehouse.MatchExpectedParcels(parcels, entries), nil
+}
+
+// MatchEntries marks parcels arrived with the given entries
+// and returns the report for the customer
+func (s *PreAdviceStore) MatchEntries(customerCode string, entries ...warehouse.Entry) (warehouse.PreAdviceReport, error) {
+	var report warehouse.PreAdviceReport
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(PreAdvicesBucket)
+		var parcels []warehouse.ExpectedParcel
+		err := b.ForEach(func(k, v []byte) error {
+			var p warehouse.ExpectedParcel
+			err := json.Unmarshal(v, &p)
+			if err != nil {
+				return err
+			}
+			if customerCode == "" || p.CustomerCode == customerCode {
+				parcels = append(parcels, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		wasArrived := make(map[string]bool)
+		for _, p := range parcels {
+			wasArrived[parcelKey(p.CustomerCode, p.TrackCode)] = p.IsArrived()
+		}
+
+		report = warehouse.MatchExpectedParcels(parcels, entries)
+		for _, p := range report.Arrived {
+			key := parcelKey(p.CustomerCode, p.TrackCode)
+			if wasArrived[key] {
+				continue
+			}
+			err = putJSON(b, key, p)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return report, err
+}
@@ -0,0 +1,96 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+var TrackSourcesBucket = []byte("track_sources")
+
+type cachedSource struct {
+	ExpiresAt time.Time           `json:"expires_at"`
+	Response  *api.SourceResponse `json:"response"`
+}
+
+// SourceCache stores results of 快递鸟 track code identification
+// and implements api.SourceCache
+type SourceCache struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+func NewSourceCache(db *bolt.DB, ttl time.Duration) (*SourceCache, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(TrackSourcesBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SourceCache{db: db, ttl: ttl}, nil
+}
+
+// GetSource returns cached response if it is not expired yet
+func (c *SourceCache) GetSource(code string) (*api.SourceResponse, bool) {
+	var cs cachedSource
+	err := c.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(TrackSourcesBucket).Get([]byte(code))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &cs)
+	})
+	if err != nil || cs.Response == nil {
+		return nil, false
+	}
+
+	if time.Now().After(cs.ExpiresAt) {
+		return nil, false
+	}
+
+	return cs.Response, true
+}
+
+func (c *SourceCache) SetSource(code string, resp *api.SourceResponse) error {
+	b, err := json.Marshal(cachedSource{
+		ExpiresAt: time.Now().Add(c.ttl),
+		Response:  resp,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(TrackSourcesBucket).Put([]byte(code), b)
+	})
+}
+
+// DeleteExpired removes all expired records from the cache
+func (c *SourceCache) DeleteExpired() error {
+	now := time.Now()
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TrackSourcesBucket)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var cs cachedSource
+			if err := json.Unmarshal(v, &cs); err != nil || now.After(cs.ExpiresAt) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,93 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	bolt "go.etcd.io/bbolt"
+)
+
+var StatusHistoryBucket = []byte("shipment_status_history")
+
+// StatusHistoryStore keeps status changes of shipments. Every
+// shipment has its own bucket with changes in order of recording
+type StatusHistoryStore struct {
+	db *bolt.DB
+}
+
+func NewStatusHistoryStore(db *bolt.DB) (*StatusHistoryStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(StatusHistoryBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusHistoryStore{db: db}, nil
+}
+
+func (s *StatusHistoryStore) AddChange(c logistics.StatusChange) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(StatusHistoryBucket).CreateBucketIfNotExists([]byte(c.Code))
+		if err != nil {
+			return err
+		}
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return putJSON(b, string(itob(seq)), c)
+	})
+}
+
+// ListChanges returns status changes of the shipment in order of recording
+func (s *StatusHistoryStore) ListChanges(code string) ([]logistics.StatusChange, error) {
+	changes := []logistics.StatusChange{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(StatusHistoryBucket).Bucket([]byte(code))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var c logistics.StatusChange
+			err := json.Unmarshal(v, &c)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, c)
+			return nil
+		})
+	})
+
+	return changes, err
+}
+
+// LastStatus returns the last recorded status of the shipment
+func (s *StatusHistoryStore) LastStatus(code string) (logistics.ShipmentStatus, bool, error) {
+	var c logistics.StatusChange
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(StatusHistoryBucket).Bucket([]byte(code))
+		if b == nil {
+			return nil
+		}
+		_, v := b.Cursor().Last()
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &c)
+	})
+
+	return c.To, found, err
+}
+
+// itob returns big endian representation of v, so keys are sorted
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,84 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
+	bolt "go.etcd.io/bbolt"
+)
+
+var TariffsBucket = []byte("tariffs")
+
+// TariffStore keeps tariffs keyed by transfer point and delivery method
+type TariffStore struct {
+	db             *bolt.DB
+	transferPoints *logistics.TransferPointRegistry
+}
+
+func NewTariffStore(db *bolt.DB, tps *logistics.TransferPointRegistry) (*TariffStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(TariffsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TariffStore{db: db, transferPoints: tps}, nil
+}
+
+// ReplaceTariffs removes all tariffs and saves the given ones
+func (s *TariffStore) ReplaceTariffs(tariffs []pricing.Tariff) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(TariffsBucket)
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		b, err := tx.CreateBucket(TariffsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tariffs {
+			err = t.Validate(s.transferPoints)
+			if err != nil {
+				return err
+			}
+			err = putJSON(b, t.Key(), t)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *TariffStore) ListTariffs() ([]pricing.Tariff, error) {
+	tariffs := []pricing.Tariff{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(TariffsBucket).ForEach(func(k, v []byte) error {
+			var t pricing.Tariff
+			err := json.Unmarshal(v, &t)
+			if err != nil {
+				return err
+			}
+			tariffs = append(tariffs, t)
+			return nil
+		})
+	})
+
+	return tariffs, err
+}
+
+func (s *TariffStore) GetTariff(transferPoint string, dm logistics.DeliveryMethod) (pricing.Tariff, error) {
+	var t pricing.Tariff
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(TariffsBucket), pricing.TariffKey(transferPoint, dm), &t)
+	})
+	if err == ErrNotFound {
+		return t, pricing.ErrTariffNotFound
+	}
+
+	return t, err
+}
@@ -0,0 +1,250 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"time"
+)
+
+var (
+	WebhooksBucket                    = []byte("webhooks")
+	WebhookDeliveriesBucket           = []byte("webhook_deliveries")
+	PendingWebhookDeliveriesBucket    = []byte("webhook_deliveries_pending")
+	SubscriptionWebhookDeliveryBucket = []byte("webhook_deliveries_by_subscription")
+)
+
+var webhookDeliveries = deliveryLog{
+	prefix:  "WD",
+	bucket:  WebhookDeliveriesBucket,
+	pending: PendingWebhookDeliveriesBucket,
+	byOwner: SubscriptionWebhookDeliveryBucket,
+}
+
+// WebhookStore keeps webhook subscriptions and the log of their deliveries
+type WebhookStore struct {
+	db *bolt.DB
+}
+
+func NewWebhookStore(db *bolt.DB) (*WebhookStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(WebhooksBucket)
+		if err != nil {
+			return err
+		}
+		return webhookDeliveries.init(tx, "subscription_id")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookStore{db: db}, nil
+}
+
+// CreateSubscription saves new subscription with the next id, e.g. WH000003
+func (s *WebhookStore) CreateSubscription(sub webhook.Subscription) (webhook.Subscription, error) {
+	sub.Normalize()
+	err := sub.Validate()
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(WebhooksBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		sub.ID = fmt.Sprintf("WH%06d", seq)
+		sub.CreatedAt = time.Now()
+		sub.UpdatedAt = sub.CreatedAt
+
+		return putJSON(b, sub.ID, sub)
+	})
+
+	return sub, err
+}
+
+// UpdateSubscription replaces subscription with the same id,
+// the secret is kept if it's empty
+func (s *WebhookStore) UpdateSubscription(sub webhook.Subscription) (webhook.Subscription, error) {
+	sub.Normalize()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(WebhooksBucket)
+		var existing webhook.Subscription
+		err := getJSON(b, sub.ID, &existing)
+		if err == ErrNotFound {
+			return webhook.ErrSubscriptionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if sub.Secret == "" {
+			sub.Secret = existing.Secret
+		}
+		err = sub.Validate()
+		if err != nil {
+			return err
+		}
+		sub.CreatedAt = existing.CreatedAt
+		sub.UpdatedAt = time.Now()
+
+		return putJSON(b, sub.ID, sub)
+	})
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (s *WebhookStore) GetSubscription(id string) (webhook.Subscription, error) {
+	var sub webhook.Subscription
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(WebhooksBucket), id, &sub)
+	})
+	if err == ErrNotFound {
+		return sub, webhook.ErrSubscriptionNotFound
+	}
+
+	return sub, err
+}
+
+func (s *WebhookStore) DeleteSubscription(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(WebhooksBucket)
+		if b.Get([]byte(id)) == nil {
+			return webhook.ErrSubscriptionNotFound
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
+// ListSubscriptions returns subscriptions sorted by id
+func (s *WebhookStore) ListSubscriptions() ([]webhook.Subscription, error) {
+	subscriptions := []webhook.Subscription{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(WebhooksBucket).ForEach(func(k, v []byte) error {
+			var sub webhook.Subscription
+			err := json.Unmarshal(v, &sub)
+			if err != nil {
+				return err
+			}
+			subscriptions = append(subscriptions, sub)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+
+	return subscriptions, nil
+}
+
+// CreateDelivery saves new delivery with the next id, e.g. WD000012
+func (s *WebhookStore) CreateDelivery(d webhook.Delivery) (webhook.Delivery, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		id, err := webhookDeliveries.nextID(tx)
+		if err != nil {
+			return err
+		}
+		d.ID = id
+
+		return webhookDeliveries.put(tx, d.ID, d.SubscriptionID, d.Status, d)
+	})
+
+	return d, err
+}
+
+func (s *WebhookStore) SaveDelivery(d webhook.Delivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return webhookDeliveries.put(tx, d.ID, d.SubscriptionID, d.Status, d)
+	})
+}
+
+func (s *WebhookStore) GetDelivery(id string) (webhook.Delivery, error) {
+	var d webhook.Delivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getJSON(tx.Bucket(WebhookDeliveriesBucket), id, &d)
+	})
+
+	return d, err
+}
+
+// RequeueDelivery sets status of the delivered or failed delivery
+// back to pending, delivery.ErrInProgress is returned for pending one
+func (s *WebhookStore) RequeueDelivery(id string, at time.Time) (webhook.Delivery, error) {
+	var d webhook.Delivery
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		err := getJSON(tx.Bucket(WebhookDeliveriesBucket), id, &d)
+		if err != nil {
+			return err
+		}
+		if d.Status == delivery.Pending {
+			return delivery.ErrInProgress
+		}
+		d.Status = delivery.Pending
+		d.UpdatedAt = at
+
+		return webhookDeliveries.put(tx, d.ID, d.SubscriptionID, d.Status, d)
+	})
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+
+	return d, nil
+}
+
+// ListPendingDeliveries returns deliveries to be continued after restart
+func (s *WebhookStore) ListPendingDeliveries() ([]webhook.Delivery, error) {
+	var deliveries []webhook.Delivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		deliveries, err = s.getDeliveries(tx, webhookDeliveries.pendingIDs(tx))
+		return err
+	})
+
+	return deliveries, err
+}
+
+// ListDeliveries returns the page of deliveries from the newest to the oldest
+// and the total count, filtered by subscription id if it's not empty
+func (s *WebhookStore) ListDeliveries(subscriptionID string, page int, perPage int) ([]webhook.Delivery, int, error) {
+	var deliveries []webhook.Delivery
+	var total int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var ids []string
+		ids, total = webhookDeliveries.pageIDs(tx, subscriptionID, page, perPage)
+		var err error
+		deliveries, err = s.getDeliveries(tx, ids)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (s *WebhookStore) getDeliveries(tx *bolt.Tx, ids []string) ([]webhook.Delivery, error) {
+	b := tx.Bucket(WebhookDeliveriesBucket)
+	deliveries := make([]webhook.Delivery, 0, len(ids))
+	for _, id := range ids {
+		var d webhook.Delivery
+		err := getJSON(b, id, &d)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"github.com/amanbolat/ca-warehouse-client/common"
 	"github.com/amanbolat/ca-warehouse-client/config"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
 	"github.com/amanbolat/ca-warehouse-client/server"
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"net/http"
 	"os"
 )
 
@@ -55,6 +57,25 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "mock-partner",
+				Usage: "run endpoint accepting pushed manifests and webhooks for local testing",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "port", Value: 9090, Usage: "port to listen on"},
+					&cli.StringFlag{Name: "secret", Usage: "partner integration or webhook secret"},
+					&cli.IntFlag{Name: "fail", Usage: "number of the first requests to fail"},
+				},
+				Action: func(context *cli.Context) error {
+					mock := delivery.NewMockReceiver(context.String("secret"), context.Int("fail"))
+					mock.OnReceive = func(r delivery.MockRequest) {
+						logger.Infof("delivery %s received: %s", r.DeliveryID, r.Body)
+					}
+					addr := fmt.Sprintf(":%d", context.Int("port"))
+					logger.Infof("mock partner listens on %s", addr)
+
+					return http.ListenAndServe(addr, mock)
+				},
+			},
 			{
 				Name:  "run",
 				Usage: "run warehouse client",
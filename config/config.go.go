@@ -1,6 +1,13 @@
 package config
 
-import "github.com/amanbolat/ca-warehouse-client/api"
+import (
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/documents"
+	"github.com/amanbolat/ca-warehouse-client/integration"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
+)
 
 type Config struct {
 	FmHost         string `split_words:"true" required:"true"`
@@ -13,5 +20,13 @@ type Config struct {
 	Port           int    `split_words:"true" required:"true"`
 	FontPath       string `split_words:"true" required:"true"`
 	BoltDbPath     string `split_words:"true" required:"true"`
+	// Warehouse is the code of the warehouse shipments and entries
+	// are polled and listed for, new consolidations depart from it
+	Warehouse string `default:"GZWH2"`
 	api.KDNiaoConfig
+	pricing.VolumetricConfig
+	logistics.TransferPointsConfig
+	documents.Config
+	integration.PushConfig
+	webhook.DispatchConfig
 }
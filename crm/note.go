@@ -1,24 +1,65 @@
 package crm
 
-import "time"
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// MaxNoteLength is the max count of characters in the note
+const MaxNoteLength = 1000
+
+var (
+	ErrEmptyNote   = errors.New("note content is empty")
+	ErrNoteTooLong = errors.Errorf("note content is longer than %d characters", MaxNoteLength)
+)
 
 type Note struct {
 	ID        string    `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 	Content   string    `json:"content"`
+	Author    string    `json:"author"`
+	// UpdatedBy is the user, who edited the note last time
+	UpdatedBy string `json:"updated_by"`
+	// RelatedID is id of the shipment or the entry note belongs to
+	RelatedID  string `json:"related_id"`
+	FMRecordID int    `json:"-"`
+}
+
+// Validate trims content of the note and checks its length
+func (n *Note) Validate() error {
+	n.Content = strings.TrimSpace(n.Content)
+	if n.Content == "" {
+		return ErrEmptyNote
+	}
+	if len([]rune(n.Content)) > MaxNoteLength {
+		return ErrNoteTooLong
+	}
+
+	return nil
 }
 
 type FileMakerNote struct {
-	ID          string    `json:"Id_note"`
-	DateCreated time.Time `json:"Date_Created_Timestamp"`
-	Content     string    `json:"NoteContent"`
-	RelatedId   string    `json:"Id_relationID"`
+	ID           string    `json:"Id_note"`
+	DateCreated  time.Time `json:"Date_Created_Timestamp"`
+	DateModified time.Time `json:"Date_Modified_Timestamp"`
+	Content      string    `json:"NoteContent"`
+	Author       string    `json:"Author"`
+	UpdatedBy    string    `json:"UpdatedBy"`
+	RelatedId    string    `json:"Id_relationID"`
+	FMRecordID   int       `json:"-"`
 }
 
 func (fn FileMakerNote) ToNote() *Note {
 	return &Note{
-		ID:        fn.ID,
-		CreatedAt: fn.DateCreated,
-		Content:   fn.Content,
+		ID:         fn.ID,
+		CreatedAt:  fn.DateCreated,
+		UpdatedAt:  fn.DateModified,
+		Content:    fn.Content,
+		Author:     fn.Author,
+		UpdatedBy:  fn.UpdatedBy,
+		RelatedID:  fn.RelatedId,
+		FMRecordID: fn.FMRecordID,
 	}
 }
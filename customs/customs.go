@@ -0,0 +1,105 @@
+// Package customs contains customs attributes of goods
+// and the reference table of HS codes
+package customs
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"strings"
+	"unicode"
+)
+
+// HSCodeLength is the length of the EAEU commodity code (ТН ВЭД ЕАЭС)
+const HSCodeLength = 10
+
+var (
+	ErrInvalidHSCode  = errors.Errorf("customs: hs code should have %d digits", HSCodeLength)
+	ErrInvalidCountry = errors.New("customs: country of origin should be ISO 3166 alpha-2 code")
+	ErrNegativeValue  = errors.New("customs: unit value and net weight should not be negative")
+)
+
+// Attributes are customs data of the product line
+type Attributes struct {
+	HSCode   string `json:"hs_code"`
+	Material string `json:"material"`
+	Brand    string `json:"brand"`
+	// CountryOfOrigin is ISO 3166 alpha-2 code, e.g. CN
+	CountryOfOrigin string `json:"country_of_origin"`
+	// UnitValue is the value of one piece in the invoice currency
+	UnitValue decimal.Decimal `json:"unit_value"`
+	// NetWeight in kg
+	NetWeight decimal.Decimal `json:"net_weight"`
+}
+
+// NormalizeHSCode removes spaces and dots, e.g. 6201.93 000 0 -> 6201930000
+func NormalizeHSCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '.' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+func ValidHSCode(code string) bool {
+	if len(code) != HSCodeLength {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Attributes) Normalize() {
+	a.HSCode = NormalizeHSCode(a.HSCode)
+	a.Material = strings.TrimSpace(a.Material)
+	a.Brand = strings.TrimSpace(a.Brand)
+	a.CountryOfOrigin = strings.ToUpper(strings.TrimSpace(a.CountryOfOrigin))
+}
+
+// Validate checks format of the filled attributes,
+// use Missing to check completeness
+func (a Attributes) Validate() error {
+	if a.HSCode != "" && !ValidHSCode(a.HSCode) {
+		return ErrInvalidHSCode
+	}
+	if a.CountryOfOrigin != "" {
+		if len(a.CountryOfOrigin) != 2 || strings.IndexFunc(a.CountryOfOrigin, func(r rune) bool {
+			return r < 'A' || r > 'Z'
+		}) >= 0 {
+			return ErrInvalidCountry
+		}
+	}
+	if a.UnitValue.IsNegative() || a.NetWeight.IsNegative() {
+		return ErrNegativeValue
+	}
+
+	return nil
+}
+
+// Missing returns json names of the attributes required for declaration
+// which are empty. Brand is not required
+func (a Attributes) Missing() []string {
+	var missing []string
+	if a.HSCode == "" {
+		missing = append(missing, "hs_code")
+	}
+	if a.Material == "" {
+		missing = append(missing, "material")
+	}
+	if a.CountryOfOrigin == "" {
+		missing = append(missing, "country_of_origin")
+	}
+	if a.UnitValue.LessThanOrEqual(decimal.Zero) {
+		missing = append(missing, "unit_value")
+	}
+	if a.NetWeight.LessThanOrEqual(decimal.Zero) {
+		missing = append(missing, "net_weight")
+	}
+
+	return missing
+}
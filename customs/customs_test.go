@@ -0,0 +1,55 @@
+package customs_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	a := customs.Attributes{
+		HSCode:          " 6201.93 000 0",
+		Material:        " 涤纶 ",
+		CountryOfOrigin: "cn",
+	}
+	a.Normalize()
+	assert.Equal(t, "6201930000", a.HSCode)
+	assert.Equal(t, "涤纶", a.Material)
+	assert.Equal(t, "CN", a.CountryOfOrigin)
+	assert.NoError(t, a.Validate())
+	assert.Equal(t, []string{"unit_value", "net_weight"}, a.Missing())
+
+	a.UnitValue = decimal.NewFromFloat(1.5)
+	a.NetWeight = decimal.NewFromInt(10)
+	assert.Empty(t, a.Missing())
+
+	a.HSCode = "620193"
+	assert.Equal(t, customs.ErrInvalidHSCode, a.Validate())
+	a.HSCode = "6201930000"
+	a.CountryOfOrigin = "CHN"
+	assert.Equal(t, customs.ErrInvalidCountry, a.Validate())
+	a.CountryOfOrigin = "CN"
+	a.UnitValue = decimal.NewFromInt(-1)
+	assert.Equal(t, customs.ErrNegativeValue, a.Validate())
+}
+
+func TestParseHSCodesCSV(t *testing.T) {
+	codes, err := customs.ParseHSCodesCSV(strings.NewReader(`code,description_zh,description_ru,unit
+6201.93 000 0,男式防寒短上衣,Куртки мужские,шт
+8539 50 000 0,LED 灯,Лампы светодиодные,шт
+`))
+	if assert.NoError(t, err) && assert.Len(t, codes, 2) {
+		assert.Equal(t, "6201930000", codes[0].Code)
+		assert.True(t, codes[0].Matches("6201"))
+		assert.True(t, codes[1].Matches("led"))
+		assert.True(t, codes[1].Matches("светодиод"))
+		assert.False(t, codes[1].Matches("6201"))
+	}
+
+	_, err = customs.ParseHSCodesCSV(strings.NewReader("6201930000,a,b,c\n6201930000,a,b,c\n"))
+	assert.Error(t, err)
+	_, err = customs.ParseHSCodesCSV(strings.NewReader("62019,a,b,c\n"))
+	assert.Error(t, err)
+}
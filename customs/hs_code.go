@@ -0,0 +1,81 @@
+package customs
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// HSCode is the record of HS codes reference table
+type HSCode struct {
+	Code          string `json:"code"`
+	DescriptionZh string `json:"description_zh"`
+	DescriptionRu string `json:"description_ru"`
+	// Unit is the additional unit of measure, e.g. шт, пар
+	Unit string `json:"unit"`
+}
+
+// Matches checks if code starts with query
+// or description contains it
+func (h HSCode) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	if code := NormalizeHSCode(query); code != "" && strings.HasPrefix(h.Code, code) {
+		return true
+	}
+
+	return strings.Contains(strings.ToLower(h.DescriptionZh), query) ||
+		strings.Contains(strings.ToLower(h.DescriptionRu), query)
+}
+
+var HSCodeCSVHeader = []string{"code", "description_zh", "description_ru", "unit"}
+
+// ParseHSCodesCSV parses HS codes from the CSV file with HSCodeCSVHeader columns
+func ParseHSCodesCSV(r io.Reader) ([]HSCode, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []HSCode
+	seen := make(map[string]bool)
+	for i, rec := range records {
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if i == 0 && strings.TrimSpace(rec[0]) == HSCodeCSVHeader[0] {
+			continue
+		}
+
+		col := func(n int) string {
+			if n >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[n])
+		}
+
+		h := HSCode{
+			Code:          NormalizeHSCode(col(0)),
+			DescriptionZh: col(1),
+			DescriptionRu: col(2),
+			Unit:          col(3),
+		}
+		if !ValidHSCode(h.Code) {
+			return nil, errors.Errorf("line %d: wrong hs code %q", i+1, col(0))
+		}
+		if seen[h.Code] {
+			return nil, errors.Errorf("line %d: hs code %s is duplicated", i+1, h.Code)
+		}
+		seen[h.Code] = true
+		codes = append(codes, h)
+	}
+
+	return codes, nil
+}
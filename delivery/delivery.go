@@ -0,0 +1,34 @@
+// Package delivery sends signed requests to the endpoints of partners
+// and webhooks, retrying failed ones with doubled delay
+package delivery
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrInProgress = errors.New("delivery: delivery is in progress")
+	ErrStopped    = errors.New("delivery: delivery is stopped")
+)
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Delivered Status = "delivered"
+	Failed    Status = "failed"
+)
+
+// Attempt is one request to the endpoint
+type Attempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	// Duration in milliseconds
+	Duration int64 `json:"duration"`
+}
+
+func (a Attempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
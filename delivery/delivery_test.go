@@ -0,0 +1,62 @@
+package delivery_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSender_Send(t *testing.T) {
+	mock := delivery.NewMockReceiver("secret", 1)
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	sender := delivery.NewSender(delivery.Config{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond})
+	req := delivery.Request{ID: "D1", ContentType: "application/json", Body: []byte(`{}`), Headers: map[string]string{"X-Event": "test"}}
+	target := func() (delivery.Target, error) {
+		return delivery.Target{URL: srv.URL, Secret: "secret"}, nil
+	}
+
+	var attempts []delivery.Attempt
+	status := sender.Send(req, target, nil, func(t delivery.Target, a delivery.Attempt, status delivery.Status) {
+		attempts = append(attempts, a)
+	})
+	assert.Equal(t, delivery.Delivered, status)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 503, attempts[0].StatusCode)
+	received := mock.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, "D1", received[0].DeliveryID)
+
+	// stop wakes delivery waiting for retry
+	mock.Secret = "rotated"
+	slow := delivery.NewSender(delivery.Config{Timeout: time.Second, Retries: 2, Backoff: time.Hour})
+	stop := make(chan struct{})
+	saved := make(chan delivery.Attempt, 3)
+	done := make(chan delivery.Status)
+	go func() {
+		done <- slow.Send(req, target, stop, func(t delivery.Target, a delivery.Attempt, status delivery.Status) {
+			saved <- a
+		})
+	}()
+	assert.Equal(t, 401, (<-saved).StatusCode)
+	close(stop)
+	select {
+	case status = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("delivery is not stopped")
+	}
+	assert.Equal(t, delivery.Failed, status)
+	assert.Equal(t, delivery.ErrStopped.Error(), (<-saved).Error)
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"shipment_code":"SPN00123"}`)
+	sig := delivery.Sign("secret", 1590000000, body)
+	assert.True(t, delivery.VerifySignature("secret", sig, 1590000000, body))
+	assert.False(t, delivery.VerifySignature("secret", sig, 1590000001, body))
+	assert.False(t, delivery.VerifySignature("other", sig, 1590000000, body))
+}
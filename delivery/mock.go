@@ -0,0 +1,80 @@
+package delivery
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MockRequest is the request received by MockReceiver
+type MockRequest struct {
+	DeliveryID  string
+	ContentType string
+	Body        []byte
+	ReceivedAt  time.Time
+}
+
+// MockReceiver is the endpoint for local testing of partner manifests
+// and webhooks. It checks signatures and fails the first FailFirst requests
+type MockReceiver struct {
+	Secret    string
+	FailFirst int
+	// OnReceive is called with every accepted request
+	OnReceive func(r MockRequest)
+
+	mu       sync.Mutex
+	requests int
+	received []MockRequest
+}
+
+func NewMockReceiver(secret string, failFirst int) *MockReceiver {
+	return &MockReceiver{Secret: secret, FailFirst: failFirst}
+}
+
+func (m *MockReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || !VerifySignature(m.Secret, r.Header.Get(HeaderSignature), ts, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if m.requests <= m.FailFirst {
+		http.Error(w, "temporary failure", http.StatusServiceUnavailable)
+		return
+	}
+	req := MockRequest{
+		DeliveryID:  r.Header.Get(HeaderDeliveryID),
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        body,
+		ReceivedAt:  time.Now(),
+	}
+	m.received = append(m.received, req)
+	if m.OnReceive != nil {
+		m.OnReceive(req)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Received returns requests accepted by the mock
+func (m *MockReceiver) Received() []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]MockRequest{}, m.received...)
+}
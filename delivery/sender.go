@@ -0,0 +1,141 @@
+package delivery
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Config of the sender. Attempt n waits Backoff * 2^(n-2)
+// after the previous one failed
+type Config struct {
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+}
+
+// Request is the body signed and posted to the target
+type Request struct {
+	ID          string
+	ContentType string
+	Body        []byte
+	// Headers are set in addition to the signature headers
+	Headers map[string]string
+}
+
+// Target is the endpoint request is sent to and the secret it's signed with
+type Target struct {
+	URL    string
+	Secret string
+}
+
+// TargetFunc returns the current target of the delivery, it's called
+// before every attempt as endpoint and secret may be changed between
+// retries. Error fails the delivery without further retries
+type TargetFunc func() (Target, error)
+
+// SaveFunc is called after every attempt with the target it was sent to
+// and the status of the delivery. Target is empty if it wasn't resolved
+type SaveFunc func(t Target, a Attempt, status Status)
+
+// Sender posts signed requests retrying on failures
+type Sender struct {
+	client *http.Client
+	config Config
+}
+
+func NewSender(config Config) *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// Send delivers the request and returns the final status. Closing stop
+// fails the delivery with ErrStopped right away, even if it waits for retry
+func (s *Sender) Send(r Request, target TargetFunc, stop <-chan struct{}, save SaveFunc) Status {
+	backoff := s.config.Backoff
+	status := Pending
+	for i := 0; status == Pending; i++ {
+		var wait time.Duration
+		if i > 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		var t Target
+		var a Attempt
+		err := sleep(wait, stop)
+		if err == nil {
+			t, err = target()
+		}
+		if err != nil {
+			a = Attempt{At: time.Now(), Error: err.Error()}
+			status = Failed
+		} else {
+			a = s.attempt(r, t)
+			if a.Succeeded() {
+				status = Delivered
+			} else if i == s.config.Retries {
+				status = Failed
+			}
+		}
+		save(t, a, status)
+	}
+
+	return status
+}
+
+// sleep waits d, ErrStopped is returned if stop is closed meanwhile
+func sleep(d time.Duration, stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return ErrStopped
+	default:
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return ErrStopped
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *Sender) attempt(r Request, t Target) (a Attempt) {
+	start := time.Now()
+	a.At = start
+	defer func() {
+		a.Duration = time.Since(start).Milliseconds()
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, t.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	ts := start.Unix()
+	req.Header.Set("Content-Type", r.ContentType)
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(HeaderDeliveryID, r.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(t.Secret, ts, r.Body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	defer res.Body.Close()
+	a.StatusCode = res.StatusCode
+	if !a.Succeeded() {
+		a.Error = res.Status
+	}
+
+	return a
+}
@@ -0,0 +1,33 @@
+package delivery
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers of the delivered requests
+const (
+	HeaderSignature  = "X-Signature"
+	HeaderTimestamp  = "X-Timestamp"
+	HeaderDeliveryID = "X-Delivery-ID"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns HMAC-SHA256 of the timestamp and body joined with dot,
+// e.g. sha256=5d41402abc4b2a76b9719d911017c592
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks signature made by Sign
+func VerifySignature(secret string, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
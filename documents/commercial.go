@@ -0,0 +1,179 @@
+// Package documents generates customs documents of declared shipments:
+// commercial invoice and packing list in PDF and XLSX formats
+package documents
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Type string
+
+const (
+	Invoice     Type = "invoice"
+	PackingList Type = "packing_list"
+)
+
+type Format string
+
+const (
+	PDF  Format = "pdf"
+	XLSX Format = "xlsx"
+)
+
+var (
+	ErrNotDeclared   = errors.New("documents: shipment is not declared")
+	ErrNoUnitLoads   = errors.New("documents: shipment has no unit loads")
+	ErrUnknownType   = errors.New("documents: unknown document type")
+	ErrUnknownFormat = errors.New("documents: unknown document format")
+)
+
+// Config is the shipper printed on the documents
+type Config struct {
+	DocumentsShipperName    string `split_words:"true"`
+	DocumentsShipperAddress string `split_words:"true"`
+	DocumentsShipperPhone   string `split_words:"true"`
+	DocumentsCurrency       string `split_words:"true" default:"USD"`
+}
+
+type Party struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+}
+
+type PackingListLine struct {
+	Sequence    int             `json:"sequence"`
+	ProductName string          `json:"product_name"`
+	Quantity    int             `json:"quantity"`
+	Weight      decimal.Decimal `json:"weight"`
+	Length      int64           `json:"length"`
+	Width       int64           `json:"width"`
+	Height      int64           `json:"height"`
+	Cubage      decimal.Decimal `json:"cubage"`
+}
+
+type InvoiceLine struct {
+	ProductName string          `json:"product_name"`
+	Quantity    int             `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// CommercialDocument contains data of both invoice and packing list
+type CommercialDocument struct {
+	Number        string            `json:"number"`
+	Date          time.Time         `json:"date"`
+	ShipmentCode  string            `json:"shipment_code"`
+	Shipper       Party             `json:"shipper"`
+	Consignee     Party             `json:"consignee"`
+	TransferPoint string            `json:"transfer_point"`
+	Currency      string            `json:"currency"`
+	PackingList   []PackingListLine `json:"packing_list"`
+	Invoice       []InvoiceLine     `json:"invoice"`
+	TotalQuantity int               `json:"total_quantity"`
+	TotalWeight   decimal.Decimal   `json:"total_weight"`
+	TotalCubage   decimal.Decimal   `json:"total_cubage"`
+	TotalValue    decimal.Decimal   `json:"total_value"`
+}
+
+// NewCommercialDocument collects document data from the shipment. Cargo
+// value is split between products in proportion to their quantity
+func NewCommercialDocument(sm logistics.Shipment, config Config, now time.Time) (CommercialDocument, error) {
+	if !sm.NeedDeclare {
+		return CommercialDocument{}, ErrNotDeclared
+	}
+	unitLoads := sm.AllUnitLoads()
+	if len(unitLoads) == 0 {
+		return CommercialDocument{}, ErrNoUnitLoads
+	}
+
+	doc := CommercialDocument{
+		Number:       fmt.Sprintf("%s-%s", strings.ToUpper(sm.Code), now.Format("20060102")),
+		Date:         now,
+		ShipmentCode: sm.Code,
+		Shipper: Party{
+			Name:    config.DocumentsShipperName,
+			Address: config.DocumentsShipperAddress,
+			Phone:   config.DocumentsShipperPhone,
+		},
+		Consignee: Party{
+			Name:    sm.PartnerInfo.Recipient.Name,
+			Address: sm.PartnerInfo.Recipient.Destination,
+			Phone:   sm.PartnerInfo.Recipient.PhoneNumber,
+		},
+		TransferPoint: sm.TransferPoint.Name(logistics.LangRu),
+		Currency:      config.DocumentsCurrency,
+		TotalValue:    decimal.NewFromFloat(sm.PartnerInfo.CargoValue).Round(2),
+	}
+	if doc.Currency == "" {
+		doc.Currency = "USD"
+	}
+
+	quantities := make(map[string]int)
+	for _, ul := range unitLoads {
+		name := strings.TrimSpace(ul.ProductName)
+		if name == "" {
+			name = strings.TrimSpace(sm.PartnerInfo.ProductName)
+		}
+		doc.PackingList = append(doc.PackingList, PackingListLine{
+			Sequence:    ul.Sequence,
+			ProductName: name,
+			Quantity:    ul.Quantity,
+			Weight:      ul.Weight,
+			Length:      ul.Length,
+			Width:       ul.Width,
+			Height:      ul.Height,
+			Cubage:      ul.Cubage(),
+		})
+		doc.TotalQuantity += ul.Quantity
+		doc.TotalWeight = doc.TotalWeight.Add(ul.Weight)
+		doc.TotalCubage = doc.TotalCubage.Add(ul.Cubage())
+		quantities[name] += ul.Quantity
+	}
+
+	var names []string
+	for name := range quantities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	doc.Invoice = splitValue(names, quantities, doc.TotalValue)
+
+	return doc, nil
+}
+
+// splitValue allocates total value to products by quantity,
+// rounding remainder goes to the last product
+func splitValue(names []string, quantities map[string]int, total decimal.Decimal) []InvoiceLine {
+	var totalQty int
+	for _, name := range names {
+		totalQty += quantities[name]
+	}
+
+	lines := []InvoiceLine{}
+	allocated := decimal.Zero
+	for i, name := range names {
+		l := InvoiceLine{ProductName: name, Quantity: quantities[name]}
+		switch {
+		case i == len(names)-1:
+			l.Amount = total.Sub(allocated)
+		case totalQty == 0:
+			l.Amount = total.DivRound(decimal.New(int64(len(names)), 0), 2)
+		default:
+			l.Amount = total.Mul(decimal.New(int64(l.Quantity), 0)).DivRound(decimal.New(int64(totalQty), 0), 2)
+		}
+		allocated = allocated.Add(l.Amount)
+		if l.Quantity > 0 {
+			l.UnitPrice = l.Amount.DivRound(decimal.New(int64(l.Quantity), 0), 2)
+		}
+		lines = append(lines, l)
+	}
+
+	return lines
+}
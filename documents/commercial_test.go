@@ -0,0 +1,99 @@
+package documents_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/documents"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tealeg/xlsx"
+	"os"
+	"testing"
+	"time"
+)
+
+var sm = logistics.Shipment{
+	Code:          "SPN007001",
+	NeedDeclare:   true,
+	TransferPoint: logistics.TransferPoint{Slug: "almaty", Names: map[string]string{logistics.LangRu: "Алматы"}},
+	PartnerInfo: logistics.PartnerInfo{
+		ProductName: "Одежда",
+		CargoValue:  100,
+		Recipient:   logistics.Recipient{Name: "Иванов Иван", PhoneNumber: "+77011234567", Destination: "Алматы"},
+	},
+	UnitLoads: []*logistics.UnitLoad{
+		{Sequence: 1, Quantity: 1, ProductName: "Куртки", Weight: decimal.NewFromInt(20), Length: 60, Width: 40, Height: 40},
+		{Sequence: 2, Quantity: 1, ProductName: "Куртки", Weight: decimal.NewFromInt(22), Length: 60, Width: 40, Height: 40},
+		{Sequence: 3, Quantity: 1, Weight: decimal.NewFromInt(15), Length: 50, Width: 40, Height: 30},
+	},
+}
+
+func TestNewCommercialDocument(t *testing.T) {
+	doc, err := documents.NewCommercialDocument(sm, documents.Config{}, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, "SPN007001-20200601", doc.Number)
+	assert.Equal(t, "USD", doc.Currency)
+	assert.Len(t, doc.PackingList, 3)
+	assert.Equal(t, "Одежда", doc.PackingList[2].ProductName)
+	assert.Equal(t, "57", doc.TotalWeight.String())
+
+	require.Len(t, doc.Invoice, 2)
+	assert.Equal(t, "Куртки", doc.Invoice[0].ProductName)
+	assert.Equal(t, "66.67", doc.Invoice[0].Amount.String())
+	assert.Equal(t, "33.33", doc.Invoice[1].Amount.String())
+
+	notDeclared := sm
+	notDeclared.NeedDeclare = false
+	_, err = documents.NewCommercialDocument(notDeclared, documents.Config{}, time.Now())
+	assert.Equal(t, documents.ErrNotDeclared, err)
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	g, err := documents.NewGenerator(os.Getenv("FONT_PATH"))
+	require.NoError(t, err)
+
+	doc, err := documents.NewCommercialDocument(sm, documents.Config{DocumentsShipperName: "GZWH2"}, time.Now())
+	require.NoError(t, err)
+
+	for _, typ := range []documents.Type{documents.Invoice, documents.PackingList} {
+		for _, f := range []documents.Format{documents.PDF, documents.XLSX} {
+			file, err := g.Generate(doc, typ, f)
+			if assert.NoError(t, err) {
+				assert.FileExists(t, file.FullPath)
+				os.Remove(file.FullPath)
+			}
+		}
+	}
+
+	_, err = g.Generate(doc, "waybill", documents.PDF)
+	assert.Equal(t, documents.ErrUnknownType, err)
+}
+
+func TestGenerator_GenerateXLSX_TextCells(t *testing.T) {
+	g, err := documents.NewGenerator(os.Getenv("FONT_PATH"))
+	require.NoError(t, err)
+
+	codes := sm
+	codes.UnitLoads = []*logistics.UnitLoad{
+		{Sequence: 1, Quantity: 1, ProductName: "00123", Weight: decimal.NewFromInt(20), Length: 60, Width: 40, Height: 40},
+		{Sequence: 2, Quantity: 1, ProductName: "Inf", Weight: decimal.NewFromInt(22), Length: 60, Width: 40, Height: 40},
+	}
+	doc, err := documents.NewCommercialDocument(codes, documents.Config{}, time.Now())
+	require.NoError(t, err)
+
+	file, err := g.Generate(doc, documents.PackingList, documents.XLSX)
+	require.NoError(t, err)
+	defer os.Remove(file.FullPath)
+
+	f, err := xlsx.OpenFile(file.FullPath)
+	require.NoError(t, err)
+	rows := f.Sheets[0].Rows
+	last := len(rows) - 1
+	for i, name := range []string{"00123", "Inf"} {
+		cell := rows[last-2+i].Cells[1]
+		assert.Equal(t, xlsx.CellTypeString, cell.Type())
+		assert.Equal(t, name, cell.Value)
+	}
+	assert.Equal(t, xlsx.CellTypeNumeric, rows[last].Cells[3].Type())
+}
@@ -0,0 +1,255 @@
+package documents
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	XML Format = "xml"
+	CSV Format = "csv"
+)
+
+// DeclarationSchemaVersion is the version of the bundled DeclarationSchema
+const DeclarationSchemaVersion = "1.0"
+
+// DeclarationNamespace is the target namespace of DeclarationSchema
+const DeclarationNamespace = "urn:ca-warehouse:preliminary-declaration:1.0"
+
+// ConsignorCountry is the country of all goods dispatched from the warehouse
+const ConsignorCountry = "CN"
+
+// DeclarationCountries are countries goods can be declared in,
+// they should match DestinationCountryCode of DeclarationSchema
+var DeclarationCountries = []string{"RU", "KZ", "KG", "BY", "AM", "UZ"}
+
+var (
+	ErrNoConsignor   = errors.New("documents: shipper name is not configured")
+	ErrNoDestination = errors.New("documents: transfer point is not in the declaration country")
+)
+
+// DeclarationParty is the consignor or consignee of the declaration
+type DeclarationParty struct {
+	Name    string `xml:"Name" json:"name"`
+	Address string `xml:"Address,omitempty" json:"address"`
+	Phone   string `xml:"Phone,omitempty" json:"phone"`
+	Country string `xml:"CountryCode" json:"country"`
+}
+
+// DeclarationGoods is one goods item of the declaration. Unit loads
+// with the same customs attributes and product name are merged
+type DeclarationGoods struct {
+	Number          int             `xml:"GoodsNumber" json:"number"`
+	HSCode          string          `xml:"HSCode" json:"hs_code"`
+	Description     string          `xml:"Description" json:"description"`
+	Material        string          `xml:"Material" json:"material"`
+	Brand           string          `xml:"Brand,omitempty" json:"brand"`
+	CountryOfOrigin string          `xml:"OriginCountryCode" json:"country_of_origin"`
+	Quantity        int             `xml:"Quantity" json:"quantity"`
+	Packages        int             `xml:"PackageQuantity" json:"packages"`
+	GrossWeight     decimal.Decimal `xml:"GrossWeight" json:"gross_weight"`
+	NetWeight       decimal.Decimal `xml:"NetWeight" json:"net_weight"`
+	UnitValue       decimal.Decimal `xml:"UnitValue" json:"unit_value"`
+	Value           decimal.Decimal `xml:"InvoiceValue" json:"value"`
+	// sequences of unit loads, e.g. 1,2,5
+	UnitLoads string `xml:"PackageNumbers" json:"unit_loads"`
+}
+
+// Declaration is the preliminary goods declaration (предварительная
+// информация о товарах) for customs brokers. It's the interchange format
+// of this service described by DeclarationSchema, not the official EAEU
+// customs schema, brokers map it to the format of their software
+type Declaration struct {
+	XMLName          xml.Name           `xml:"urn:ca-warehouse:preliminary-declaration:1.0 PreliminaryDeclaration" json:"-"`
+	SchemaVersion    string             `xml:"SchemaVersion,attr" json:"schema_version"`
+	Number           string             `xml:"DocumentNumber" json:"number"`
+	Date             string             `xml:"DocumentDate" json:"date"`
+	ShipmentCode     string             `xml:"ShipmentCode" json:"shipment_code"`
+	TransferPoint    string             `xml:"TransferPoint" json:"transfer_point"`
+	DispatchCountry  string             `xml:"DispatchCountryCode" json:"dispatch_country"`
+	DestinationCode  string             `xml:"DestinationCountryCode" json:"destination_country"`
+	Consignor        DeclarationParty   `xml:"Consignor" json:"consignor"`
+	Consignee        DeclarationParty   `xml:"Consignee" json:"consignee"`
+	Currency         string             `xml:"CurrencyCode" json:"currency"`
+	TotalPackages    int                `xml:"TotalPackageQuantity" json:"total_packages"`
+	TotalGrossWeight decimal.Decimal    `xml:"TotalGrossWeight" json:"total_gross_weight"`
+	TotalNetWeight   decimal.Decimal    `xml:"TotalNetWeight" json:"total_net_weight"`
+	TotalValue       decimal.Decimal    `xml:"TotalInvoiceValue" json:"total_value"`
+	Goods            []DeclarationGoods `xml:"Goods" json:"goods"`
+}
+
+// NewDeclaration collects declaration data from the shipment. All unit
+// loads should have complete customs attributes, value of the goods is
+// unit value multiplied by quantity. Shipper of the config is the consignor
+func NewDeclaration(sm logistics.Shipment, config Config, now time.Time) (Declaration, error) {
+	if !sm.NeedDeclare {
+		return Declaration{}, ErrNotDeclared
+	}
+	if strings.TrimSpace(config.DocumentsShipperName) == "" {
+		return Declaration{}, ErrNoConsignor
+	}
+	destination, err := destinationCountry(sm.TransferPoint)
+	if err != nil {
+		return Declaration{}, err
+	}
+	unitLoads := sm.AllUnitLoads()
+	if len(unitLoads) == 0 {
+		return Declaration{}, ErrNoUnitLoads
+	}
+	report := logistics.ValidateShipment(sm, logistics.ValidateCustoms)
+	if report.HasErrors() {
+		return Declaration{}, errors.WithMessage(logistics.ErrIncompleteCustomsData, report.ErrorMessages())
+	}
+
+	d := Declaration{
+		SchemaVersion:   DeclarationSchemaVersion,
+		Number:          fmt.Sprintf("%s-%s", strings.ToUpper(sm.Code), now.Format("20060102")),
+		Date:            now.Format("2006-01-02"),
+		ShipmentCode:    sm.Code,
+		TransferPoint:   sm.TransferPoint.Slug,
+		DispatchCountry: ConsignorCountry,
+		DestinationCode: destination,
+		Consignor: DeclarationParty{
+			Name:    config.DocumentsShipperName,
+			Address: config.DocumentsShipperAddress,
+			Phone:   config.DocumentsShipperPhone,
+			Country: ConsignorCountry,
+		},
+		Consignee: DeclarationParty{
+			Name:    sm.PartnerInfo.Recipient.Name,
+			Address: sm.PartnerInfo.Recipient.Destination,
+			Phone:   sm.PartnerInfo.Recipient.PhoneNumber,
+		},
+		Currency: config.DocumentsCurrency,
+	}
+	d.Consignee.Country = d.DestinationCode
+	if d.Currency == "" {
+		d.Currency = "USD"
+	}
+
+	items := make(map[string]*DeclarationGoods)
+	sequences := make(map[string][]string)
+	var keys []string
+	for _, ul := range unitLoads {
+		name := strings.TrimSpace(ul.ProductName)
+		if name == "" {
+			name = strings.TrimSpace(sm.PartnerInfo.ProductName)
+		}
+		ca := ul.Customs
+		ca.Normalize()
+		key := strings.Join([]string{ca.HSCode, name, ca.Material, ca.Brand, ca.CountryOfOrigin, ca.UnitValue.String()}, "|")
+		g, ok := items[key]
+		if !ok {
+			g = &DeclarationGoods{
+				HSCode:          ca.HSCode,
+				Description:     name,
+				Material:        ca.Material,
+				Brand:           ca.Brand,
+				CountryOfOrigin: ca.CountryOfOrigin,
+				UnitValue:       ca.UnitValue.Round(2),
+			}
+			items[key] = g
+			keys = append(keys, key)
+		}
+		g.Quantity += ul.Quantity
+		g.Packages++
+		g.GrossWeight = g.GrossWeight.Add(ul.Weight)
+		g.NetWeight = g.NetWeight.Add(ca.NetWeight)
+		sequences[key] = append(sequences[key], strconv.Itoa(ul.Sequence))
+	}
+
+	sort.Strings(keys)
+	for i, key := range keys {
+		g := items[key]
+		g.Number = i + 1
+		g.GrossWeight = g.GrossWeight.Round(3)
+		g.NetWeight = g.NetWeight.Round(3)
+		g.Value = g.UnitValue.Mul(decimal.New(int64(g.Quantity), 0)).Round(2)
+		g.UnitLoads = strings.Join(sequences[key], ",")
+
+		d.TotalPackages += g.Packages
+		d.TotalGrossWeight = d.TotalGrossWeight.Add(g.GrossWeight)
+		d.TotalNetWeight = d.TotalNetWeight.Add(g.NetWeight)
+		d.TotalValue = d.TotalValue.Add(g.Value)
+		d.Goods = append(d.Goods, *g)
+	}
+
+	return d, nil
+}
+
+// destinationCountry returns the country goods are cleared in at the
+// transfer point, it should be one of DeclarationCountries
+func destinationCountry(tp logistics.TransferPoint) (string, error) {
+	country := tp.CustomsCountry
+	if country == "" {
+		country = tp.Country
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	for _, c := range DeclarationCountries {
+		if c == country {
+			return country, nil
+		}
+	}
+
+	return "", errors.WithMessagef(ErrNoDestination, "%s", tp.Slug)
+}
+
+// XMLDocument returns XML document validated with DeclarationSchema
+func (d Declaration) XMLDocument() ([]byte, error) {
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	data = append([]byte(xml.Header), data...)
+
+	err = ValidateDeclarationXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// DeclarationCSVHeader are the columns of the flat CSV declaration,
+// every row is the goods item with the declaration data repeated
+var DeclarationCSVHeader = []string{
+	"document_number", "document_date", "shipment_code", "transfer_point", "destination_country",
+	"consignor", "consignee", "consignee_address", "consignee_phone", "currency",
+	"goods_number", "hs_code", "description", "material", "brand", "country_of_origin",
+	"quantity", "packages", "gross_weight", "net_weight", "unit_value", "value", "package_numbers",
+}
+
+// WriteCSV writes declaration as flat CSV, fallback for brokers
+// who can not import XML
+func (d Declaration) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(DeclarationCSVHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range d.Goods {
+		err = cw.Write([]string{
+			d.Number, d.Date, d.ShipmentCode, d.TransferPoint, d.DestinationCode,
+			d.Consignor.Name, d.Consignee.Name, d.Consignee.Address, d.Consignee.Phone, d.Currency,
+			strconv.Itoa(g.Number), g.HSCode, g.Description, g.Material, g.Brand, g.CountryOfOrigin,
+			strconv.Itoa(g.Quantity), strconv.Itoa(g.Packages), g.GrossWeight.String(), g.NetWeight.String(),
+			g.UnitValue.StringFixed(2), g.Value.StringFixed(2), g.UnitLoads,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,107 @@
+package documents
+
+// DeclarationSchema is the XSD of the preliminary goods declaration. It's
+// our own interchange format in DeclarationNamespace, not the official
+// EAEU schema, brokers import the XML declaration validated with it
+const DeclarationSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+           targetNamespace="urn:ca-warehouse:preliminary-declaration:1.0"
+           xmlns="urn:ca-warehouse:preliminary-declaration:1.0"
+           elementFormDefault="qualified">
+  <xs:simpleType name="NonEmptyString">
+    <xs:restriction base="xs:string">
+      <xs:minLength value="1"/>
+      <xs:maxLength value="250"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="CountryCode">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[A-Z]{2}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="DestinationCountryCode">
+    <xs:restriction base="xs:string">
+      <xs:enumeration value="RU"/>
+      <xs:enumeration value="KZ"/>
+      <xs:enumeration value="KG"/>
+      <xs:enumeration value="BY"/>
+      <xs:enumeration value="AM"/>
+      <xs:enumeration value="UZ"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="CurrencyCode">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[A-Z]{3}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="HSCode">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[0-9]{10}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="Amount">
+    <xs:restriction base="xs:decimal">
+      <xs:minInclusive value="0"/>
+      <xs:fractionDigits value="2"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="Weight">
+    <xs:restriction base="xs:decimal">
+      <xs:minInclusive value="0"/>
+      <xs:fractionDigits value="3"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="Party">
+    <xs:sequence>
+      <xs:element name="Name" type="NonEmptyString"/>
+      <xs:element name="Address" type="NonEmptyString" minOccurs="0"/>
+      <xs:element name="Phone" type="NonEmptyString" minOccurs="0"/>
+      <xs:element name="CountryCode" type="CountryCode"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="Goods">
+    <xs:sequence>
+      <xs:element name="GoodsNumber" type="xs:positiveInteger"/>
+      <xs:element name="HSCode" type="HSCode"/>
+      <xs:element name="Description" type="NonEmptyString"/>
+      <xs:element name="Material" type="NonEmptyString"/>
+      <xs:element name="Brand" type="NonEmptyString" minOccurs="0"/>
+      <xs:element name="OriginCountryCode" type="CountryCode"/>
+      <xs:element name="Quantity" type="xs:positiveInteger"/>
+      <xs:element name="PackageQuantity" type="xs:positiveInteger"/>
+      <xs:element name="GrossWeight" type="Weight"/>
+      <xs:element name="NetWeight" type="Weight"/>
+      <xs:element name="UnitValue" type="Amount"/>
+      <xs:element name="InvoiceValue" type="Amount"/>
+      <xs:element name="PackageNumbers">
+        <xs:simpleType>
+          <xs:restriction base="xs:string">
+            <xs:pattern value="[0-9]+(,[0-9]+)*"/>
+          </xs:restriction>
+        </xs:simpleType>
+      </xs:element>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:element name="PreliminaryDeclaration">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="DocumentNumber" type="NonEmptyString"/>
+        <xs:element name="DocumentDate" type="xs:date"/>
+        <xs:element name="ShipmentCode" type="NonEmptyString"/>
+        <xs:element name="TransferPoint" type="xs:string"/>
+        <xs:element name="DispatchCountryCode" type="CountryCode"/>
+        <xs:element name="DestinationCountryCode" type="DestinationCountryCode"/>
+        <xs:element name="Consignor" type="Party"/>
+        <xs:element name="Consignee" type="Party"/>
+        <xs:element name="CurrencyCode" type="CurrencyCode"/>
+        <xs:element name="TotalPackageQuantity" type="xs:positiveInteger"/>
+        <xs:element name="TotalGrossWeight" type="Weight"/>
+        <xs:element name="TotalNetWeight" type="Weight"/>
+        <xs:element name="TotalInvoiceValue" type="Amount"/>
+        <xs:element name="Goods" type="Goods" maxOccurs="999"/>
+      </xs:sequence>
+      <xs:attribute name="SchemaVersion" type="xs:string" use="required"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>
+`
@@ -0,0 +1,122 @@
+package documents_test
+
+import (
+	"bytes"
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	"github.com/amanbolat/ca-warehouse-client/documents"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func declaredShipment() logistics.Shipment {
+	jackets := customs.Attributes{
+		HSCode:          "6201 93 000 0",
+		Material:        "полиэстер",
+		CountryOfOrigin: "CN",
+		UnitValue:       decimal.NewFromFloat(12.5),
+		NetWeight:       decimal.NewFromInt(18),
+	}
+	lamps := customs.Attributes{
+		HSCode:          "8539500000",
+		Material:        "пластик",
+		Brand:           "Xiaomi",
+		CountryOfOrigin: "CN",
+		UnitValue:       decimal.NewFromInt(3),
+		NetWeight:       decimal.NewFromInt(12),
+	}
+
+	s := sm
+	s.TransferPoint = logistics.TransferPoint{Slug: "almaty", Country: "KZ"}
+	s.UnitLoads = []*logistics.UnitLoad{
+		{Sequence: 1, Quantity: 10, ProductName: "Куртки", Weight: decimal.NewFromInt(20), Customs: jackets},
+		{Sequence: 2, Quantity: 50, ProductName: "Лампы", Weight: decimal.NewFromInt(15), Customs: lamps},
+		{Sequence: 3, Quantity: 12, ProductName: "Куртки", Weight: decimal.NewFromInt(22), Customs: jackets},
+	}
+
+	return s
+}
+
+func TestNewDeclaration(t *testing.T) {
+	config := documents.Config{DocumentsShipperName: "Guangzhou Warehouse"}
+	d, err := documents.NewDeclaration(declaredShipment(), config, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, "KZ", d.DestinationCode)
+	require.Len(t, d.Goods, 2)
+	assert.Equal(t, "6201930000", d.Goods[0].HSCode)
+	assert.Equal(t, 22, d.Goods[0].Quantity)
+	assert.Equal(t, "1,3", d.Goods[0].UnitLoads)
+	assert.Equal(t, "275", d.Goods[0].Value.String())
+	assert.Equal(t, "425", d.TotalValue.String())
+	assert.Equal(t, "57", d.TotalGrossWeight.String())
+	assert.Equal(t, 3, d.TotalPackages)
+
+	data, err := d.XMLDocument()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<HSCode>8539500000</HSCode>")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, d.WriteCSV(buf))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 3)
+
+	assert.Contains(t, string(data), `<PreliminaryDeclaration xmlns="`+documents.DeclarationNamespace+`"`)
+
+	_, err = documents.NewDeclaration(declaredShipment(), documents.Config{}, time.Now())
+	assert.Equal(t, documents.ErrNoConsignor, err)
+
+	incomplete := declaredShipment()
+	incomplete.UnitLoads[1].Customs.HSCode = ""
+	_, err = documents.NewDeclaration(incomplete, config, time.Now())
+	assert.Equal(t, logistics.ErrIncompleteCustomsData, errors.Cause(err))
+}
+
+func TestNewDeclaration_Destination(t *testing.T) {
+	config := documents.Config{DocumentsShipperName: "Guangzhou Warehouse"}
+	cases := []struct {
+		tp          logistics.TransferPoint
+		destination string
+	}{
+		{logistics.TransferPoint{Slug: "manchuria", Country: "CN", CustomsCountry: "RU"}, "RU"},
+		{logistics.TransferPoint{Slug: "tashkent", Country: "UZ"}, "UZ"},
+		{logistics.TransferPoint{Slug: "local", Country: "CN"}, ""},
+	}
+	for _, c := range cases {
+		s := declaredShipment()
+		s.TransferPoint = c.tp
+		d, err := documents.NewDeclaration(s, config, time.Now())
+		if c.destination == "" {
+			assert.Equal(t, documents.ErrNoDestination, errors.Cause(err), c.tp.Slug)
+			continue
+		}
+		require.NoError(t, err, c.tp.Slug)
+		assert.Equal(t, c.destination, d.DestinationCode)
+		_, err = d.XMLDocument()
+		assert.NoError(t, err, c.tp.Slug)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := documents.ParseSchema([]byte(documents.DeclarationSchema))
+	require.NoError(t, err)
+
+	err = schema.Validate([]byte(`<PreliminaryDeclaration xmlns="` + documents.DeclarationNamespace + `"><DocumentNumber>1</DocumentNumber></PreliminaryDeclaration>`))
+	if assert.IsType(t, documents.SchemaError{}, err) {
+		problems := err.(documents.SchemaError).Problems
+		assert.Contains(t, problems, "/PreliminaryDeclaration: attribute SchemaVersion is required")
+		assert.Contains(t, problems, "/PreliminaryDeclaration: element DocumentDate is required")
+	}
+
+	err = schema.Validate([]byte(`<Invoice/>`))
+	assert.Error(t, err)
+
+	err = schema.Validate([]byte(`<PreliminaryDeclaration SchemaVersion="1.0"/>`))
+	if assert.IsType(t, documents.SchemaError{}, err) {
+		assert.Contains(t, err.Error(), "should be in namespace")
+	}
+}
@@ -0,0 +1,330 @@
+package documents
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/signintech/gopdf"
+	"github.com/tealeg/xlsx"
+	"math"
+	"os"
+	"path"
+	"strconv"
+)
+
+// A4 page size in points
+const pageW, pageH = 595, 842
+
+// table is the layout of the document shared by PDF and XLSX
+type table struct {
+	title   string
+	info    [][2]string
+	headers []string
+	// widths are pdf column widths in points
+	widths []float64
+	// numeric columns are saved to xlsx as numbers
+	numeric []bool
+	rows    [][]string
+	totals  []string
+}
+
+func invoiceTable(doc CommercialDocument) table {
+	t := table{
+		title:   "商业发票 / Коммерческий инвойс",
+		info:    documentInfo(doc),
+		headers: []string{"#", "品名 / Наименование", "数量 / Кол-во", fmt.Sprintf("单价 / Цена, %s", doc.Currency), fmt.Sprintf("金额 / Сумма, %s", doc.Currency)},
+		widths:  []float64{25, 235, 80, 100, 115},
+		numeric: []bool{true, false, true, true, true},
+		totals:  []string{"", "合计 / Итого", strconv.Itoa(doc.TotalQuantity), "", doc.TotalValue.StringFixed(2)},
+	}
+	for i, l := range doc.Invoice {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(i + 1),
+			l.ProductName,
+			strconv.Itoa(l.Quantity),
+			l.UnitPrice.StringFixed(2),
+			l.Amount.StringFixed(2),
+		})
+	}
+
+	return t
+}
+
+func packingListTable(doc CommercialDocument) table {
+	t := table{
+		title:   "装箱单 / Упаковочный лист",
+		info:    documentInfo(doc),
+		headers: []string{"#", "品名 / Наименование", "数量 / Кол-во", "毛重 / Брутто, кг", "尺寸 / Размеры, см", "体积 / Объем, м3"},
+		widths:  []float64{25, 175, 70, 85, 110, 90},
+		numeric: []bool{true, false, true, true, false, true},
+		totals:  []string{"", "合计 / Итого", strconv.Itoa(doc.TotalQuantity), doc.TotalWeight.String(), "", doc.TotalCubage.String()},
+	}
+	for _, l := range doc.PackingList {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(l.Sequence),
+			l.ProductName,
+			strconv.Itoa(l.Quantity),
+			l.Weight.String(),
+			fmt.Sprintf("%dx%dx%d", l.Length, l.Width, l.Height),
+			l.Cubage.String(),
+		})
+	}
+
+	return t
+}
+
+func documentInfo(doc CommercialDocument) [][2]string {
+	return [][2]string{
+		{"编号 / Номер", doc.Number},
+		{"日期 / Дата", doc.Date.Format("02.01.2006")},
+		{"发货人 / Отправитель", joinNonEmpty(doc.Shipper.Name, doc.Shipper.Address, doc.Shipper.Phone)},
+		{"收货人 / Получатель", joinNonEmpty(doc.Consignee.Name, doc.Consignee.Address, doc.Consignee.Phone)},
+		{"转运点 / Пункт назначения", doc.TransferPoint},
+		{"票号 / Отправка", doc.ShipmentCode},
+	}
+}
+
+func joinNonEmpty(values ...string) string {
+	var res string
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if res != "" {
+			res += ", "
+		}
+		res += v
+	}
+
+	return res
+}
+
+// Generator creates document files
+type Generator struct {
+	fontPath string
+}
+
+func NewGenerator(fontPath string) (Generator, error) {
+	info, err := os.Lstat(fontPath)
+	if err != nil {
+		return Generator{}, errors.Errorf("fonts are not found in: %s", fontPath)
+	}
+	if info.IsDir() {
+		return Generator{}, errors.New("provided font path is not file")
+	}
+
+	return Generator{fontPath: fontPath}, nil
+}
+
+// File is the generated document saved in the temp directory
+type File struct {
+	FullPath    string
+	Name        string
+	ContentType string
+}
+
+// Generate creates document of the given type and format
+func (g Generator) Generate(doc CommercialDocument, t Type, f Format) (File, error) {
+	var tbl table
+	switch t {
+	case Invoice:
+		tbl = invoiceTable(doc)
+	case PackingList:
+		tbl = packingListTable(doc)
+	default:
+		return File{}, ErrUnknownType
+	}
+
+	file := File{
+		Name:     fmt.Sprintf("%s-%s.%s", doc.ShipmentCode, t, f),
+		FullPath: path.Join(os.TempDir(), fmt.Sprintf("%s-%s.%s", xid.New(), t, f)),
+	}
+
+	var err error
+	switch f {
+	case PDF:
+		file.ContentType = "application/pdf"
+		err = g.writePDF(tbl, file.FullPath)
+	case XLSX:
+		file.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = writeXLSX(tbl, file.FullPath)
+	default:
+		return File{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return File{}, err
+	}
+
+	return file, nil
+}
+
+func (g Generator) writePDF(t table, filePath string) error {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: gopdf.Rect{W: pageW, H: pageH}})
+	pdf.AddPage()
+	err := pdf.AddTTFFont("noto-cjk", g.fontPath)
+	if err != nil {
+		return err
+	}
+
+	err = pdf.SetFont("noto-cjk", "", 16)
+	if err != nil {
+		return err
+	}
+	pdf.SetX(20)
+	pdf.SetY(30)
+	err = pdf.Cell(nil, t.title)
+	if err != nil {
+		return err
+	}
+
+	err = pdf.SetFont("noto-cjk", "", 10)
+	if err != nil {
+		return err
+	}
+	pdf.SetY(pdf.GetY() + 30)
+	for _, i := range t.info {
+		pdf.SetX(20)
+		err = pdf.Cell(nil, fmt.Sprintf("%s: %s", i[0], i[1]))
+		if err != nil {
+			return err
+		}
+		pdf.SetY(pdf.GetY() + 15)
+	}
+	pdf.SetY(pdf.GetY() + 10)
+
+	// cells are wrapped to the column width, row is
+	// as high as its longest cell
+	const lineH = 13.0
+	splitRow := func(cells []string) ([][]string, float64) {
+		var rowLines [][]string
+		height := lineH
+		for i, cell := range cells {
+			lines, err := pdf.SplitText(cell, t.widths[i]-4)
+			if err != nil || len(lines) == 0 {
+				lines = []string{cell}
+			}
+			rowLines = append(rowLines, lines)
+			if h := float64(len(lines)) * lineH; h > height {
+				height = h
+			}
+		}
+		return rowLines, height
+	}
+	writeRow := func(cells []string) error {
+		rowLines, height := splitRow(cells)
+		y := pdf.GetY()
+		x := 20.0
+		for i, lines := range rowLines {
+			for n, l := range lines {
+				pdf.SetX(x)
+				pdf.SetY(y + float64(n)*lineH)
+				err := pdf.Cell(nil, l)
+				if err != nil {
+					return err
+				}
+			}
+			x += t.widths[i]
+		}
+		pdf.SetY(y + height + 2)
+		return nil
+	}
+	writeHeader := func() error {
+		err := writeRow(t.headers)
+		if err != nil {
+			return err
+		}
+		pdf.RectFromUpperLeft(20, pdf.GetY()-2, pageW-40, 1)
+		pdf.SetY(pdf.GetY() + 4)
+		return nil
+	}
+	// nextRow starts new page with the table header if row doesn't fit
+	nextRow := func(cells []string) error {
+		if _, height := splitRow(cells); pdf.GetY()+height > pageH-30 {
+			pdf.AddPage()
+			pdf.SetY(20)
+			err := writeHeader()
+			if err != nil {
+				return err
+			}
+		}
+		return writeRow(cells)
+	}
+
+	err = writeHeader()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range t.rows {
+		err = nextRow(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	pdf.RectFromUpperLeft(20, pdf.GetY()-2, pageW-40, 1)
+	pdf.SetY(pdf.GetY() + 4)
+	err = nextRow(t.totals)
+	if err != nil {
+		return err
+	}
+
+	pdf.SetY(pdf.GetY() + 40)
+	pdf.SetX(20)
+	err = pdf.Cell(nil, "签字 / Подпись: ____________________")
+	if err != nil {
+		return err
+	}
+
+	return pdf.WritePdf(filePath)
+}
+
+func writeXLSX(t table, filePath string) error {
+	f := xlsx.NewFile()
+	sheet, err := f.AddSheet("Sheet1")
+	if err != nil {
+		return err
+	}
+
+	addRow := func(cells ...string) {
+		row := sheet.AddRow()
+		for _, c := range cells {
+			row.AddCell().SetString(c)
+		}
+	}
+	// addTableRow saves cells of numeric columns as numbers
+	addTableRow := func(cells ...string) {
+		row := sheet.AddRow()
+		for i, c := range cells {
+			if t.numeric[i] {
+				v, err := strconv.ParseFloat(c, 64)
+				if err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) {
+					row.AddCell().SetFloat(v)
+					continue
+				}
+			}
+			row.AddCell().SetString(c)
+		}
+	}
+
+	addRow(t.title)
+	for _, i := range t.info {
+		addRow(i[0], i[1])
+	}
+	addRow()
+	addRow(t.headers...)
+	for _, r := range t.rows {
+		addTableRow(r...)
+	}
+	addTableRow(t.totals...)
+
+	for i := range t.headers {
+		err = sheet.SetColWidth(i, i, t.widths[i]/5)
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.Save(filePath)
+}
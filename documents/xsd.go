@@ -0,0 +1,351 @@
+package documents
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaError contains all problems found by XSD validation
+type SchemaError struct {
+	Problems []string
+}
+
+func (e SchemaError) Error() string {
+	return "documents: xml does not match schema: " + strings.Join(e.Problems, "; ")
+}
+
+// Schema is the parsed XSD. Only the subset used by bundled schemas is
+// supported: global elements, named and anonymous complex types with
+// sequence and attributes, simple types restricted by pattern, enumeration,
+// length, minInclusive and fractionDigits facets
+type Schema struct {
+	// targetNamespace is the namespace of the root element
+	targetNamespace string
+	elements        map[string]xsdElement
+	complexTypes    map[string]*xsdComplexType
+	simpleTypes     map[string]*xsdSimpleType
+}
+
+type xsdFacet struct {
+	Value string `xml:"value,attr"`
+}
+
+type xsdSimpleType struct {
+	Name        string `xml:"name,attr"`
+	Restriction struct {
+		Base           string     `xml:"base,attr"`
+		Patterns       []xsdFacet `xml:"pattern"`
+		Enumeration    []xsdFacet `xml:"enumeration"`
+		MinLength      *xsdFacet  `xml:"minLength"`
+		MaxLength      *xsdFacet  `xml:"maxLength"`
+		MinInclusive   *xsdFacet  `xml:"minInclusive"`
+		FractionDigits *xsdFacet  `xml:"fractionDigits"`
+	} `xml:"restriction"`
+}
+
+type xsdAttribute struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+	Use  string `xml:"use,attr"`
+}
+
+type xsdComplexType struct {
+	Name       string         `xml:"name,attr"`
+	Sequence   []xsdElement   `xml:"sequence>element"`
+	Attributes []xsdAttribute `xml:"attribute"`
+}
+
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	MinOccurs   string          `xml:"minOccurs,attr"`
+	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+	SimpleType  *xsdSimpleType  `xml:"simpleType"`
+}
+
+func (e xsdElement) occurs() (min, max int) {
+	min, max = 1, 1
+	if n, err := strconv.Atoi(e.MinOccurs); err == nil {
+		min = n
+	}
+	if e.MaxOccurs == "unbounded" {
+		max = -1
+	} else if n, err := strconv.Atoi(e.MaxOccurs); err == nil {
+		max = n
+	}
+
+	return min, max
+}
+
+// ParseSchema parses XSD document
+func ParseSchema(data []byte) (*Schema, error) {
+	var raw struct {
+		TargetNamespace string           `xml:"targetNamespace,attr"`
+		Elements        []xsdElement     `xml:"element"`
+		ComplexTypes    []xsdComplexType `xml:"complexType"`
+		SimpleTypes     []xsdSimpleType  `xml:"simpleType"`
+	}
+	err := xml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "documents: could not parse schema")
+	}
+
+	s := &Schema{
+		targetNamespace: raw.TargetNamespace,
+		elements:        make(map[string]xsdElement),
+		complexTypes:    make(map[string]*xsdComplexType),
+		simpleTypes:     make(map[string]*xsdSimpleType),
+	}
+	for _, e := range raw.Elements {
+		s.elements[e.Name] = e
+	}
+	for i := range raw.ComplexTypes {
+		s.complexTypes[raw.ComplexTypes[i].Name] = &raw.ComplexTypes[i]
+	}
+	for i := range raw.SimpleTypes {
+		st := &raw.SimpleTypes[i]
+		for _, p := range st.Restriction.Patterns {
+			if _, err := compilePattern(p.Value); err != nil {
+				return nil, errors.Wrapf(err, "documents: wrong pattern of type %s", st.Name)
+			}
+		}
+		s.simpleTypes[st.Name] = st
+	}
+
+	return s, nil
+}
+
+var declarationSchema = mustParseSchema(DeclarationSchema)
+
+func mustParseSchema(xsd string) *Schema {
+	s, err := ParseSchema([]byte(xsd))
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// ValidateDeclarationXML validates XML document with the bundled DeclarationSchema
+func ValidateDeclarationXML(data []byte) error {
+	return declarationSchema.Validate(data)
+}
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+// Validate checks XML document, returns SchemaError with all problems found
+func (s *Schema) Validate(data []byte) error {
+	var root xmlNode
+	err := xml.Unmarshal(data, &root)
+	if err != nil {
+		return SchemaError{Problems: []string{err.Error()}}
+	}
+
+	var problems []string
+	el, ok := s.elements[root.XMLName.Local]
+	if !ok {
+		problems = append(problems, fmt.Sprintf("unknown root element %s", root.XMLName.Local))
+	} else if root.XMLName.Space != s.targetNamespace {
+		problems = append(problems, fmt.Sprintf("root element %s should be in namespace %q", root.XMLName.Local, s.targetNamespace))
+	} else {
+		problems = s.validateElement("/"+el.Name, el, root)
+	}
+	if len(problems) > 0 {
+		return SchemaError{Problems: problems}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateElement(path string, el xsdElement, node xmlNode) []string {
+	ct := el.ComplexType
+	if ct == nil {
+		ct = s.complexTypes[localName(el.Type)]
+	}
+	if ct != nil {
+		return s.validateComplex(path, ct, node)
+	}
+	if len(node.Children) > 0 {
+		return []string{fmt.Sprintf("%s: unexpected child element %s", path, node.Children[0].XMLName.Local)}
+	}
+
+	st := el.SimpleType
+	typ := el.Type
+	if st != nil {
+		typ = ""
+	}
+	if problem := s.validateValue(st, typ, strings.TrimSpace(node.Content)); problem != "" {
+		return []string{fmt.Sprintf("%s: %s", path, problem)}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateComplex(path string, ct *xsdComplexType, node xmlNode) []string {
+	var problems []string
+	attrs := make(map[string]string)
+	for _, a := range node.Attrs {
+		attrs[a.Name.Local] = a.Value
+	}
+	for _, a := range ct.Attributes {
+		v, ok := attrs[a.Name]
+		if !ok {
+			if a.Use == "required" {
+				problems = append(problems, fmt.Sprintf("%s: attribute %s is required", path, a.Name))
+			}
+			continue
+		}
+		if problem := s.validateValue(nil, a.Type, v); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s/@%s: %s", path, a.Name, problem))
+		}
+	}
+
+	i := 0
+	for _, child := range ct.Sequence {
+		min, max := child.occurs()
+		count := 0
+		for i < len(node.Children) && node.Children[i].XMLName.Local == child.Name {
+			count++
+			childPath := path + "/" + child.Name
+			if max != 1 {
+				childPath = fmt.Sprintf("%s[%d]", childPath, count)
+			}
+			problems = append(problems, s.validateElement(childPath, child, node.Children[i])...)
+			i++
+		}
+		if count < min {
+			problems = append(problems, fmt.Sprintf("%s: element %s is required", path, child.Name))
+		}
+		if max >= 0 && count > max {
+			problems = append(problems, fmt.Sprintf("%s: element %s occurs %d times, max %d", path, child.Name, count, max))
+		}
+	}
+	if i < len(node.Children) {
+		problems = append(problems, fmt.Sprintf("%s: unexpected element %s", path, node.Children[i].XMLName.Local))
+	}
+
+	return problems
+}
+
+// validateValue checks value by simple type st or by the type name
+// if st is nil, returns the problem or empty string
+func (s *Schema) validateValue(st *xsdSimpleType, typ string, value string) string {
+	if st == nil {
+		if named, ok := s.simpleTypes[localName(typ)]; ok {
+			st = named
+		} else {
+			return validateBuiltin(localName(typ), value)
+		}
+	}
+
+	r := st.Restriction
+	if problem := s.validateValue(nil, r.Base, value); problem != "" {
+		return problem
+	}
+	for _, p := range r.Patterns {
+		re, _ := compilePattern(p.Value)
+		if !re.MatchString(value) {
+			return fmt.Sprintf("value %q does not match pattern %s", value, p.Value)
+		}
+	}
+	if len(r.Enumeration) > 0 {
+		found := false
+		for _, e := range r.Enumeration {
+			if e.Value == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("value %q is not allowed", value)
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if r.MinLength != nil {
+		if n, _ := strconv.Atoi(r.MinLength.Value); length < n {
+			return fmt.Sprintf("value %q is shorter than %d", value, n)
+		}
+	}
+	if r.MaxLength != nil {
+		if n, _ := strconv.Atoi(r.MaxLength.Value); length > n {
+			return fmt.Sprintf("value %q is longer than %d", value, n)
+		}
+	}
+	if r.MinInclusive != nil {
+		min, err := decimal.NewFromString(r.MinInclusive.Value)
+		v, err2 := decimal.NewFromString(value)
+		if err == nil && err2 == nil && v.LessThan(min) {
+			return fmt.Sprintf("value %s is less than %s", value, r.MinInclusive.Value)
+		}
+	}
+	if r.FractionDigits != nil {
+		n, _ := strconv.Atoi(r.FractionDigits.Value)
+		if dot := strings.IndexByte(value, '.'); dot >= 0 && len(strings.TrimRight(value[dot+1:], "0")) > n {
+			return fmt.Sprintf("value %s has more than %d fraction digits", value, n)
+		}
+	}
+
+	return ""
+}
+
+var (
+	decimalRe = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	integerRe = regexp.MustCompile(`^[+-]?\d+$`)
+)
+
+func validateBuiltin(typ string, value string) string {
+	switch typ {
+	case "", "string":
+		return ""
+	case "decimal":
+		if !decimalRe.MatchString(value) {
+			return fmt.Sprintf("value %q is not decimal", value)
+		}
+	case "integer", "positiveInteger", "nonNegativeInteger":
+		if !integerRe.MatchString(value) {
+			return fmt.Sprintf("value %q is not integer", value)
+		}
+		n, _ := strconv.ParseInt(value, 10, 64)
+		if typ == "positiveInteger" && n < 1 || typ == "nonNegativeInteger" && n < 0 {
+			return fmt.Sprintf("value %q should be %s", value, typ)
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Sprintf("value %q is not date", value)
+		}
+	case "dateTime":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Sprintf("value %q is not date time", value)
+		}
+	default:
+		return fmt.Sprintf("unknown type %s", typ)
+	}
+
+	return ""
+}
+
+// compilePattern compiles XSD pattern, which always matches the whole value
+func compilePattern(p string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + p + ")$")
+}
+
+func localName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+
+	return name
+}
@@ -9,6 +9,7 @@ import (
 	fm "github.com/amanbolat/gofmcon"
 	"github.com/pkg/errors"
 	"strconv"
+	"time"
 )
 
 var ErrZeroRecordsInResultSet = errors.New("0 records in fmResultSet after entry update")
@@ -100,6 +101,45 @@ func (s *EntryStore) GetEntryList(meta api.RequestMeta) ([]warehouse.Entry, api.
 	return entries, resMeta, nil
 }
 
+// GetEntryUpdates gets entries of the warehouse modified at or after since,
+// oldest first. Entries modified today are returned if since is zero
+func (s *EntryStore) GetEntryUpdates(warehouseCode string, since time.Time) ([]warehouse.Entry, error) {
+	from := time.Now().Format(fm.DATE_FORMAT)
+	if !since.IsZero() {
+		from = since.Format(fm.TIMESTAMP_FORMAT)
+	}
+
+	q := fm.NewFMQuery(s.databaseName, ENTRY_LAYOUT, fm.Find)
+	q.WithFields(
+		fm.FMQueryField{Name: "Warehouse", Value: warehouseCode, Op: fm.Equal},
+		fm.FMQueryField{Name: "Date_Modified_Timestamp", Value: from, Op: fm.GreaterThanEqual},
+	)
+	meta := api.RequestMeta{
+		SortFields: []api.SortField{{Name: "Date_Modified_Timestamp"}},
+	}
+	recs, _, err := fmutil.GetFileMakerRecordList(s, q, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []warehouse.Entry
+	for _, rec := range recs {
+		fEntry := warehouse.FileMakerEntry{}
+		b, err := rec.JsonFields()
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(b, &fEntry)
+		if err != nil {
+			return nil, err
+		}
+		fEntry.FMRecordID = rec.ID
+		entries = append(entries, fEntry.ToEntry())
+	}
+
+	return entries, nil
+}
+
 func (s *EntryStore) CreateEntry(e warehouse.Entry) (warehouse.Entry, error) {
 	q := fm.NewFMQuery(s.databaseName, ENTRY_LAYOUT, fm.New)
 	q.WithFields(
@@ -113,6 +153,12 @@ func (s *EntryStore) CreateEntry(e warehouse.Entry) (warehouse.Entry, error) {
 		fm.FMQueryField{Name: "is_found_for_shipment", Value: strconv.Itoa(fmutil.ConvertBoolToInt(e.IsFoundForShipment))},
 		fm.FMQueryField{Name: "has_brand", Value: strconv.Itoa(fmutil.ConvertBoolToInt(e.HasBrand))},
 		fm.FMQueryField{Name: "product_category", Value: string(e.ProductCategory)},
+		fm.FMQueryField{Name: "HSCode", Value: e.Customs.HSCode},
+		fm.FMQueryField{Name: "Material", Value: e.Customs.Material},
+		fm.FMQueryField{Name: "Brand", Value: e.Customs.Brand},
+		fm.FMQueryField{Name: "CountryOfOrigin", Value: e.Customs.CountryOfOrigin},
+		fm.FMQueryField{Name: "UnitValue", Value: e.Customs.UnitValue.String()},
+		fm.FMQueryField{Name: "NetWeight", Value: e.Customs.NetWeight.String()},
 		fm.FMQueryField{Name: "CreatedBy_Account", Value: s.conn.Username},
 	)
 
@@ -153,6 +199,12 @@ func (s *EntryStore) UpdateEntry(e warehouse.Entry) (*warehouse.Entry, error) {
 		fm.FMQueryField{Name: "is_found_for_shipment", Value: strconv.Itoa(fmutil.ConvertBoolToInt(e.IsFoundForShipment))},
 		fm.FMQueryField{Name: "has_brand", Value: strconv.Itoa(fmutil.ConvertBoolToInt(e.HasBrand))},
 		fm.FMQueryField{Name: "product_category", Value: string(e.ProductCategory)},
+		fm.FMQueryField{Name: "HSCode", Value: e.Customs.HSCode},
+		fm.FMQueryField{Name: "Material", Value: e.Customs.Material},
+		fm.FMQueryField{Name: "Brand", Value: e.Customs.Brand},
+		fm.FMQueryField{Name: "CountryOfOrigin", Value: e.Customs.CountryOfOrigin},
+		fm.FMQueryField{Name: "UnitValue", Value: e.Customs.UnitValue.String()},
+		fm.FMQueryField{Name: "NetWeight", Value: e.Customs.NetWeight.String()},
 	)
 
 	var auditData string
@@ -0,0 +1,139 @@
+package filemaker
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/crm"
+	"github.com/amanbolat/ca-warehouse-client/filemaker/fmutil"
+	fm "github.com/amanbolat/gofmcon"
+	"github.com/pkg/errors"
+)
+
+const (
+	NOTE_LAYOUT = "warehouse_note_single"
+)
+
+// NoteStore manages notes of shipments and entries. Notes are related
+// to the shipment or the entry by its id
+type NoteStore struct {
+	conn         *fm.FMConnector
+	databaseName string
+}
+
+func (r *NoteStore) DBName() string {
+	return r.databaseName
+}
+
+func (r *NoteStore) FMConn() *fm.FMConnector {
+	return r.conn
+}
+
+func NewNoteStore(conn *fm.FMConnector, dbName string) *NoteStore {
+	return &NoteStore{conn, dbName}
+}
+
+func decodeNote(rec *fm.Record) (crm.Note, error) {
+	fNote := crm.FileMakerNote{}
+	b, err := rec.JsonFields()
+	if err != nil {
+		return crm.Note{}, err
+	}
+	err = json.Unmarshal(b, &fNote)
+	if err != nil {
+		return crm.Note{}, err
+	}
+	fNote.FMRecordID = rec.ID
+
+	return *fNote.ToNote(), nil
+}
+
+// GetNoteList returns notes of the shipment or the entry
+func (r *NoteStore) GetNoteList(relatedID string) ([]crm.Note, error) {
+	q := fm.NewFMQuery(r.databaseName, NOTE_LAYOUT, fm.Find)
+	q.WithFields(
+		fm.FMQueryField{Name: "Id_relationID", Value: relatedID, Op: fm.Equal},
+	)
+	q.WithSortFields(fm.FMSortField{Name: "Date_Created_Timestamp", Order: fm.Ascending})
+
+	recs, _, err := fmutil.GetFileMakerRecordList(r, q, api.RequestMeta{})
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []crm.Note{}
+	for _, rec := range recs {
+		n, err := decodeNote(rec)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+
+	return notes, nil
+}
+
+func (r *NoteStore) GetNoteByID(id string) (crm.Note, error) {
+	q := fm.NewFMQuery(r.databaseName, NOTE_LAYOUT, fm.Find)
+	q.WithFields(
+		fm.FMQueryField{Name: "Id_note", Value: id, Op: fm.Equal},
+	)
+
+	rec, err := fmutil.GetFileMakerRecordSingle(r, q)
+	if err != nil {
+		return crm.Note{}, err
+	}
+
+	return decodeNote(rec)
+}
+
+// CreateNote creates note related to n.RelatedID
+func (r *NoteStore) CreateNote(n crm.Note) (crm.Note, error) {
+	q := fm.NewFMQuery(r.databaseName, NOTE_LAYOUT, fm.New)
+	q.WithFields(
+		fm.FMQueryField{Name: "Id_relationID", Value: n.RelatedID},
+		fm.FMQueryField{Name: "NoteContent", Value: n.Content},
+		fm.FMQueryField{Name: "Author", Value: n.Author},
+		fm.FMQueryField{Name: "CreatedBy_Account", Value: r.conn.Username},
+	)
+
+	return r.queryNote(q)
+}
+
+// UpdateNote saves content of the note and the user who edited it,
+// author of the note is not changed
+func (r *NoteStore) UpdateNote(n crm.Note) (crm.Note, error) {
+	q := fm.NewFMQuery(r.databaseName, NOTE_LAYOUT, fm.Edit)
+	q.WithRecordId(n.FMRecordID)
+	q.WithFields(
+		fm.FMQueryField{Name: "NoteContent", Value: n.Content},
+		fm.FMQueryField{Name: "UpdatedBy", Value: n.UpdatedBy},
+	)
+	fmutil.WithAudit(q, n.ID, "Notes", "NoteContent", n.Content, r.conn.Username)
+
+	return r.queryNote(q)
+}
+
+func (r *NoteStore) DeleteNote(n crm.Note) error {
+	q := fm.NewFMQuery(r.databaseName, NOTE_LAYOUT, fm.Delete)
+	q.WithRecordId(n.FMRecordID)
+
+	_, err := r.conn.Query(q)
+	if err != nil {
+		return errors.WithMessage(err, "database_error")
+	}
+
+	return nil
+}
+
+func (r *NoteStore) queryNote(q *fm.FMQuery) (crm.Note, error) {
+	fmSet, err := r.conn.Query(q)
+	if err != nil {
+		return crm.Note{}, errors.WithMessage(err, "database_error")
+	}
+
+	if len(fmSet.Resultset.Records) < 1 {
+		return crm.Note{}, ErrZeroRecordsInResultSet
+	}
+
+	return decodeNote(fmSet.Resultset.Records[0])
+}
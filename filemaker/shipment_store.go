@@ -2,21 +2,26 @@ package filemaker
 
 import (
 	"encoding/json"
+	"fmt"
 	query "github.com/amanbolat/ca-warehouse-client/api"
 	"github.com/amanbolat/ca-warehouse-client/filemaker/fmutil"
 	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
 	fm "github.com/amanbolat/gofmcon"
 	"github.com/pkg/errors"
+	"strconv"
 )
 
 const (
-	SHIPMENT_LAYOUT         = "warehouse_shipment_single"
-	SHIPMENT_UPDATES_LAYOUT = "warehouse_shipment_updates"
+	SHIPMENT_LAYOUT   = "warehouse_shipment_single"
+	UNIT_LOADS_PORTAL = "TO2b_Shipments||ShipmentDetails"
 )
 
 type ShipmentStore struct {
-	conn         *fm.FMConnector
-	databaseName string
+	conn            *fm.FMConnector
+	databaseName    string
+	transferPoints  *logistics.TransferPointRegistry
+	volumetricRules pricing.VolumetricRules
 }
 
 func (r *ShipmentStore) DBName() string {
@@ -27,8 +32,13 @@ func (r *ShipmentStore) FMConn() *fm.FMConnector {
 	return r.conn
 }
 
-func NewShipmentStore(conn *fm.FMConnector, dbName string) *ShipmentStore {
-	return &ShipmentStore{conn, dbName}
+func NewShipmentStore(conn *fm.FMConnector, dbName string, tps *logistics.TransferPointRegistry, rules pricing.VolumetricRules) *ShipmentStore {
+	return &ShipmentStore{
+		conn:            conn,
+		databaseName:    dbName,
+		transferPoints:  tps,
+		volumetricRules: rules,
+	}
 }
 
 // updateShipment updates shipment
@@ -42,33 +52,24 @@ func (r *ShipmentStore) updateShipment(q *fm.FMQuery) (logistics.Shipment, error
 		return logistics.Shipment{}, errors.New("database_error: update failed")
 	}
 
-	fShipment := logistics.FileMakerShipment{}
-	b, err := fmSet.Resultset.Records[0].JsonFields()
-	if err != nil {
-		return logistics.Shipment{}, err
-	}
-	err = json.Unmarshal(b, &fShipment)
+	sm, err := r.decodeShipment(fmSet.Resultset.Records[0])
 	if err != nil {
 		return logistics.Shipment{}, err
 	}
 
-	return fShipment.ToShipment(), nil
+	return r.loadConsolidation(sm)
 }
 
+// GetShipmentList finds shipments by meta.InternalFilter,
+// see logistics.MapShipmentFields
 func (r *ShipmentStore) GetShipmentList(meta query.RequestMeta) ([]logistics.Shipment, query.ResponseMeta, error) {
 	var resMeta query.ResponseMeta
 	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.Find)
-	q.WithFields(
-		fm.FMQueryField{
-			Name:  "Departure_Warehouse",
-			Value: "GZWH2",
-			Op:    fm.Equal,
-		},
-		fm.FMQueryField{
-			Name:  "ShipmentStatus_number",
-			Value: "1...2",
-			Op:    "=",
-		})
+	var qFields []fm.FMQueryField
+	for k, v := range meta.InternalFilter {
+		qFields = append(qFields, fm.FMQueryField{Name: k, Value: v, Op: "="})
+	}
+	q.WithFields(qFields...)
 
 	recs, resMeta, err := fmutil.GetFileMakerRecordList(r, q, meta)
 	if err != nil {
@@ -77,30 +78,27 @@ func (r *ShipmentStore) GetShipmentList(meta query.RequestMeta) ([]logistics.Shi
 
 	var shipments []logistics.Shipment
 	for _, rec := range recs {
-		fShipment := logistics.FileMakerShipment{}
-		b, err := rec.JsonFields()
+		s, err := r.decodeShipment(rec)
 		if err != nil {
 			return nil, resMeta, err
 		}
-		err = json.Unmarshal(b, &fShipment)
-		if err != nil {
-			return nil, resMeta, err
-		}
-		s := fShipment.ToShipment()
 		shipments = append(shipments, s)
 	}
 
 	return shipments, resMeta, nil
 }
 
-// GetShipmentUpdates gets only list of shipments with updated_at, id and code fields
-func (r *ShipmentStore) GetShipmentUpdates() ([]logistics.Shipment, query.ResponseMeta, error) {
+// GetShipmentUpdates gets shipments of the warehouse with status numbers 1..2. They are read
+// from the full shipment layout with unit loads, same as GetShipmentByCode,
+// so the poller compares all the fields of logistics.ShipmentSnapshot
+func (r *ShipmentStore) GetShipmentUpdates(warehouseCode string) ([]logistics.Shipment, query.ResponseMeta, error) {
 	var resMeta query.ResponseMeta
-	q := fm.NewFMQuery(r.databaseName, SHIPMENT_UPDATES_LAYOUT, fm.Find)
+	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.Find)
+	q.WithResponseLayout(SHIPMENT_LAYOUT)
 	q.WithFields(
 		fm.FMQueryField{
 			Name:  "Departure_Warehouse",
-			Value: "GZWH2",
+			Value: warehouseCode,
 			Op:    fm.Equal,
 		},
 		fm.FMQueryField{
@@ -116,16 +114,10 @@ func (r *ShipmentStore) GetShipmentUpdates() ([]logistics.Shipment, query.Respon
 
 	var shipments []logistics.Shipment
 	for _, rec := range recs {
-		fShipment := logistics.FileMakerShipment{}
-		b, err := rec.JsonFields()
-		if err != nil {
-			return nil, resMeta, err
-		}
-		err = json.Unmarshal(b, &fShipment)
+		s, err := r.decodeShipment(rec)
 		if err != nil {
 			return nil, resMeta, err
 		}
-		s := fShipment.ToShipment()
 		shipments = append(shipments, s)
 	}
 
@@ -144,6 +136,17 @@ func (r *ShipmentStore) GetShipmentByCode(code string) (logistics.Shipment, erro
 		return logistics.Shipment{}, err
 	}
 
+	sm, err := r.decodeShipment(rec)
+	if err != nil {
+		return logistics.Shipment{}, err
+	}
+
+	return r.loadConsolidation(sm)
+}
+
+// decodeShipment converts FileMaker record into shipment, sets
+// record ids of shipment and its unit loads and calculates its weight
+func (r *ShipmentStore) decodeShipment(rec *fm.Record) (logistics.Shipment, error) {
 	fShipment := logistics.FileMakerShipment{}
 	b, err := rec.JsonFields()
 	if err != nil {
@@ -153,6 +156,171 @@ func (r *ShipmentStore) GetShipmentByCode(code string) (logistics.Shipment, erro
 	if err != nil {
 		return logistics.Shipment{}, err
 	}
+	fShipment.FMRecordID = int64(rec.ID)
+
+	unitLoads, err := decodeUnitLoads(rec)
+	if err != nil {
+		return logistics.Shipment{}, err
+	}
+	if len(unitLoads) != len(fShipment.UnitLoads) {
+		return logistics.Shipment{}, errors.Errorf("shipment %s has %d unit loads, but %d portal records", fShipment.Code, len(fShipment.UnitLoads), len(unitLoads))
+	}
+	fShipment.UnitLoads = unitLoads
+
+	sm := fShipment.ToShipment(r.transferPoints)
+	sm.WeightCalculation = r.volumetricRules.CalculateWeight(sm)
+
+	return sm, nil
+}
+
+// decodeUnitLoads decodes every row of unit loads portal together with
+// its record id, so saved unit loads are updated and not created again
+func decodeUnitLoads(rec *fm.Record) ([]*logistics.FileMakerUnitLoad, error) {
+	var unitLoads []*logistics.FileMakerUnitLoad
+	for _, rr := range rec.RelatedSetFromTable(UNIT_LOADS_PORTAL).Records {
+		b, err := rr.JsonFields()
+		if err != nil {
+			return nil, err
+		}
+		ul := &logistics.FileMakerUnitLoad{}
+		err = json.Unmarshal(b, ul)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unit load record %d", rr.ID)
+		}
+		ul.FMRecordID = int64(rr.ID)
+		unitLoads = append(unitLoads, ul)
+	}
+
+	return unitLoads, nil
+}
+
+// UpdateShipmentStatus saves current status of the shipment
+func (r *ShipmentStore) UpdateShipmentStatus(sm logistics.Shipment) (logistics.Shipment, error) {
+	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.Edit)
+	q.WithRecordId(int(sm.FMRecordID))
+	q.WithFields(
+		fm.FMQueryField{Name: "ShipmentStatus_number", Value: strconv.Itoa(int(sm.CurrentStatusKey))},
+	)
+	fmutil.WithAudit(q, sm.ID, "Shipments", "ShipmentStatus_number", sm.CurrentStatus(), r.conn.Username)
+
+	return r.updateShipment(q)
+}
+
+// SaveUnitLoads writes unit loads of the shipment into ShipmentDetails portal.
+// Unit loads without record id are created, removed ones are deleted.
+// FileMaker can create only one portal row per request
+func (r *ShipmentStore) SaveUnitLoads(sm logistics.Shipment, removed ...*logistics.UnitLoad) (logistics.Shipment, error) {
+	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.Edit)
+	q.WithRecordId(int(sm.FMRecordID))
+
+	var fields []fm.FMQueryField
+	var created int
+	for _, ul := range sm.UnitLoads {
+		if ul.FMRecordID == 0 {
+			created++
+		}
+		if created > 1 {
+			return logistics.Shipment{}, errors.New("only one unit load could be created at once")
+		}
+
+		fields = append(fields, unitLoadFields(ul)...)
+	}
+
+	for _, ul := range removed {
+		if ul.FMRecordID == 0 {
+			continue
+		}
+		fields = append(fields, fm.FMQueryField{
+			Name:  "-delete.related",
+			Value: fmt.Sprintf("%s.%d", UNIT_LOADS_PORTAL, ul.FMRecordID),
+		})
+	}
+
+	q.WithFields(fields...)
+
+	var auditData string
+	for _, f := range fields {
+		auditData += fmt.Sprintf("[%s:%s]", f.Name, f.Value)
+	}
+	fmutil.WithAudit(q, sm.ID, "Shipments", "api_edit_unit_loads", auditData, r.conn.Username)
+
+	return r.updateShipment(q)
+}
+
+// unitLoadFields returns fields of the portal row. Zero record id
+// means new portal row
+func unitLoadFields(ul *logistics.UnitLoad) []fm.FMQueryField {
+	name := func(field string) string {
+		return fmt.Sprintf("%s::%s.%d", UNIT_LOADS_PORTAL, field, ul.FMRecordID)
+	}
+
+	return []fm.FMQueryField{
+		{Name: name("SequenceNumber"), Value: strconv.Itoa(ul.Sequence)},
+		{Name: name("Quantity"), Value: strconv.Itoa(ul.Quantity)},
+		{Name: name("SD_ProductName"), Value: ul.ProductName},
+		{Name: name("SD_Weight"), Value: ul.Weight.String()},
+		{Name: name("SD_Length"), Value: strconv.FormatInt(ul.Length, 10)},
+		{Name: name("SD_Width"), Value: strconv.FormatInt(ul.Width, 10)},
+		{Name: name("SD_Height"), Value: strconv.FormatInt(ul.Height, 10)},
+		{Name: name("SD_HSCode"), Value: ul.Customs.HSCode},
+		{Name: name("SD_Material"), Value: ul.Customs.Material},
+		{Name: name("SD_Brand"), Value: ul.Customs.Brand},
+		{Name: name("SD_CountryOfOrigin"), Value: ul.Customs.CountryOfOrigin},
+		{Name: name("SD_UnitValue"), Value: ul.Customs.UnitValue.String()},
+		{Name: name("SD_NetWeight"), Value: ul.Customs.NetWeight.String()},
+	}
+}
+
+// CreateConsolidation creates consolidation shipment
+func (r *ShipmentStore) CreateConsolidation(sm logistics.Shipment) (logistics.Shipment, error) {
+	if !sm.TransferPoint.IsKnown() {
+		return logistics.Shipment{}, errors.Errorf("unknown transfer point %s", sm.TransferPoint)
+	}
+	if sm.TransportMethod == nil {
+		return logistics.Shipment{}, errors.New("transport method is empty")
+	}
+
+	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.New)
+	q.WithFields(
+		fm.FMQueryField{Name: "CargoType_number", Value: strconv.Itoa(int(logistics.ConsolidationShipment))},
+		fm.FMQueryField{Name: "CustomerCode", Value: sm.CustomerCode},
+		fm.FMQueryField{Name: "ShipmentStatus_number", Value: strconv.Itoa(int(sm.CurrentStatusKey))},
+		fm.FMQueryField{Name: "TransferPoint_number", Value: strconv.Itoa(sm.TransferPoint.Key)},
+		fm.FMQueryField{Name: "TransportationMethod_number", Value: strconv.Itoa(int(*sm.TransportMethod))},
+		fm.FMQueryField{Name: "Departure_Warehouse", Value: sm.DepartureWarehouse},
+	)
+
+	return r.updateShipment(q)
+}
+
+// UpdateConsolidationID attaches shipment to the consolidation
+// or detaches it if consolidation id is empty
+func (r *ShipmentStore) UpdateConsolidationID(sm logistics.Shipment) (logistics.Shipment, error) {
+	q := fm.NewFMQuery(r.databaseName, SHIPMENT_LAYOUT, fm.Edit)
+	q.WithRecordId(int(sm.FMRecordID))
+	q.WithFields(
+		fm.FMQueryField{Name: "Id_consolidation", Value: sm.ConsolidationID},
+	)
+	fmutil.WithAudit(q, sm.ID, "Shipments", "Id_consolidation", sm.ConsolidationID, r.conn.Username)
+
+	return r.updateShipment(q)
+}
+
+// loadConsolidation replaces consolidated shipments of the consolidation
+// with fully fetched ones, so weights and unit loads include them
+func (r *ShipmentStore) loadConsolidation(sm logistics.Shipment) (logistics.Shipment, error) {
+	if !sm.IsConsolidation() {
+		return sm, nil
+	}
+
+	for i, child := range sm.Consolidation {
+		fullChild, err := r.GetShipmentByCode(child.Code)
+		if err != nil {
+			return logistics.Shipment{}, errors.WithMessagef(err, "consolidated shipment %s", child.Code)
+		}
+		sm.Consolidation[i] = &fullChild
+	}
+	sm.WeightCalculation = r.volumetricRules.CalculateWeight(sm)
 
-	return fShipment.ToShipment(), nil
+	return sm, nil
 }
@@ -0,0 +1,85 @@
+package filemaker
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/filemaker/fmutil"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	fm "github.com/amanbolat/gofmcon"
+)
+
+const (
+	TRANSFER_POINT_LAYOUT = "warehouse_transfer_point_list"
+)
+
+type FileMakerTransferPoint struct {
+	Key            int    `json:"TransferPoint_number"`
+	Slug           string `json:"Slug"`
+	NameZh         string `json:"Name_zh"`
+	NameRu         string `json:"Name_ru"`
+	NameEn         string `json:"Name_en"`
+	Country        string `json:"Country"`
+	CustomsCountry string `json:"CustomsCountry"`
+	Address        string `json:"Address"`
+	Contact        string `json:"Contact"`
+	TransitDays    int    `json:"TransitDays"`
+}
+
+func (ft FileMakerTransferPoint) ToTransferPoint() logistics.TransferPoint {
+	return logistics.TransferPoint{
+		Key:  ft.Key,
+		Slug: ft.Slug,
+		Names: map[string]string{
+			logistics.LangZh: ft.NameZh,
+			logistics.LangRu: ft.NameRu,
+			logistics.LangEn: ft.NameEn,
+		},
+		Country:        ft.Country,
+		CustomsCountry: ft.CustomsCountry,
+		Address:        ft.Address,
+		Contact:        ft.Contact,
+		TransitDays:    ft.TransitDays,
+	}
+}
+
+type TransferPointStore struct {
+	conn         *fm.FMConnector
+	databaseName string
+}
+
+func (r *TransferPointStore) DBName() string {
+	return r.databaseName
+}
+
+func (r *TransferPointStore) FMConn() *fm.FMConnector {
+	return r.conn
+}
+
+func NewTransferPointStore(conn *fm.FMConnector, dbName string) *TransferPointStore {
+	return &TransferPointStore{conn, dbName}
+}
+
+func (r *TransferPointStore) GetTransferPointList() ([]logistics.TransferPoint, error) {
+	q := fm.NewFMQuery(r.databaseName, TRANSFER_POINT_LAYOUT, fm.FindAll)
+
+	recs, _, err := fmutil.GetFileMakerRecordList(r, q, api.RequestMeta{PerPage: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	var tps []logistics.TransferPoint
+	for _, rec := range recs {
+		ft := FileMakerTransferPoint{}
+		b, err := rec.JsonFields()
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(b, &ft)
+		if err != nil {
+			return nil, err
+		}
+		tps = append(tps, ft.ToTransferPoint())
+	}
+
+	return tps, nil
+}
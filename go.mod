@@ -9,7 +9,7 @@ require (
 	github.com/fogleman/gg v1.3.0
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/gorilla/schema v1.1.0
-	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/joho/godotenv v1.3.0
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/labstack/echo/v4 v4.1.16
@@ -21,6 +21,7 @@ require (
 	github.com/signintech/gopdf v0.9.8
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.5.1
+	github.com/tealeg/xlsx v1.0.5
 	github.com/tmdvs/Go-Emoji-Utils v1.1.0
 	github.com/urfave/cli/v2 v2.2.0
 	go.etcd.io/bbolt v1.3.5
@@ -0,0 +1,32 @@
+package integration
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"time"
+)
+
+// Delivery is the manifest pushed to the partner with all the attempts
+type Delivery struct {
+	ID           string                   `json:"id"`
+	PartnerCode  string                   `json:"partner_code"`
+	ShipmentCode string                   `json:"shipment_code"`
+	Endpoint     string                   `json:"endpoint"`
+	Format       logistics.ManifestFormat `json:"format"`
+	ContentType  string                   `json:"content_type"`
+	Payload      string                   `json:"payload"`
+	Status       delivery.Status          `json:"status"`
+	Attempts     []delivery.Attempt       `json:"attempts"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+}
+
+// DeliveryLog keeps deliveries, CreateDelivery sets delivery id.
+// RequeueDelivery sets status of the finished delivery back to pending
+// in one transaction, delivery.ErrInProgress is returned for pending one
+type DeliveryLog interface {
+	CreateDelivery(d Delivery) (Delivery, error)
+	SaveDelivery(d Delivery) error
+	GetDelivery(id string) (Delivery, error)
+	RequeueDelivery(id string, at time.Time) (Delivery, error)
+}
@@ -0,0 +1,112 @@
+// Package integration pushes manifests of sent out shipments
+// to the partners' HTTP endpoints
+package integration
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type ManifestUnitLoad struct {
+	Sequence    int             `json:"sequence" xml:"Sequence"`
+	Quantity    int             `json:"quantity" xml:"Quantity"`
+	ProductName string          `json:"product_name" xml:"ProductName"`
+	Weight      decimal.Decimal `json:"weight" xml:"Weight"`
+	Length      int64           `json:"length" xml:"Length"`
+	Width       int64           `json:"width" xml:"Width"`
+	Height      int64           `json:"height" xml:"Height"`
+	Cubage      decimal.Decimal `json:"cubage" xml:"Cubage"`
+}
+
+type ManifestRecipient struct {
+	Name        string `json:"name" xml:"Name"`
+	NameLatin   string `json:"name_latin" xml:"NameLatin"`
+	Phone       string `json:"phone" xml:"Phone"`
+	Destination string `json:"destination" xml:"Destination"`
+	City        string `json:"city" xml:"City"`
+}
+
+// Manifest is the shipment data partner needs to deliver it to the recipient
+type Manifest struct {
+	XMLName        xml.Name                 `json:"-" xml:"Manifest"`
+	ShipmentCode   string                   `json:"shipment_code" xml:"ShipmentCode"`
+	PartnerCode    string                   `json:"partner_code" xml:"PartnerCode"`
+	DeliveryMethod logistics.DeliveryMethod `json:"delivery_method" xml:"DeliveryMethod"`
+	TransferPoint  string                   `json:"transfer_point" xml:"TransferPoint"`
+	SentOutAt      time.Time                `json:"sent_out_at" xml:"SentOutAt"`
+	NeedDeclare    bool                     `json:"need_declare" xml:"NeedDeclare"`
+	CargoValue     decimal.Decimal          `json:"cargo_value" xml:"CargoValue"`
+	ProductName    string                   `json:"product_name" xml:"ProductName"`
+	Recipient      ManifestRecipient        `json:"recipient" xml:"Recipient"`
+	Packages       int                      `json:"packages" xml:"Packages"`
+	TotalWeight    decimal.Decimal          `json:"total_weight" xml:"TotalWeight"`
+	TotalCubage    decimal.Decimal          `json:"total_cubage" xml:"TotalCubage"`
+	UnitLoads      []ManifestUnitLoad       `json:"unit_loads" xml:"UnitLoads>UnitLoad"`
+}
+
+// NewManifest collects manifest data of the shipment including
+// unit loads of the consolidated shipments
+func NewManifest(sm logistics.Shipment, sentOutAt time.Time) Manifest {
+	r := sm.PartnerInfo.Recipient
+	nr := r.Normalize(sm.TransferPoint)
+	m := Manifest{
+		ShipmentCode:   sm.Code,
+		PartnerCode:    sm.PartnerInfo.Code,
+		DeliveryMethod: sm.PartnerInfo.DeliveryMethod,
+		TransferPoint:  sm.TransferPoint.Slug,
+		SentOutAt:      sentOutAt,
+		NeedDeclare:    sm.NeedDeclare,
+		CargoValue:     decimal.NewFromFloat(sm.PartnerInfo.CargoValue).Round(2),
+		ProductName:    sm.PartnerInfo.ProductName,
+		Recipient: ManifestRecipient{
+			Name:        r.Name,
+			NameLatin:   nr.NameLatin,
+			Phone:       r.PhoneNumber,
+			Destination: r.Destination,
+		},
+		TotalWeight: sm.Weight(),
+		TotalCubage: sm.Cubage(),
+		UnitLoads:   []ManifestUnitLoad{},
+	}
+	if nr.Phone != "" {
+		m.Recipient.Phone = nr.Phone
+	}
+	if nr.City != nil {
+		m.Recipient.City = nr.City.NameRu
+	}
+
+	for _, ul := range sm.AllUnitLoads() {
+		m.UnitLoads = append(m.UnitLoads, ManifestUnitLoad{
+			Sequence:    ul.Sequence,
+			Quantity:    ul.Quantity,
+			ProductName: ul.ProductName,
+			Weight:      ul.Weight,
+			Length:      ul.Length,
+			Width:       ul.Width,
+			Height:      ul.Height,
+			Cubage:      ul.Cubage(),
+		})
+	}
+	m.Packages = len(m.UnitLoads)
+
+	return m
+}
+
+// Encode returns manifest in XML if format is xml, otherwise in JSON.
+// CSV manifests are sent by email and are not pushed
+func (m Manifest) Encode(format logistics.ManifestFormat) ([]byte, string, error) {
+	if format == logistics.ManifestXML {
+		data, err := xml.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return append([]byte(xml.Header), data...), "application/xml; charset=utf-8", nil
+	}
+
+	data, err := json.Marshal(m)
+
+	return data, "application/json; charset=utf-8", err
+}
@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var ErrNoIntegration = errors.New("integration: partner has no enabled integration")
+
+// PushConfig of manifest pushing. Attempt n waits PartnerPushBackoff * 2^(n-2)
+// after the previous one failed
+type PushConfig struct {
+	PartnerPushTimeout time.Duration `split_words:"true" default:"10s"`
+	PartnerPushRetries int           `split_words:"true" default:"3"`
+	PartnerPushBackoff time.Duration `split_words:"true" default:"30s"`
+}
+
+// Pusher sends manifests of the sent out shipments to the partners
+type Pusher struct {
+	sender   *delivery.Sender
+	partners *logistics.PartnerRegistry
+	log      DeliveryLog
+	logger   *logrus.Logger
+}
+
+func NewPusher(config PushConfig, partners *logistics.PartnerRegistry, log DeliveryLog, logger *logrus.Logger) *Pusher {
+	return &Pusher{
+		sender: delivery.NewSender(delivery.Config{
+			Timeout: config.PartnerPushTimeout,
+			Retries: config.PartnerPushRetries,
+			Backoff: config.PartnerPushBackoff,
+		}),
+		partners: partners,
+		log:      log,
+		logger:   logger,
+	}
+}
+
+// Prepare saves pending delivery of the shipment manifest
+func (p *Pusher) Prepare(sm logistics.Shipment, sentOutAt time.Time) (Delivery, error) {
+	partner, ok := p.partners.ByCode(sm.PartnerInfo.Code)
+	if !ok || !partner.Integration.Enabled {
+		return Delivery{}, ErrNoIntegration
+	}
+
+	format := logistics.ManifestJSON
+	if partner.ManifestFormat == logistics.ManifestXML {
+		format = logistics.ManifestXML
+	}
+	payload, contentType, err := NewManifest(sm, sentOutAt).Encode(format)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	return p.log.CreateDelivery(Delivery{
+		PartnerCode:  partner.Code,
+		ShipmentCode: sm.Code,
+		Endpoint:     partner.Integration.Endpoint,
+		Format:       format,
+		ContentType:  contentType,
+		Payload:      string(payload),
+		Status:       delivery.Pending,
+		Attempts:     []delivery.Attempt{},
+		CreatedAt:    sentOutAt,
+		UpdatedAt:    sentOutAt,
+	})
+}
+
+// PushShipment prepares delivery and sends it in background
+func (p *Pusher) PushShipment(sm logistics.Shipment, sentOutAt time.Time) (Delivery, error) {
+	d, err := p.Prepare(sm, sentOutAt)
+	if err != nil {
+		return Delivery{}, err
+	}
+	go p.Deliver(d)
+
+	return d, nil
+}
+
+// Redeliver sends failed or delivered manifest again in background
+// to the current endpoint of the partner
+func (p *Pusher) Redeliver(id string) (Delivery, error) {
+	d, err := p.log.RequeueDelivery(id, time.Now())
+	if err != nil {
+		return Delivery{}, err
+	}
+	go p.Deliver(d)
+
+	return d, nil
+}
+
+// Deliver sends the manifest retrying on failures, every attempt
+// is saved to the delivery log. Delivery fails right away if partner
+// has no enabled integration anymore
+func (p *Pusher) Deliver(d Delivery) Delivery {
+	req := delivery.Request{ID: d.ID, ContentType: d.ContentType, Body: []byte(d.Payload)}
+	p.sender.Send(req, func() (delivery.Target, error) {
+		partner, ok := p.partners.ByCode(d.PartnerCode)
+		if !ok || !partner.Integration.Enabled {
+			return delivery.Target{}, ErrNoIntegration
+		}
+		return delivery.Target{URL: partner.Integration.Endpoint, Secret: partner.Integration.Secret}, nil
+	}, nil, func(t delivery.Target, a delivery.Attempt, status delivery.Status) {
+		if t.URL != "" {
+			d.Endpoint = t.URL
+		}
+		d.Attempts = append(d.Attempts, a)
+		d.UpdatedAt = a.At
+		d.Status = status
+
+		err := p.log.SaveDelivery(d)
+		if err != nil {
+			p.logger.Errorf("failed to save delivery %s of shipment %s: %v", d.ID, d.ShipmentCode, err)
+		}
+	})
+
+	if d.Status == delivery.Failed {
+		p.logger.Errorf("failed to push manifest of shipment %s to partner %s", d.ShipmentCode, d.PartnerCode)
+	}
+
+	return d
+}
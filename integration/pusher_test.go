@@ -0,0 +1,140 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/integration"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryLog struct {
+	mu         sync.Mutex
+	deliveries map[string]integration.Delivery
+}
+
+func (l *memoryLog) CreateDelivery(d integration.Delivery) (integration.Delivery, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d.ID = fmt.Sprintf("PD%06d", len(l.deliveries)+1)
+	l.deliveries[d.ID] = d
+	return d, nil
+}
+
+func (l *memoryLog) SaveDelivery(d integration.Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.deliveries[d.ID] = d
+	return nil
+}
+
+func (l *memoryLog) GetDelivery(id string) (integration.Delivery, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.deliveries[id], nil
+}
+
+func (l *memoryLog) RequeueDelivery(id string, at time.Time) (integration.Delivery, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d := l.deliveries[id]
+	if d.Status == delivery.Pending {
+		return integration.Delivery{}, delivery.ErrInProgress
+	}
+	d.Status = delivery.Pending
+	d.UpdatedAt = at
+	l.deliveries[id] = d
+	return d, nil
+}
+
+func TestPusher_Deliver(t *testing.T) {
+	mock := delivery.NewMockReceiver("secret", 2)
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	partners := logistics.NewPartnerRegistry([]logistics.Partner{{
+		Code:            "XX-PARTNER",
+		Name:            "Partner",
+		DeliveryMethods: []logistics.DeliveryMethod{logistics.DMLandRail},
+		Integration:     logistics.PartnerIntegration{Enabled: true, Endpoint: srv.URL, Secret: "secret"},
+	}})
+
+	sm := logistics.Shipment{
+		Code:        "SPN00123",
+		NeedDeclare: true,
+		PartnerInfo: logistics.PartnerInfo{
+			Code:       "XX-PARTNER",
+			CargoValue: 150,
+			Recipient:  logistics.Recipient{Name: "Иванов Иван", PhoneNumber: "87011234567", Destination: "Алматы"},
+		},
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Quantity: 2, Weight: decimal.NewFromInt(30), Length: 100, Width: 50, Height: 50},
+		},
+	}
+
+	log := &memoryLog{deliveries: make(map[string]integration.Delivery)}
+	config := integration.PushConfig{PartnerPushTimeout: time.Second, PartnerPushRetries: 2, PartnerPushBackoff: time.Millisecond}
+	pusher := integration.NewPusher(config, partners, log, logrus.New())
+
+	d, err := pusher.Prepare(sm, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, delivery.Pending, d.Status)
+
+	d = pusher.Deliver(d)
+	assert.Equal(t, delivery.Delivered, d.Status)
+	assert.Len(t, d.Attempts, 3)
+	assert.Equal(t, 503, d.Attempts[0].StatusCode)
+
+	received := mock.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, d.ID, received[0].DeliveryID)
+	var m integration.Manifest
+	require.NoError(t, json.Unmarshal(received[0].Body, &m))
+	assert.Equal(t, "SPN00123", m.ShipmentCode)
+	assert.Equal(t, "+77011234567", m.Recipient.Phone)
+	assert.True(t, m.NeedDeclare)
+	assert.Equal(t, 1, m.Packages)
+
+	// wrong secret is rejected by partner
+	mock.Secret = "rotated"
+	d, err = pusher.Prepare(sm, time.Now())
+	require.NoError(t, err)
+	d = pusher.Deliver(d)
+	assert.Equal(t, delivery.Failed, d.Status)
+	assert.Equal(t, 401, d.Attempts[2].StatusCode)
+
+	// moved endpoint and rotated secret are taken from the registry
+	moved := delivery.NewMockReceiver("rotated", 0)
+	movedSrv := httptest.NewServer(moved)
+	defer movedSrv.Close()
+	partners.Set([]logistics.Partner{{
+		Code:            "XX-PARTNER",
+		Name:            "Partner",
+		DeliveryMethods: []logistics.DeliveryMethod{logistics.DMLandRail},
+		Integration:     logistics.PartnerIntegration{Enabled: true, Endpoint: movedSrv.URL, Secret: "rotated"},
+	}})
+	d = pusher.Deliver(d)
+	assert.Equal(t, delivery.Delivered, d.Status)
+	assert.Equal(t, movedSrv.URL, d.Endpoint)
+	assert.Len(t, moved.Received(), 1)
+
+	// disabled integration fails delivery without retries
+	partners.Set([]logistics.Partner{{Code: "XX-PARTNER", Name: "Partner"}})
+	attempts := len(d.Attempts)
+	d = pusher.Deliver(d)
+	assert.Equal(t, delivery.Failed, d.Status)
+	require.Len(t, d.Attempts, attempts+1)
+	assert.Equal(t, integration.ErrNoIntegration.Error(), d.Attempts[attempts].Error)
+
+	sm.PartnerInfo.Code = "YY-OTHER"
+	_, err = pusher.Prepare(sm, time.Now())
+	assert.Equal(t, integration.ErrNoIntegration, err)
+}
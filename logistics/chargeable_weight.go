@@ -0,0 +1,33 @@
+package logistics
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// VolumetricWeight returns volumetric weight of the unit load in kg
+func (ul *UnitLoad) VolumetricWeight(divisor int64) decimal.Decimal {
+	if divisor <= 0 {
+		return decimal.Zero
+	}
+
+	cm3 := decimal.New(ul.Length*ul.Width*ul.Height, 0)
+
+	return cm3.DivRound(decimal.New(divisor, 0), 2)
+}
+
+type UnitLoadWeight struct {
+	Sequence         int             `json:"sequence"`
+	Weight           decimal.Decimal `json:"weight"`
+	VolumetricWeight decimal.Decimal `json:"volumetric_weight"`
+}
+
+// WeightCalculation shows how chargeable weight of the shipment was
+// calculated, see pricing.VolumetricRules
+type WeightCalculation struct {
+	// Divisor is volumetric divisor in cm3/kg
+	Divisor          int64            `json:"divisor"`
+	Weight           decimal.Decimal  `json:"weight"`
+	VolumetricWeight decimal.Decimal  `json:"volumetric_weight"`
+	ChargeableWeight decimal.Decimal  `json:"chargeable_weight"`
+	UnitLoads        []UnitLoadWeight `json:"unit_loads"`
+}
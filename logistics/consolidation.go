@@ -0,0 +1,141 @@
+package logistics
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotConsolidation        = errors.New("consolidation: shipment is not a consolidation")
+	ErrChildIsConsolidation    = errors.New("consolidation: consolidation could not be attached to another consolidation")
+	ErrChildAlreadyAttached    = errors.New("consolidation: shipment is already attached to a consolidation")
+	ErrChildNotAttached        = errors.New("consolidation: shipment is not attached to the consolidation")
+	ErrTransferPointMismatch   = errors.New("consolidation: shipments should have the same transfer point")
+	ErrTransportMethodMismatch = errors.New("consolidation: shipments should have the same transport method")
+	ErrChildAlreadySentOut     = errors.New("consolidation: shipment is already sent out")
+)
+
+// NewConsolidation creates consolidation shipment which is going to be
+// prepared in the departure warehouse
+func NewConsolidation(transferPoint TransferPoint, transportMethod TransportMethod, departureWarehouse string) Shipment {
+	return Shipment{
+		Type:               ShipmentTypeP(int(ConsolidationShipment)),
+		CurrentStatusKey:   Preparation,
+		TransferPoint:      transferPoint,
+		TransportMethod:    TransportMethodP(int(transportMethod)),
+		DepartureWarehouse: departureWarehouse,
+	}
+}
+
+func (s Shipment) IsConsolidation() bool {
+	return s.Type != nil && *s.Type == ConsolidationShipment
+}
+
+// CanAttach checks if child shipment could be a part of the consolidation
+func (s Shipment) CanAttach(child Shipment) error {
+	if !s.IsConsolidation() {
+		return ErrNotConsolidation
+	}
+	if s.CurrentStatusKey != Preparation {
+		return errors.Errorf("consolidation: consolidation should be on %s status", Preparation)
+	}
+	if child.IsConsolidation() {
+		return ErrChildIsConsolidation
+	}
+	if child.ConsolidationID != "" && child.ConsolidationID != s.ID {
+		return ErrChildAlreadyAttached
+	}
+	if child.CurrentStatusKey >= SentOut {
+		return ErrChildAlreadySentOut
+	}
+	if child.TransferPoint.Slug != s.TransferPoint.Slug {
+		return ErrTransferPointMismatch
+	}
+	if child.TransportMethod == nil || s.TransportMethod == nil || *child.TransportMethod != *s.TransportMethod {
+		return ErrTransportMethodMismatch
+	}
+
+	return nil
+}
+
+// Attach adds child shipment to the consolidation
+func (s *Shipment) Attach(child *Shipment) error {
+	err := s.CanAttach(*child)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range s.Consolidation {
+		if c.Code == child.Code {
+			return nil
+		}
+	}
+
+	child.ConsolidationID = s.ID
+	s.Consolidation = append(s.Consolidation, child)
+
+	return nil
+}
+
+// Detach removes child shipment from the consolidation
+func (s *Shipment) Detach(code string) (*Shipment, error) {
+	if !s.IsConsolidation() {
+		return nil, ErrNotConsolidation
+	}
+	if s.CurrentStatusKey != Preparation {
+		return nil, errors.Errorf("consolidation: consolidation should be on %s status", Preparation)
+	}
+
+	var detached *Shipment
+	var children []*Shipment
+	for _, c := range s.Consolidation {
+		if c.Code == code {
+			detached = c
+			continue
+		}
+		children = append(children, c)
+	}
+	if detached == nil {
+		return nil, ErrChildNotAttached
+	}
+
+	detached.ConsolidationID = ""
+	s.Consolidation = children
+
+	return detached, nil
+}
+
+// AllUnitLoads returns unit loads of the shipment and
+// unit loads of all consolidated shipments
+func (s Shipment) AllUnitLoads() []*UnitLoad {
+	unitLoads := append([]*UnitLoad{}, s.UnitLoads...)
+	for _, c := range s.Consolidation {
+		unitLoads = append(unitLoads, c.AllUnitLoads()...)
+	}
+
+	return unitLoads
+}
+
+// AllPackagesQty returns packages quantity of the shipment, for consolidation
+// it's the sum of the consolidated shipments as consolidation itself has no packages
+func (s Shipment) AllPackagesQty() int {
+	if !s.IsConsolidation() {
+		return s.PackagesQty
+	}
+
+	var qty int
+	for _, c := range s.Consolidation {
+		qty += c.AllPackagesQty()
+	}
+
+	return qty
+}
+
+// ChildCodes returns codes of consolidated shipments
+func (s Shipment) ChildCodes() []string {
+	var codes []string
+	for _, c := range s.Consolidation {
+		codes = append(codes, c.Code)
+	}
+
+	return codes
+}
@@ -0,0 +1,56 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShipment_Attach(t *testing.T) {
+	cons := logistics.NewConsolidation(logistics.TransferPoint{Slug: "almaty"}, logistics.Auto, "GZWH2")
+	cons.ID = "S1"
+	cons.Code = "SPN00100"
+
+	child := logistics.Shipment{
+		Code:             "SPN00101",
+		Type:             logistics.ShipmentTypeP(int(logistics.CommonShipment)),
+		CurrentStatusKey: logistics.Packed,
+		TransferPoint:    logistics.TransferPoint{Slug: "almaty"},
+		TransportMethod:  logistics.TransportMethodP(int(logistics.Auto)),
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Quantity: 1, Weight: decimal.NewFromInt(100), Length: 100, Width: 100, Height: 50},
+		},
+	}
+	other := child
+	other.Code = "SPN00102"
+	other.TransferPoint = logistics.TransferPoint{Slug: "moscow"}
+
+	assert.Equal(t, logistics.ErrTransferPointMismatch, cons.Attach(&other))
+
+	other.TransferPoint = logistics.TransferPoint{Slug: "almaty"}
+	other.TransportMethod = logistics.TransportMethodP(int(logistics.Train))
+	assert.Equal(t, logistics.ErrTransportMethodMismatch, cons.Attach(&other))
+
+	other.TransportMethod = logistics.TransportMethodP(int(logistics.Auto))
+	other.ConsolidationID = "S2"
+	assert.Equal(t, logistics.ErrChildAlreadyAttached, cons.Attach(&other))
+
+	other.ConsolidationID = ""
+	assert.NoError(t, cons.Attach(&child))
+	assert.NoError(t, cons.Attach(&other))
+	assert.Equal(t, "S1", child.ConsolidationID)
+	assert.Equal(t, []string{"SPN00101", "SPN00102"}, cons.ChildCodes())
+	assert.Len(t, cons.AllUnitLoads(), 2)
+	assert.Equal(t, "200", cons.Weight().String())
+	assert.Equal(t, "1", cons.Cubage().String())
+
+	detached, err := cons.Detach("SPN00101")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", detached.ConsolidationID)
+	}
+	assert.Equal(t, "100", cons.Weight().String())
+
+	_, err = cons.Detach("SPN00101")
+	assert.Equal(t, logistics.ErrChildNotAttached, err)
+}
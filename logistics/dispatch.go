@@ -0,0 +1,228 @@
+package logistics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDispatchShipmentNotReady     = errors.New("dispatch: shipment should be packed")
+	ErrDispatchShipmentAssigned     = errors.New("dispatch: shipment is already assigned to the dispatch")
+	ErrDispatchShipmentNotAssigned  = errors.New("dispatch: shipment is not assigned to the dispatch")
+	ErrDispatchShipmentConsolidated = errors.New("dispatch: consolidated shipment should be dispatched with its consolidation")
+)
+
+// DispatchCapacityWarningRatio is the vehicle load ratio
+// after which the dispatch is considered almost full
+var DispatchCapacityWarningRatio = decimal.NewFromFloat(0.9)
+
+// DispatchShipment is the shipment assigned to the dispatch.
+// Figures are taken at the moment of assignment
+type DispatchShipment struct {
+	Code         string          `json:"code"`
+	CustomerCode string          `json:"customer_code"`
+	UnitLoads    int             `json:"unit_loads"`
+	Weight       decimal.Decimal `json:"weight"`
+	Cubage       decimal.Decimal `json:"cubage"`
+	Recipient    Recipient       `json:"recipient"`
+	AssignedAt   time.Time       `json:"assigned_at"`
+}
+
+func NewDispatchShipment(s Shipment, now time.Time) DispatchShipment {
+	return DispatchShipment{
+		Code:         s.Code,
+		CustomerCode: s.CustomerCode,
+		UnitLoads:    len(s.AllUnitLoads()),
+		Weight:       s.Weight(),
+		Cubage:       s.Cubage(),
+		Recipient:    s.PartnerInfo.Recipient,
+		AssignedAt:   now,
+	}
+}
+
+// Dispatch is the truck or container shipments are loaded to
+type Dispatch struct {
+	ID      string `json:"id"`
+	Vehicle string `json:"vehicle"`
+	// CapacityWeight in kg
+	CapacityWeight decimal.Decimal `json:"capacity_weight"`
+	// CapacityCubage in m3
+	CapacityCubage decimal.Decimal    `json:"capacity_cubage"`
+	TransferPoint  TransferPoint      `json:"transfer_point"`
+	DepartureDate  time.Time          `json:"departure_date"`
+	CreatedAt      time.Time          `json:"created_at"`
+	CreatedBy      string             `json:"created_by"`
+	Shipments      []DispatchShipment `json:"shipments"`
+}
+
+func (d *Dispatch) Validate() error {
+	d.Vehicle = strings.TrimSpace(d.Vehicle)
+	if d.Vehicle == "" {
+		return errors.New("dispatch: vehicle is empty")
+	}
+	if d.CapacityWeight.LessThanOrEqual(decimal.Zero) || d.CapacityCubage.LessThanOrEqual(decimal.Zero) {
+		return errors.New("dispatch: capacity should be positive")
+	}
+	if d.TransferPoint.Slug == "" || !d.TransferPoint.IsKnown() {
+		return errors.Errorf("dispatch: unknown transfer point %s", d.TransferPoint)
+	}
+	if d.DepartureDate.IsZero() {
+		return errors.New("dispatch: departure date is empty")
+	}
+	if d.Shipments == nil {
+		d.Shipments = []DispatchShipment{}
+	}
+
+	return nil
+}
+
+func (d Dispatch) HasShipment(code string) bool {
+	for _, ds := range d.Shipments {
+		if strings.EqualFold(ds.Code, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanAssign checks if the shipment could be loaded to the dispatch.
+// Capacity is not checked, overloading only raises warnings
+func (d Dispatch) CanAssign(s Shipment) error {
+	if s.CurrentStatusKey != Packed {
+		return ErrDispatchShipmentNotReady
+	}
+	if s.ConsolidationID != "" {
+		return ErrDispatchShipmentConsolidated
+	}
+	if s.TransferPoint.Slug != d.TransferPoint.Slug {
+		return ErrTransferPointMismatch
+	}
+	if d.HasShipment(s.Code) {
+		return ErrDispatchShipmentAssigned
+	}
+
+	return nil
+}
+
+func (d *Dispatch) Assign(s Shipment, now time.Time) error {
+	err := d.CanAssign(s)
+	if err != nil {
+		return err
+	}
+	d.Shipments = append(d.Shipments, NewDispatchShipment(s, now))
+
+	return nil
+}
+
+func (d *Dispatch) Unassign(code string) error {
+	for i, ds := range d.Shipments {
+		if strings.EqualFold(ds.Code, code) {
+			d.Shipments = append(d.Shipments[:i], d.Shipments[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrDispatchShipmentNotAssigned
+}
+
+// DispatchLoad is the total load of the dispatch
+// and how much of the vehicle capacity is used
+type DispatchLoad struct {
+	UnitLoads int             `json:"unit_loads"`
+	Weight    decimal.Decimal `json:"weight"`
+	Cubage    decimal.Decimal `json:"cubage"`
+	// WeightRatio and CubageRatio are used part of the capacity, 1 is full
+	WeightRatio decimal.Decimal   `json:"weight_ratio"`
+	CubageRatio decimal.Decimal   `json:"cubage_ratio"`
+	Warnings    []ValidationIssue `json:"warnings"`
+}
+
+func (d Dispatch) Load() DispatchLoad {
+	l := DispatchLoad{Warnings: []ValidationIssue{}}
+	for _, ds := range d.Shipments {
+		l.UnitLoads += ds.UnitLoads
+		l.Weight = l.Weight.Add(ds.Weight)
+		l.Cubage = l.Cubage.Add(ds.Cubage)
+	}
+
+	if d.CapacityWeight.GreaterThan(decimal.Zero) {
+		l.WeightRatio = l.Weight.DivRound(d.CapacityWeight, 4)
+	}
+	if d.CapacityCubage.GreaterThan(decimal.Zero) {
+		l.CubageRatio = l.Cubage.DivRound(d.CapacityCubage, 4)
+	}
+
+	capacityWarning := func(rule, name string, ratio, load, capacity decimal.Decimal, unit string) {
+		switch {
+		case ratio.GreaterThan(decimal.New(1, 0)):
+			l.Warnings = append(l.Warnings, ValidationIssue{
+				Rule:     rule,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s超载: %v / %v %s", name, load, capacity, unit),
+			})
+		case ratio.GreaterThanOrEqual(DispatchCapacityWarningRatio):
+			l.Warnings = append(l.Warnings, ValidationIssue{
+				Rule:     rule,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s接近满载: %v / %v %s", name, load, capacity, unit),
+			})
+		}
+	}
+	capacityWarning("capacity_weight", "重量", l.WeightRatio, l.Weight, d.CapacityWeight, "kg")
+	capacityWarning("capacity_cubage", "体积", l.CubageRatio, l.Cubage, d.CapacityCubage, "m3")
+
+	return l
+}
+
+var DispatchManifestCSVHeader = []string{
+	"code",
+	"customer_code",
+	"unit_loads",
+	"weight",
+	"cubage",
+	"recipient_name",
+	"recipient_phone",
+	"recipient_destination",
+}
+
+// WriteDispatchManifestCSV writes list of the dispatch shipments
+// with the total row at the end
+func WriteDispatchManifestCSV(w io.Writer, d Dispatch) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(DispatchManifestCSVHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range d.Shipments {
+		err = cw.Write([]string{
+			ds.Code,
+			ds.CustomerCode,
+			strconv.Itoa(ds.UnitLoads),
+			ds.Weight.String(),
+			ds.Cubage.String(),
+			ds.Recipient.Name,
+			ds.Recipient.PhoneNumber,
+			ds.Recipient.Destination,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	l := d.Load()
+	err = cw.Write([]string{"total", "", strconv.Itoa(l.UnitLoads), l.Weight.String(), l.Cubage.String(), "", "", ""})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
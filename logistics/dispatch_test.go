@@ -0,0 +1,57 @@
+package logistics_test
+
+import (
+	"bytes"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDispatch_Assign(t *testing.T) {
+	d := logistics.Dispatch{
+		Vehicle:        "粤A12345",
+		CapacityWeight: decimal.NewFromInt(100),
+		CapacityCubage: decimal.NewFromInt(10),
+		TransferPoint:  logistics.TransferPoint{Key: 8, Slug: "almaty"},
+		DepartureDate:  time.Now(),
+	}
+	require.NoError(t, d.Validate())
+
+	sm := logistics.Shipment{
+		Code:             "SPN1",
+		CurrentStatusKey: logistics.Packed,
+		TransferPoint:    logistics.TransferPoint{Slug: "almaty"},
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Weight: decimal.NewFromInt(60), Length: 100, Width: 100, Height: 100},
+			{Sequence: 2, Weight: decimal.NewFromInt(35), Length: 100, Width: 100, Height: 100},
+		},
+	}
+	require.NoError(t, d.Assign(sm, time.Now()))
+	assert.Equal(t, logistics.ErrDispatchShipmentAssigned, d.Assign(sm, time.Now()))
+
+	load := d.Load()
+	assert.Equal(t, 2, load.UnitLoads)
+	require.Len(t, load.Warnings, 1)
+	assert.Equal(t, "capacity_weight", load.Warnings[0].Rule)
+
+	other := sm
+	other.Code = "SPN2"
+	other.CurrentStatusKey = logistics.Preparation
+	assert.Equal(t, logistics.ErrDispatchShipmentNotReady, d.Assign(other, time.Now()))
+	other.CurrentStatusKey = logistics.Packed
+	other.TransferPoint = logistics.TransferPoint{Slug: "bishkek"}
+	assert.Equal(t, logistics.ErrTransferPointMismatch, d.Assign(other, time.Now()))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, logistics.WriteDispatchManifestCSV(buf, d))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "SPN1,,2,95,2,,,", lines[1])
+
+	require.NoError(t, d.Unassign("spn1"))
+	assert.Equal(t, logistics.ErrDispatchShipmentNotAssigned, d.Unassign("SPN1"))
+}
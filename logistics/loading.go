@@ -0,0 +1,295 @@
+package logistics
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidUnitLoadQR    = errors.New("loading: invalid unit load qr code")
+	ErrLoadingSessionClosed = errors.New("loading: session is closed")
+)
+
+// UnitLoadQR is the content of the unit load label's qr code,
+// e.g. spn00123-2/5
+type UnitLoadQR struct {
+	Code     string `json:"code"`
+	Sequence int    `json:"sequence"`
+	Total    int    `json:"total"`
+}
+
+// NewUnitLoadQR returns qr code of the shipment's unit load
+func NewUnitLoadQR(code string, sequence, total int) UnitLoadQR {
+	return UnitLoadQR{Code: strings.ToUpper(code), Sequence: sequence, Total: total}
+}
+
+func (q UnitLoadQR) String() string {
+	return fmt.Sprintf("%s-%d/%d", strings.ToLower(q.Code), q.Sequence, q.Total)
+}
+
+// ParseUnitLoadQR parses content of the unit load qr code.
+// Shipment code is returned in upper case
+func ParseUnitLoadQR(s string) (UnitLoadQR, error) {
+	s = strings.TrimSpace(s)
+	dash := strings.LastIndex(s, "-")
+	if dash < 1 {
+		return UnitLoadQR{}, ErrInvalidUnitLoadQR
+	}
+
+	parts := strings.Split(s[dash+1:], "/")
+	if len(parts) != 2 {
+		return UnitLoadQR{}, ErrInvalidUnitLoadQR
+	}
+	seq, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return UnitLoadQR{}, ErrInvalidUnitLoadQR
+	}
+	total, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return UnitLoadQR{}, ErrInvalidUnitLoadQR
+	}
+	if seq < 1 || total < 1 || seq > total {
+		return UnitLoadQR{}, ErrInvalidUnitLoadQR
+	}
+
+	return NewUnitLoadQR(s[:dash], seq, total), nil
+}
+
+type ScanResult string
+
+const (
+	ScanOK        ScanResult = "ok"
+	ScanDuplicate ScanResult = "duplicate"
+	// ScanUnknown is the scan of the unknown shipment or unit load
+	ScanUnknown ScanResult = "unknown"
+	// ScanInvalid is the scan of the code which is not unit load qr code
+	ScanInvalid ScanResult = "invalid"
+)
+
+type LoadingScan struct {
+	Raw       string     `json:"raw"`
+	Code      string     `json:"code,omitempty"`
+	Sequence  int        `json:"sequence,omitempty"`
+	ScannedAt time.Time  `json:"scanned_at"`
+	Result    ScanResult `json:"result"`
+	Message   string     `json:"message"`
+}
+
+// LoadingShipment is the shipment being loaded
+type LoadingShipment struct {
+	Code   string         `json:"code"`
+	Status ShipmentStatus `json:"status"`
+	// UnitLoads is count of the shipment's unit loads, zero
+	// if shipment was not found
+	UnitLoads int   `json:"unit_loads"`
+	Scanned   []int `json:"scanned"`
+}
+
+func (ls LoadingShipment) Found() bool {
+	return ls.UnitLoads > 0
+}
+
+func (ls LoadingShipment) Missing() []int {
+	scanned := make(map[int]bool)
+	for _, seq := range ls.Scanned {
+		scanned[seq] = true
+	}
+
+	missing := []int{}
+	for seq := 1; seq <= ls.UnitLoads; seq++ {
+		if !scanned[seq] {
+			missing = append(missing, seq)
+		}
+	}
+
+	return missing
+}
+
+func (ls LoadingShipment) Complete() bool {
+	return ls.Found() && len(ls.Missing()) == 0
+}
+
+// LoadingSession is the loading of one truck. Every unit load
+// put into the truck is scanned by its label's qr code
+type LoadingSession struct {
+	ID        string                      `json:"id"`
+	Truck     string                      `json:"truck"`
+	OpenedBy  string                      `json:"opened_by"`
+	OpenedAt  time.Time                   `json:"opened_at"`
+	ClosedAt  *time.Time                  `json:"closed_at,omitempty"`
+	Shipments map[string]*LoadingShipment `json:"shipments"`
+	Scans     []LoadingScan               `json:"scans"`
+}
+
+func NewLoadingSession(truck, openedBy string, now time.Time) LoadingSession {
+	return LoadingSession{
+		Truck:     strings.TrimSpace(truck),
+		OpenedBy:  openedBy,
+		OpenedAt:  now,
+		Shipments: make(map[string]*LoadingShipment),
+		Scans:     []LoadingScan{},
+	}
+}
+
+func (s *LoadingSession) IsClosed() bool {
+	return s.ClosedAt != nil
+}
+
+func (s *LoadingSession) HasShipment(code string) bool {
+	_, ok := s.Shipments[strings.ToUpper(code)]
+	return ok
+}
+
+// AddShipment adds shipment found by the scanned code. Zero value
+// shipment with the code is added if shipment was not found,
+// so it's not looked up again
+func (s *LoadingSession) AddShipment(code string, sm *Shipment) {
+	code = strings.ToUpper(code)
+	if _, ok := s.Shipments[code]; ok {
+		return
+	}
+
+	ls := &LoadingShipment{Code: code, Status: InvalidStatus, Scanned: []int{}}
+	if sm != nil {
+		ls.Status = sm.CurrentStatusKey
+		ls.UnitLoads = len(sm.UnitLoads)
+	}
+	s.Shipments[code] = ls
+}
+
+// Scan registers scan of the unit load qr code. Shipment should be
+// added to the session before the scan, see AddShipment
+func (s *LoadingSession) Scan(raw string, now time.Time) (LoadingScan, error) {
+	if s.IsClosed() {
+		return LoadingScan{}, ErrLoadingSessionClosed
+	}
+
+	scan := LoadingScan{Raw: raw, ScannedAt: now}
+	defer func() {
+		s.Scans = append(s.Scans, scan)
+	}()
+
+	qr, err := ParseUnitLoadQR(raw)
+	if err != nil {
+		scan.Result = ScanInvalid
+		scan.Message = fmt.Sprintf("%s 不是包装标签", raw)
+		return scan, nil
+	}
+	scan.Code = qr.Code
+	scan.Sequence = qr.Sequence
+
+	ls, ok := s.Shipments[qr.Code]
+	switch {
+	case !ok || !ls.Found():
+		scan.Result = ScanUnknown
+		scan.Message = fmt.Sprintf("没有找到票号 %s", qr.Code)
+	case qr.Sequence > ls.UnitLoads || qr.Total != ls.UnitLoads:
+		scan.Result = ScanUnknown
+		scan.Message = fmt.Sprintf("票号 %s 有 %d 包，标签 %d/%d 已过期", qr.Code, ls.UnitLoads, qr.Sequence, qr.Total)
+	default:
+		for _, seq := range ls.Scanned {
+			if seq == qr.Sequence {
+				scan.Result = ScanDuplicate
+				scan.Message = fmt.Sprintf("%s 第 %d 包已扫描", qr.Code, qr.Sequence)
+				return scan, nil
+			}
+		}
+		ls.Scanned = append(ls.Scanned, qr.Sequence)
+		sort.Ints(ls.Scanned)
+		scan.Result = ScanOK
+		scan.Message = fmt.Sprintf("%s 第 %d/%d 包", qr.Code, qr.Sequence, ls.UnitLoads)
+	}
+
+	return scan, nil
+}
+
+type LoadingShipmentProgress struct {
+	Code      string         `json:"code"`
+	Status    ShipmentStatus `json:"status"`
+	UnitLoads int            `json:"unit_loads"`
+	Scanned   []int          `json:"scanned"`
+	Missing   []int          `json:"missing"`
+	Complete  bool           `json:"complete"`
+}
+
+// LoadingReport shows what was loaded and what is still missing
+type LoadingReport struct {
+	SessionID string                    `json:"session_id"`
+	Truck     string                    `json:"truck"`
+	Closed    bool                      `json:"closed"`
+	Shipments []LoadingShipmentProgress `json:"shipments"`
+	Scanned   int                       `json:"scanned"`
+	Missing   int                       `json:"missing"`
+	// Duplicates, Unknown and Invalid are counts of the scans with such result
+	Duplicates int `json:"duplicates"`
+	Unknown    int `json:"unknown"`
+	Invalid    int `json:"invalid"`
+}
+
+func (s *LoadingSession) Report() LoadingReport {
+	r := LoadingReport{
+		SessionID: s.ID,
+		Truck:     s.Truck,
+		Closed:    s.IsClosed(),
+		Shipments: []LoadingShipmentProgress{},
+	}
+
+	for _, ls := range s.Shipments {
+		if !ls.Found() {
+			continue
+		}
+		p := LoadingShipmentProgress{
+			Code:      ls.Code,
+			Status:    ls.Status,
+			UnitLoads: ls.UnitLoads,
+			Scanned:   ls.Scanned,
+			Missing:   ls.Missing(),
+			Complete:  ls.Complete(),
+		}
+		r.Scanned += len(p.Scanned)
+		r.Missing += len(p.Missing)
+		r.Shipments = append(r.Shipments, p)
+	}
+	sort.Slice(r.Shipments, func(i, j int) bool {
+		return r.Shipments[i].Code < r.Shipments[j].Code
+	})
+
+	for _, scan := range s.Scans {
+		switch scan.Result {
+		case ScanDuplicate:
+			r.Duplicates++
+		case ScanUnknown:
+			r.Unknown++
+		case ScanInvalid:
+			r.Invalid++
+		}
+	}
+
+	return r
+}
+
+// CompleteShipments returns codes of shipments with all unit loads scanned
+func (s *LoadingSession) CompleteShipments() []string {
+	var codes []string
+	for code, ls := range s.Shipments {
+		if ls.Complete() {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+func (s *LoadingSession) Close(now time.Time) error {
+	if s.IsClosed() {
+		return ErrLoadingSessionClosed
+	}
+	s.ClosedAt = &now
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseUnitLoadQR(t *testing.T) {
+	qr, err := logistics.ParseUnitLoadQR(" spn00123-2/5 ")
+	require.NoError(t, err)
+	assert.Equal(t, logistics.UnitLoadQR{Code: "SPN00123", Sequence: 2, Total: 5}, qr)
+	assert.Equal(t, "spn00123-2/5", qr.String())
+
+	for _, s := range []string{"spn00123", "spn00123-6/5", "spn00123-0/5", "-1/2", "spn00123-a/5"} {
+		_, err = logistics.ParseUnitLoadQR(s)
+		assert.Equal(t, logistics.ErrInvalidUnitLoadQR, err, s)
+	}
+}
+
+func TestLoadingSession_Scan(t *testing.T) {
+	now := time.Now()
+	ls := logistics.NewLoadingSession("粤A12345", "packer", now)
+	ls.AddShipment("spn1", &logistics.Shipment{
+		Code:             "SPN1",
+		CurrentStatusKey: logistics.Packed,
+		UnitLoads:        []*logistics.UnitLoad{{Sequence: 1}, {Sequence: 2}},
+	})
+
+	results := map[string]logistics.ScanResult{
+		"spn1-1/2":  logistics.ScanOK,
+		"spn1-1/2 ": logistics.ScanDuplicate,
+		"spn1-1/3":  logistics.ScanUnknown,
+		"spn2-1/1":  logistics.ScanUnknown,
+		"abc":       logistics.ScanInvalid,
+	}
+	for _, raw := range []string{"spn1-1/2", "spn1-1/2 ", "spn1-1/3", "spn2-1/1", "abc"} {
+		scan, err := ls.Scan(raw, now)
+		require.NoError(t, err)
+		assert.Equal(t, results[raw], scan.Result, raw)
+	}
+
+	r := ls.Report()
+	require.Len(t, r.Shipments, 1)
+	assert.Equal(t, []int{2}, r.Shipments[0].Missing)
+	assert.Equal(t, 1, r.Duplicates)
+	assert.Equal(t, 2, r.Unknown)
+	assert.Equal(t, 1, r.Invalid)
+	assert.Empty(t, ls.CompleteShipments())
+
+	_, err := ls.Scan("spn1-2/2", now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SPN1"}, ls.CompleteShipments())
+
+	require.NoError(t, ls.Close(now))
+	_, err = ls.Scan("spn1-2/2", now)
+	assert.Equal(t, logistics.ErrLoadingSessionClosed, err)
+}
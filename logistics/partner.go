@@ -0,0 +1,203 @@
+package logistics
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestFormat is the format partner accepts shipment manifests in
+type ManifestFormat string
+
+const (
+	ManifestCSV  ManifestFormat = "csv"
+	ManifestXML  ManifestFormat = "xml"
+	ManifestJSON ManifestFormat = "json"
+)
+
+func (f ManifestFormat) IsValid() bool {
+	return f == ManifestCSV || f == ManifestXML || f == ManifestJSON
+}
+
+var (
+	ErrPartnerNotFound          = errors.New("partner: not found")
+	ErrPartnerExists            = errors.New("partner: partner with the same code exists")
+	ErrPartnerEmptyCode         = errors.New("partner: code is empty")
+	ErrPartnerEmptyName         = errors.New("partner: name is empty")
+	ErrPartnerNoDeliveryMethods = errors.New("partner: no delivery methods")
+	ErrUnknownDeliveryMethod    = errors.New("partner: unknown delivery method")
+	ErrUnknownManifestFormat    = errors.New("partner: unknown manifest format")
+	ErrInvalidPartnerEndpoint   = errors.New("partner: integration endpoint should be http or https url")
+)
+
+type PartnerContact struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+}
+
+// LabelRequirements describe labels partner needs on the cargo
+type LabelRequirements struct {
+	// PartnerInfoLabel is required on every shipment
+	PartnerInfoLabel bool `json:"partner_info_label"`
+	// Copies of the partner info label, 1 if not set
+	Copies int    `json:"copies"`
+	Notes  string `json:"notes"`
+}
+
+// PartnerIntegration is the partner's HTTP endpoint manifests of the
+// sent out shipments are pushed to. Requests are signed with the secret,
+// it's write only and never returned by the API
+type PartnerIntegration struct {
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint"`
+	Secret   string `json:"secret"`
+}
+
+// Partner delivers shipments from the transfer point to the recipient
+type Partner struct {
+	Code            string             `json:"code"`
+	Name            string             `json:"name"`
+	Contacts        []PartnerContact   `json:"contacts"`
+	DeliveryMethods []DeliveryMethod   `json:"delivery_methods"`
+	Labels          LabelRequirements  `json:"labels"`
+	ManifestFormat  ManifestFormat     `json:"manifest_format"`
+	Integration     PartnerIntegration `json:"integration"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+// Normalize trims code and name, empty manifest format is csv
+func (p *Partner) Normalize() {
+	p.Code = strings.TrimSpace(p.Code)
+	p.Name = strings.TrimSpace(p.Name)
+	if p.ManifestFormat == "" {
+		p.ManifestFormat = ManifestCSV
+	}
+	if p.Labels.Copies < 1 {
+		p.Labels.Copies = 1
+	}
+	if p.Contacts == nil {
+		p.Contacts = []PartnerContact{}
+	}
+}
+
+func (p Partner) Validate() error {
+	if p.Code == "" {
+		return ErrPartnerEmptyCode
+	}
+	if p.Name == "" {
+		return ErrPartnerEmptyName
+	}
+	if len(p.DeliveryMethods) == 0 {
+		return ErrPartnerNoDeliveryMethods
+	}
+	for _, dm := range p.DeliveryMethods {
+		if !dm.IsValid() {
+			return errors.WithMessagef(ErrUnknownDeliveryMethod, "%s", dm)
+		}
+	}
+	if !p.ManifestFormat.IsValid() {
+		return ErrUnknownManifestFormat
+	}
+	if p.Integration.Enabled {
+		u, err := url.Parse(p.Integration.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidPartnerEndpoint
+		}
+	}
+
+	return nil
+}
+
+// Supports checks if partner delivers with the delivery method
+func (p Partner) Supports(dm DeliveryMethod) bool {
+	for _, v := range p.DeliveryMethods {
+		if v == dm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PartnerRegistry keeps partners shipments are validated with.
+// It's safe for concurrent use
+type PartnerRegistry struct {
+	mu     sync.RWMutex
+	byCode map[string]Partner
+}
+
+func NewPartnerRegistry(ps []Partner) *PartnerRegistry {
+	r := &PartnerRegistry{}
+	r.Set(ps)
+
+	return r
+}
+
+// Set replaces registered partners
+func (r *PartnerRegistry) Set(ps []Partner) {
+	byCode := make(map[string]Partner, len(ps))
+	for _, p := range ps {
+		byCode[strings.ToUpper(strings.TrimSpace(p.Code))] = p
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byCode = byCode
+}
+
+// Len returns count of registered partners
+func (r *PartnerRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.byCode)
+}
+
+// ByCode finds registered partner, code is case insensitive
+func (r *PartnerRegistry) ByCode(code string) (Partner, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.byCode[strings.ToUpper(strings.TrimSpace(code))]
+
+	return p, ok
+}
+
+// ValidatePartner checks that partner of the shipment is registered and
+// supports shipment's delivery method. Check is skipped until registry
+// is filled
+func (r *PartnerRegistry) ValidatePartner(s Shipment) []ValidationIssue {
+	if s.PartnerInfo.Code == "" || r.Len() == 0 {
+		return nil
+	}
+
+	p, ok := r.ByCode(s.PartnerInfo.Code)
+	if !ok {
+		return []ValidationIssue{{
+			Rule:     "partner",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("合作方 %s 不在合作方列表中", s.PartnerInfo.Code),
+		}}
+	}
+	dm := s.PartnerInfo.DeliveryMethod
+	if dm != "" && !p.Supports(dm) {
+		return []ValidationIssue{{
+			Rule:     "partner",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("合作方 %s 不支持运输方式 %s", p.Code, dm),
+		}}
+	}
+
+	return nil
+}
+
+// ValidationRules returns DefaultValidationRules with the partner check
+func (r *PartnerRegistry) ValidationRules() []ValidationRule {
+	rules := append([]ValidationRule{}, DefaultValidationRules...)
+
+	return append(rules, r.ValidatePartner)
+}
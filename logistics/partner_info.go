@@ -30,3 +30,30 @@ const (
 	DMWater           DeliveryMethod = "water"
 	DMLandRoadCommon  DeliveryMethod = "land_road_common"
 )
+
+var deliveryMethods = []DeliveryMethod{
+	DMParcelExpress,
+	DMAirExpress,
+	DMAirEconomy,
+	DMLandRail,
+	DMLandRoadExpress,
+	DMLandRoadEconomy,
+	DMLandContainer,
+	DMWater,
+	DMLandRoadCommon,
+}
+
+// DeliveryMethods returns all known delivery methods
+func DeliveryMethods() []DeliveryMethod {
+	return append([]DeliveryMethod{}, deliveryMethods...)
+}
+
+func (dm DeliveryMethod) IsValid() bool {
+	for _, v := range deliveryMethods {
+		if v == dm {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPartner_Validate(t *testing.T) {
+	p := logistics.Partner{Code: " XX-PARTNER ", Name: "Partner"}
+	p.Normalize()
+	assert.Equal(t, "XX-PARTNER", p.Code)
+	assert.Equal(t, logistics.ManifestCSV, p.ManifestFormat)
+	assert.Equal(t, 1, p.Labels.Copies)
+	assert.Equal(t, logistics.ErrPartnerNoDeliveryMethods, p.Validate())
+
+	p.DeliveryMethods = []logistics.DeliveryMethod{logistics.DMLandRail, "teleport"}
+	assert.Equal(t, logistics.ErrUnknownDeliveryMethod, errors.Cause(p.Validate()))
+
+	p.DeliveryMethods = []logistics.DeliveryMethod{logistics.DMLandRail}
+	assert.NoError(t, p.Validate())
+	assert.True(t, p.Supports(logistics.DMLandRail))
+	assert.False(t, p.Supports(logistics.DMAirExpress))
+}
+
+func TestPartnerRegistry_ValidatePartner(t *testing.T) {
+	partners := logistics.NewPartnerRegistry(nil)
+
+	sm := logistics.Shipment{PartnerInfo: logistics.PartnerInfo{Code: "xx-partner", DeliveryMethod: logistics.DMAirExpress}}
+	// registry is empty
+	assert.Empty(t, partners.ValidatePartner(sm))
+
+	partners.Set([]logistics.Partner{
+		{Code: "XX-PARTNER", Name: "Partner", DeliveryMethods: []logistics.DeliveryMethod{logistics.DMLandRail}},
+	})
+	issues := partners.ValidatePartner(sm)
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, logistics.SeverityError, issues[0].Severity)
+	}
+
+	sm.PartnerInfo.DeliveryMethod = logistics.DMLandRail
+	assert.Empty(t, partners.ValidatePartner(sm))
+
+	sm.PartnerInfo.Code = "YY-OTHER"
+	assert.Len(t, partners.ValidatePartner(sm), 1)
+}
@@ -0,0 +1,73 @@
+package logistics
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/address"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// NormalizedRecipient is the recipient with phone number in E.164,
+// name in both scripts and the matched destination city. Warnings
+// describe data which may cause failed delivery at the transfer point
+type NormalizedRecipient struct {
+	NameLatin    string        `json:"name_latin"`
+	NameCyrillic string        `json:"name_cyrillic"`
+	Phone        string        `json:"phone"`
+	PhoneCountry string        `json:"phone_country"`
+	City         *address.City `json:"city"`
+	Warnings     []string      `json:"warnings"`
+}
+
+// Normalize checks recipient of the shipment sent to the transfer point.
+// Empty fields are not warned about, ValidateRecipient does it
+func (r Recipient) Normalize(tp TransferPoint) NormalizedRecipient {
+	nr := NormalizedRecipient{Warnings: []string{}}
+
+	name := strings.Join(strings.Fields(r.Name), " ")
+	if address.HasCyrillic(name) {
+		nr.NameCyrillic = name
+		nr.NameLatin = address.ToLatin(name)
+	} else {
+		nr.NameLatin = name
+		nr.NameCyrillic = address.ToCyrillic(name)
+	}
+
+	destination := strings.TrimSpace(r.Destination)
+	if destination != "" {
+		m, ok := address.MatchCity(destination)
+		switch {
+		case !ok:
+			nr.Warnings = append(nr.Warnings, fmt.Sprintf("没有找到目的地城市: %s", destination))
+		case m.Fuzzy:
+			nr.City = &m.City
+			nr.Warnings = append(nr.Warnings, fmt.Sprintf("目的地 %s 可能是 %s，请核对", destination, m.City.NameRu))
+		default:
+			nr.City = &m.City
+		}
+	}
+
+	phone := strings.TrimSpace(r.PhoneNumber)
+	if phone == "" {
+		return nr
+	}
+	country := tp.Country
+	if nr.City != nil {
+		country = nr.City.Country
+	}
+	p, err := address.NormalizePhone(phone, country)
+	switch errors.Cause(err) {
+	case nil:
+		nr.Phone = p.E164
+		nr.PhoneCountry = p.Country
+		if nr.City != nil && nr.City.Country != p.Country {
+			nr.Warnings = append(nr.Warnings, fmt.Sprintf("收货人电话 %s 与目的地 %s 不是同一个国家", p.E164, nr.City.NameRu))
+		}
+	case address.ErrUnsupportedPhoneCountry:
+		nr.Warnings = append(nr.Warnings, fmt.Sprintf("收货人电话 %s 不是俄罗斯、哈萨克斯坦、吉尔吉斯斯坦或乌兹别克斯坦的号码", phone))
+	default:
+		nr.Warnings = append(nr.Warnings, fmt.Sprintf("收货人电话 %s 格式有误", phone))
+	}
+
+	return nr
+}
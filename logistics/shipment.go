@@ -88,13 +88,13 @@ type FileMakerShipment struct {
 	PartnerCargoValue                float64                     `json:"Partners||ValuesOfCargo"`
 	NeedDeclare                      int                         `json:"need_declare"`
 	Notes                            []*crm.FileMakerNote        `json:"TO2d_Shipments||Notes"`
+	ConsolidationID                  string                      `json:"Id_consolidation"`
 }
 
-func (fs FileMakerShipment) ToShipment() Shipment {
-	transferPoint, ok := transferPointsMap[fs.TransferPointKey]
-	if !ok {
-		transferPoint = "unknown"
-	}
+// ToShipment converts FileMaker shipment, transfer point is
+// looked up in the registry by its FileMaker number
+func (fs FileMakerShipment) ToShipment(tps *TransferPointRegistry) Shipment {
+	transferPoint, _ := tps.ByKey(fs.TransferPointKey)
 	var unitLoads []*UnitLoad
 	for _, ful := range fs.UnitLoads {
 		ul := ful.ToUnitLoad()
@@ -109,7 +109,7 @@ func (fs FileMakerShipment) ToShipment() Shipment {
 
 	var consolidatedShipments []*Shipment
 	for _, fs := range fs.Consolidation {
-		s := fs.ToShipment()
+		s := fs.ToShipment(tps)
 		consolidatedShipments = append(consolidatedShipments, &s)
 	}
 
@@ -152,8 +152,9 @@ func (fs FileMakerShipment) ToShipment() Shipment {
 				Destination: fs.PartnerRecipientDestinationPoint,
 			},
 		},
-		NeedDeclare: fmutil.ConvertToBool(fs.NeedDeclare),
-		Notes:       notes,
+		NeedDeclare:     fmutil.ConvertToBool(fs.NeedDeclare),
+		Notes:           notes,
+		ConsolidationID: fs.ConsolidationID,
 	}
 }
 
@@ -165,7 +166,7 @@ type Shipment struct {
 	PackagesQty            int                `json:"packages_qty,omitempty"`
 	PiecesQty              int                `json:"pieces_qty,omitempty"`
 	CurrentStatusKey       ShipmentStatus     `json:"current_status,omitempty"`
-	TransferPoint          string             `json:"transfer_point,omitempty"`
+	TransferPoint          TransferPoint      `json:"transfer_point"`
 	TransportMethod        *TransportMethod   `json:"transport_method,omitempty"`
 	PackageMethod          string             `json:"package_method,omitempty"`
 	PackageMethodZh        string             `json:"package_method_zh,omitempty"`
@@ -182,22 +183,35 @@ type Shipment struct {
 	PartnerInfo            PartnerInfo        `json:"partner_info"`
 	NeedDeclare            bool               `json:"need_declare"`
 	Notes                  []*crm.Note        `json:"notes"`
+	// WeightCalculation is filled by the store with the current volumetric rules
+	WeightCalculation WeightCalculation `json:"weight_calculation"`
+	// ConsolidationID is id of the consolidation shipment
+	// this shipment is attached to
+	ConsolidationID string `json:"consolidation_id,omitempty"`
 }
 
 type AliasShipment Shipment
 type jsonShipment struct {
-	Weight  decimal.Decimal `json:"weight,omitempty"`
-	Cubage  decimal.Decimal `json:"cubage,omitempty"`
-	Density decimal.Decimal `json:"density,omitempty"`
+	Weight           decimal.Decimal `json:"weight,omitempty"`
+	Cubage           decimal.Decimal `json:"cubage,omitempty"`
+	Density          decimal.Decimal `json:"density,omitempty"`
+	ChargeableWeight decimal.Decimal `json:"chargeable_weight,omitempty"`
+	// Recipient is checked only for shipments with partner
+	Recipient *NormalizedRecipient `json:"normalized_recipient,omitempty"`
 	*AliasShipment
 }
 
 func (s Shipment) MarshalJSON() ([]byte, error) {
 	ps := &jsonShipment{
-		Weight:        s.Weight(),
-		Cubage:        s.Cubage(),
-		Density:       s.Density(),
-		AliasShipment: (*AliasShipment)(&s),
+		Weight:           s.Weight(),
+		Cubage:           s.Cubage(),
+		Density:          s.Density(),
+		ChargeableWeight: s.WeightCalculation.ChargeableWeight,
+		AliasShipment:    (*AliasShipment)(&s),
+	}
+	if s.PartnerInfo.Code != "" {
+		nr := s.PartnerInfo.Recipient.Normalize(s.TransferPoint)
+		ps.Recipient = &nr
 	}
 
 	return json.Marshal(ps)
@@ -208,20 +222,22 @@ func (s *Shipment) ToJSON() string {
 	return string(b)
 }
 
-// Weight returns shipments weight in kg
+// Weight returns shipments weight in kg including
+// weight of consolidated shipments
 func (s Shipment) Weight() decimal.Decimal {
 	var w decimal.Decimal
-	for _, e := range s.UnitLoads {
+	for _, e := range s.AllUnitLoads() {
 		w = w.Add(e.Weight)
 	}
 
 	return w.Round(2)
 }
 
-// Cubage returns shipment volume in m3
+// Cubage returns shipment volume in m3 including
+// volume of consolidated shipments
 func (s Shipment) Cubage() decimal.Decimal {
 	var c decimal.Decimal
-	for _, e := range s.UnitLoads {
+	for _, e := range s.AllUnitLoads() {
 		c = c.Add(e.Cubage())
 	}
 
@@ -247,9 +263,48 @@ func (i ShipmentStatus) NextValid() ShipmentStatus {
 	return i + 1
 }
 
-func (s *Shipment) ChangeStatus(sts ShipmentStatus) error {
+var (
+	ErrInvalidStatusTransition = errors.New("shipment.ChangeStatus: invalid shipment status")
+	ErrNoUnitLoads             = errors.New("shipment.ChangeStatus: shipment has no unit loads")
+	ErrUnitLoadWithoutWeight   = errors.New("shipment.ChangeStatus: unit load has no weight")
+	ErrIncompleteCustomsData   = errors.New("shipment.ChangeStatus: declared shipment has incomplete customs data")
+)
+
+// CanChangeStatus checks if shipment could be moved to the given status.
+// Only the next status is allowed and shipment can't be packed
+// or sent out without unit loads
+func (s Shipment) CanChangeStatus(sts ShipmentStatus) error {
 	if s.CurrentStatusKey.NextValid() != sts {
-		return errors.New("shipment.ChangeStatus: invalid shipment status")
+		return ErrInvalidStatusTransition
+	}
+
+	switch sts {
+	case Packed, SentOut:
+		unitLoads := s.AllUnitLoads()
+		if len(unitLoads) == 0 {
+			return ErrNoUnitLoads
+		}
+		for _, ul := range unitLoads {
+			if ul.Weight.LessThanOrEqual(decimal.Zero) {
+				return errors.WithMessagef(ErrUnitLoadWithoutWeight, "unit load %d", ul.Sequence)
+			}
+		}
+	}
+
+	if sts == SentOut {
+		report := ValidateShipment(s, ValidateCustoms)
+		if report.HasErrors() {
+			return errors.WithMessage(ErrIncompleteCustomsData, report.ErrorMessages())
+		}
+	}
+
+	return nil
+}
+
+func (s *Shipment) ChangeStatus(sts ShipmentStatus) error {
+	err := s.CanChangeStatus(sts)
+	if err != nil {
+		return err
 	}
 
 	s.CurrentStatusKey = sts
@@ -257,16 +312,125 @@ func (s *Shipment) ChangeStatus(sts ShipmentStatus) error {
 	return nil
 }
 
+var ErrUnitLoadNotFound = errors.New("shipment: unit load not found")
+
+// AddUnitLoad appends unit load to the end of the shipment's unit loads
 func (s *Shipment) AddUnitLoad(ul *UnitLoad) error {
 	if s.CurrentStatusKey != Preparation {
 		return errors.Errorf("shipment.AddUnitLoad: shipment should be on %s status", Preparation)
 	}
 
+	err := ul.Validate()
+	if err != nil {
+		return err
+	}
+
+	ul.Sequence = len(s.UnitLoads) + 1
+	ul.FMRecordID = 0
 	s.UnitLoads = append(s.UnitLoads, ul)
 
 	return nil
 }
 
+// UnitLoad returns unit load by its sequence number
+func (s *Shipment) UnitLoad(sequence int) (*UnitLoad, error) {
+	for _, ul := range s.UnitLoads {
+		if ul.Sequence == sequence {
+			return ul, nil
+		}
+	}
+
+	return nil, ErrUnitLoadNotFound
+}
+
+// EditUnitLoad replaces quantity, product name, weight and dimensions
+// of the unit load with the given sequence number
+func (s *Shipment) EditUnitLoad(sequence int, changes UnitLoad) error {
+	if s.CurrentStatusKey != Preparation {
+		return errors.Errorf("shipment.EditUnitLoad: shipment should be on %s status", Preparation)
+	}
+
+	ul, err := s.UnitLoad(sequence)
+	if err != nil {
+		return err
+	}
+
+	err = changes.Validate()
+	if err != nil {
+		return err
+	}
+
+	ul.Quantity = changes.Quantity
+	ul.ProductName = changes.ProductName
+	ul.Weight = changes.Weight
+	ul.Length = changes.Length
+	ul.Width = changes.Width
+	ul.Height = changes.Height
+	ul.Customs = changes.Customs
+
+	return nil
+}
+
+// RemoveUnitLoad removes unit load and renumbers the rest of them
+func (s *Shipment) RemoveUnitLoad(sequence int) (*UnitLoad, error) {
+	if s.CurrentStatusKey != Preparation {
+		return nil, errors.Errorf("shipment.RemoveUnitLoad: shipment should be on %s status", Preparation)
+	}
+
+	removed, err := s.UnitLoad(sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	var unitLoads []*UnitLoad
+	for _, ul := range s.UnitLoads {
+		if ul != removed {
+			unitLoads = append(unitLoads, ul)
+		}
+	}
+	s.UnitLoads = unitLoads
+	s.renumberUnitLoads()
+
+	return removed, nil
+}
+
+// ReorderUnitLoads sorts unit loads in the given order of
+// their current sequence numbers and renumbers them
+func (s *Shipment) ReorderUnitLoads(sequences []int) error {
+	if s.CurrentStatusKey != Preparation {
+		return errors.Errorf("shipment.ReorderUnitLoads: shipment should be on %s status", Preparation)
+	}
+
+	if len(sequences) != len(s.UnitLoads) {
+		return errors.New("shipment.ReorderUnitLoads: all unit loads should be listed")
+	}
+
+	var unitLoads []*UnitLoad
+	seen := make(map[int]bool)
+	for _, seq := range sequences {
+		if seen[seq] {
+			return errors.Errorf("shipment.ReorderUnitLoads: unit load %d is listed twice", seq)
+		}
+		seen[seq] = true
+
+		ul, err := s.UnitLoad(seq)
+		if err != nil {
+			return err
+		}
+		unitLoads = append(unitLoads, ul)
+	}
+	s.UnitLoads = unitLoads
+	s.renumberUnitLoads()
+
+	return nil
+}
+
+func (s *Shipment) renumberUnitLoads() {
+	for i, ul := range s.UnitLoads {
+		ul.Sequence = i + 1
+	}
+}
+
 func (s *Shipment) ResourceName() string {
 	return "shipment"
 }
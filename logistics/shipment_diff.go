@@ -0,0 +1,135 @@
+package logistics
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	ShipmentCreated = "created"
+	ShipmentUpdated = "updated"
+	ShipmentRemoved = "removed"
+)
+
+// FieldChange is the old and the new value of the changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ShipmentChange is the difference of the shipment between two snapshots
+type ShipmentChange struct {
+	Kind string `json:"kind"`
+	Code string `json:"code"`
+	// Shipment is the current state, for removed shipment
+	// it's nil if the current state is unknown
+	Shipment *Shipment `json:"shipment,omitempty"`
+	// Changes are keyed by json name of the field, set for updated shipments
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// ShipmentSnapshot is the list of shipments keyed by code
+type ShipmentSnapshot map[string]Shipment
+
+// NewShipmentSnapshot creates snapshot from the list of shipments
+func NewShipmentSnapshot(shipments []Shipment) ShipmentSnapshot {
+	s := make(ShipmentSnapshot, len(shipments))
+	for _, sm := range shipments {
+		s[sm.Code] = sm
+	}
+
+	return s
+}
+
+// Shipments returns shipments of the snapshot sorted by code
+func (s ShipmentSnapshot) Shipments() []Shipment {
+	list := make([]Shipment, 0, len(s))
+	for _, sm := range s {
+		list = append(list, sm)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+
+	return list
+}
+
+// Diff returns changes from the previous snapshot to the current one sorted
+// by code. Shipment is updated if its DateModified or any of the compared
+// fields is changed
+func (s ShipmentSnapshot) Diff(previous ShipmentSnapshot) []ShipmentChange {
+	var changes []ShipmentChange
+	for code, sm := range s {
+		sm := sm
+		old, ok := previous[code]
+		if !ok {
+			changes = append(changes, ShipmentChange{Kind: ShipmentCreated, Code: code, Shipment: &sm})
+			continue
+		}
+		fields := compareShipments(old, sm)
+		if len(fields) == 0 && old.DateModified.Equal(sm.DateModified) {
+			continue
+		}
+		changes = append(changes, ShipmentChange{Kind: ShipmentUpdated, Code: code, Shipment: &sm, Changes: fields})
+	}
+	for code := range previous {
+		if _, ok := s[code]; !ok {
+			changes = append(changes, ShipmentChange{Kind: ShipmentRemoved, Code: code})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Code < changes[j].Code
+	})
+
+	return changes
+}
+
+// Left returns changes of the shipment which is not in the current
+// snapshot any more, e.g. its status is out of the polled range.
+// Shipment is updated with the changed fields first, then removed.
+// Snapshot s is the previous one
+func (s ShipmentSnapshot) Left(sm Shipment) []ShipmentChange {
+	var changes []ShipmentChange
+	if old, ok := s[sm.Code]; ok {
+		if fields := compareShipments(old, sm); len(fields) > 0 {
+			changes = append(changes, ShipmentChange{Kind: ShipmentUpdated, Code: sm.Code, Shipment: &sm, Changes: fields})
+		}
+	}
+
+	return append(changes, ShipmentChange{Kind: ShipmentRemoved, Code: sm.Code, Shipment: &sm})
+}
+
+func compareShipments(old, sm Shipment) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	add := func(name string, from, to interface{}) {
+		if from != to {
+			changes[name] = FieldChange{From: from, To: to}
+		}
+	}
+
+	add("current_status", old.CurrentStatusKey.String(), sm.CurrentStatusKey.String())
+	add("customer_code", old.CustomerCode, sm.CustomerCode)
+	add("packages_qty", old.PackagesQty, sm.PackagesQty)
+	add("pieces_qty", old.PiecesQty, sm.PiecesQty)
+	add("unit_loads", len(old.UnitLoads), len(sm.UnitLoads))
+	add("transfer_point", old.TransferPoint.Slug, sm.TransferPoint.Slug)
+	add("package_method", old.PackageMethod, sm.PackageMethod)
+	add("consolidation_id", old.ConsolidationID, sm.ConsolidationID)
+	add("partner_code", old.PartnerInfo.Code, sm.PartnerInfo.Code)
+	if !old.DateModified.Equal(sm.DateModified) {
+		changes["date_modified"] = FieldChange{From: formatTime(old.DateModified), To: formatTime(sm.DateModified)}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return changes
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
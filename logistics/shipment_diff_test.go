@@ -0,0 +1,82 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestShipmentSnapshot_Diff(t *testing.T) {
+	modified := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	previous := logistics.NewShipmentSnapshot([]logistics.Shipment{
+		{Code: "SPN1", CurrentStatusKey: logistics.Planning, DateModified: modified},
+		{Code: "SPN2", CurrentStatusKey: logistics.Planning, DateModified: modified},
+		{Code: "SPN3", CurrentStatusKey: logistics.Planning, DateModified: modified},
+	})
+	current := logistics.NewShipmentSnapshot([]logistics.Shipment{
+		{Code: "SPN1", CurrentStatusKey: logistics.Planning, DateModified: modified},
+		{Code: "SPN2", CurrentStatusKey: logistics.Packed, DateModified: modified.Add(time.Minute), UnitLoads: []*logistics.UnitLoad{{}}},
+		{Code: "SPN4", CurrentStatusKey: logistics.Planning, DateModified: modified},
+	})
+
+	changes := current.Diff(previous)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, logistics.ShipmentUpdated, changes[0].Kind)
+	assert.Equal(t, "SPN2", changes[0].Code)
+	assert.Equal(t, logistics.FieldChange{From: logistics.Planning.String(), To: logistics.Packed.String()}, changes[0].Changes["current_status"])
+	assert.Equal(t, logistics.FieldChange{From: 0, To: 1}, changes[0].Changes["unit_loads"])
+	assert.Contains(t, changes[0].Changes, "date_modified")
+
+	assert.Equal(t, logistics.ShipmentRemoved, changes[1].Kind)
+	assert.Equal(t, "SPN3", changes[1].Code)
+	assert.Nil(t, changes[1].Shipment)
+
+	assert.Equal(t, logistics.ShipmentCreated, changes[2].Kind)
+	assert.Equal(t, "SPN4", changes[2].Shipment.Code)
+
+	left := previous.Left(logistics.Shipment{Code: "SPN3", CurrentStatusKey: logistics.Packed, DateModified: modified})
+	require.Len(t, left, 2)
+	assert.Equal(t, logistics.ShipmentUpdated, left[0].Kind)
+	assert.Equal(t, logistics.FieldChange{From: logistics.Planning.String(), To: logistics.Packed.String()}, left[0].Changes["current_status"])
+	assert.Equal(t, logistics.ShipmentRemoved, left[1].Kind)
+	assert.Equal(t, logistics.Packed, left[1].Shipment.CurrentStatusKey)
+
+	assert.Empty(t, current.Diff(current))
+	assert.Len(t, current.Diff(nil), 3)
+	assert.Equal(t, "SPN4", current.Shipments()[2].Code)
+}
+
+func TestShipmentSnapshot_Left(t *testing.T) {
+	modified := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	polled := logistics.Shipment{
+		Code:             "SPN1",
+		CurrentStatusKey: logistics.Packed,
+		CustomerCode:     "C001",
+		PackageMethod:    "pallet",
+		PartnerInfo:      logistics.PartnerInfo{Code: "XX-PARTNER"},
+		UnitLoads:        []*logistics.UnitLoad{{Sequence: 1}, {Sequence: 2}},
+		DateModified:     modified,
+	}
+	previous := logistics.NewShipmentSnapshot([]logistics.Shipment{polled})
+
+	// fetched shipment has the same fields as the polled one,
+	// only really changed ones are in the update
+	fetched := polled
+	fetched.CurrentStatusKey = logistics.SentOut
+	fetched.DateModified = modified.Add(time.Minute)
+	left := previous.Left(fetched)
+	require.Len(t, left, 2)
+	assert.Equal(t, logistics.ShipmentUpdated, left[0].Kind)
+	assert.Len(t, left[0].Changes, 2)
+	assert.Equal(t, logistics.FieldChange{From: logistics.Packed.String(), To: logistics.SentOut.String()}, left[0].Changes["current_status"])
+	assert.Contains(t, left[0].Changes, "date_modified")
+	assert.Equal(t, logistics.ShipmentRemoved, left[1].Kind)
+
+	// not modified shipment is only removed
+	left = previous.Left(polled)
+	require.Len(t, left, 1)
+	assert.Equal(t, logistics.ShipmentRemoved, left[0].Kind)
+}
@@ -0,0 +1,182 @@
+package logistics
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	fm "github.com/amanbolat/gofmcon"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActiveShipmentStatuses are used when no status filter is given
+const ActiveShipmentStatuses = "1...2"
+
+var shipmentFieldNamesMap = map[string]string{
+	"id":               "Id_shipment",
+	"code":             "code",
+	"customer_code":    "CustomerCode",
+	"current_status":   "ShipmentStatus_number",
+	"transfer_point":   "TransferPoint_number",
+	"transport_method": "TransportationMethod_number",
+	"packages_qty":     "PackageQuantity",
+	"date_created":     "Date_Created",
+	"date_modified":    "Date_Modified_Timestamp",
+	"partner_code":     "Partners||PartnerCode",
+}
+
+// shipmentFilterValues returns converters of api filter values into FileMaker
+// find values, transfer point slugs are looked up in the registry
+func shipmentFilterValues(tps *TransferPointRegistry) map[string]func(v string) (string, error) {
+	return map[string]func(v string) (string, error){
+		"id":            exactValue,
+		"code":          containsValue,
+		"customer_code": exactValue,
+		"partner_code":  exactValue,
+		"current_status": func(v string) (string, error) {
+			return statusFilterValue(v)
+		},
+		"transfer_point": func(v string) (string, error) {
+			tp, ok := tps.BySlug(strings.TrimSpace(v))
+			if !ok {
+				return "", errors.Errorf("unknown transfer point %s", v)
+			}
+			return strconv.Itoa(tp.Key), nil
+		},
+		"transport_method": func(v string) (string, error) {
+			tm, err := TransportMethodString(strings.TrimSpace(v))
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(int(tm)), nil
+		},
+	}
+}
+
+// shipmentDateFilters are filters of date ranges, value is
+// the api field name and whether the filter is the range start
+var shipmentDateFilters = map[string]struct {
+	field string
+	from  bool
+}{
+	"created_from":  {"date_created", true},
+	"created_to":    {"date_created", false},
+	"modified_from": {"date_modified", true},
+	"modified_to":   {"date_modified", false},
+}
+
+// MapShipmentFields maps api field names into FileMaker field names
+// and converts filter values into FileMaker find values.
+// Status filter accepts comma separated list of continuous statuses,
+// e.g. "packed,sent_out", dates are in 2006-01-02 format
+func MapShipmentFields(meta api.RequestMeta, tps *TransferPointRegistry) (api.RequestMeta, error) {
+	var newMeta api.RequestMeta
+	newMeta = meta
+	newMeta.SortFields = []api.SortField{}
+	newMeta.InternalFilter = make(map[string]string)
+
+	for _, field := range meta.SortFields {
+		n, ok := shipmentFieldNamesMap[field.Name]
+		if ok {
+			newMeta.SortFields = append(newMeta.SortFields, api.SortField{
+				Name:       n,
+				Descending: field.Descending,
+			})
+		}
+	}
+
+	filterValues := shipmentFilterValues(tps)
+	dateRanges := make(map[string][2]string)
+	for _, filter := range meta.Filters {
+		if strings.TrimSpace(filter.V) == "" {
+			continue
+		}
+
+		if df, ok := shipmentDateFilters[filter.K]; ok {
+			t, err := time.Parse("2006-01-02", strings.TrimSpace(filter.V))
+			if err != nil {
+				return newMeta, errors.Errorf("wrong date %s of %s filter", filter.V, filter.K)
+			}
+			r := dateRanges[df.field]
+			if df.from {
+				r[0] = t.Format(fm.DATE_FORMAT)
+			} else {
+				r[1] = t.Format(fm.DATE_FORMAT)
+			}
+			dateRanges[df.field] = r
+			continue
+		}
+
+		key, ok := shipmentFieldNamesMap[filter.K]
+		if !ok {
+			continue
+		}
+		convert, ok := filterValues[filter.K]
+		if !ok {
+			continue
+		}
+		v, err := convert(filter.V)
+		if err != nil {
+			return newMeta, errors.WithMessagef(err, "%s filter", filter.K)
+		}
+		newMeta.InternalFilter[key] = v
+	}
+
+	for field, r := range dateRanges {
+		switch {
+		case r[0] != "" && r[1] != "":
+			newMeta.InternalFilter[shipmentFieldNamesMap[field]] = fmt.Sprintf("%s...%s", r[0], r[1])
+		case r[0] != "":
+			newMeta.InternalFilter[shipmentFieldNamesMap[field]] = ">=" + r[0]
+		default:
+			newMeta.InternalFilter[shipmentFieldNamesMap[field]] = "<=" + r[1]
+		}
+	}
+
+	if _, ok := newMeta.InternalFilter[shipmentFieldNamesMap["current_status"]]; !ok {
+		newMeta.InternalFilter[shipmentFieldNamesMap["current_status"]] = ActiveShipmentStatuses
+	}
+
+	return newMeta, nil
+}
+
+func exactValue(v string) (string, error) {
+	return "==" + strings.TrimSpace(v), nil
+}
+
+func containsValue(v string) (string, error) {
+	return "*" + strings.TrimSpace(v) + "*", nil
+}
+
+// statusFilterValue converts list of statuses into FileMaker range,
+// only continuous statuses can be found at once
+func statusFilterValue(v string) (string, error) {
+	var min, max ShipmentStatus = InvalidStatus, -1
+	seen := make(map[ShipmentStatus]bool)
+	for _, s := range strings.Split(v, ",") {
+		sts, err := ShipmentStatusString(strings.TrimSpace(s))
+		if err != nil {
+			return "", err
+		}
+		seen[sts] = true
+		if sts < min {
+			min = sts
+		}
+		if sts > max {
+			max = sts
+		}
+	}
+
+	for sts := min; sts <= max; sts++ {
+		if !seen[sts] {
+			return "", errors.Errorf("statuses %s are not continuous", v)
+		}
+	}
+
+	if min == max {
+		return strconv.Itoa(int(min)), nil
+	}
+
+	return fmt.Sprintf("%d...%d", min, max), nil
+}
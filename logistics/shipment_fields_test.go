@@ -0,0 +1,66 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMapShipmentFields(t *testing.T) {
+	tps, err := logistics.NewTransferPointRegistry(logistics.DefaultTransferPoints)
+	require.NoError(t, err)
+
+	meta, err := logistics.MapShipmentFields(api.RequestMeta{
+		SortFields: []api.SortField{
+			{Name: "date_modified", Descending: true},
+			{Name: "unknown"},
+		},
+		Filters: []api.FilterField{
+			{K: "current_status", V: "sent_out"},
+			{K: "customer_code", V: "77-00123"},
+			{K: "transport_method", V: "train"},
+			{K: "transfer_point", V: "almaty"},
+			{K: "partner_code", V: "XX-PARTNER"},
+			{K: "modified_from", V: "2020-06-01"},
+			{K: "modified_to", V: "2020-06-30"},
+			{K: "unknown", V: "1"},
+		},
+	}, tps)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []api.SortField{{Name: "Date_Modified_Timestamp", Descending: true}}, meta.SortFields)
+	assert.Equal(t, map[string]string{
+		"ShipmentStatus_number":       "3",
+		"CustomerCode":                "==77-00123",
+		"TransportationMethod_number": "2",
+		"TransferPoint_number":        "8",
+		"Partners||PartnerCode":       "==XX-PARTNER",
+		"Date_Modified_Timestamp":     "06/01/2020...06/30/2020",
+	}, meta.InternalFilter)
+
+	meta, err = logistics.MapShipmentFields(api.RequestMeta{}, tps)
+	if assert.NoError(t, err) {
+		assert.Equal(t, logistics.ActiveShipmentStatuses, meta.InternalFilter["ShipmentStatus_number"])
+	}
+
+	meta, err = logistics.MapShipmentFields(api.RequestMeta{
+		Filters: []api.FilterField{{K: "current_status", V: "packed,preparation"}},
+	}, tps)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1...2", meta.InternalFilter["ShipmentStatus_number"])
+	}
+
+	_, err = logistics.MapShipmentFields(api.RequestMeta{
+		Filters: []api.FilterField{{K: "current_status", V: "preparation,sent_out"}},
+	}, tps)
+	assert.Error(t, err)
+
+	_, err = logistics.MapShipmentFields(api.RequestMeta{
+		Filters: []api.FilterField{{K: "transfer_point", V: "mars"}},
+	}, tps)
+	assert.Error(t, err)
+}
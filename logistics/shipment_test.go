@@ -0,0 +1,73 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShipment_ChangeStatus(t *testing.T) {
+	sm := logistics.Shipment{Code: "SPN00123", CurrentStatusKey: logistics.Preparation}
+
+	err := sm.ChangeStatus(logistics.SentOut)
+	assert.Equal(t, logistics.ErrInvalidStatusTransition, err)
+
+	err = sm.ChangeStatus(logistics.Packed)
+	assert.Equal(t, logistics.ErrNoUnitLoads, err)
+
+	sm.UnitLoads = []*logistics.UnitLoad{
+		{Sequence: 1, Quantity: 1, Weight: decimal.NewFromFloat(10.5)},
+		{Sequence: 2, Quantity: 1},
+	}
+	err = sm.ChangeStatus(logistics.Packed)
+	assert.Equal(t, logistics.ErrUnitLoadWithoutWeight, errors.Cause(err))
+
+	sm.UnitLoads[1].Weight = decimal.NewFromFloat(3)
+	assert.NoError(t, sm.ChangeStatus(logistics.Packed))
+	assert.Equal(t, logistics.Packed, sm.CurrentStatusKey)
+	assert.NoError(t, sm.ChangeStatus(logistics.SentOut))
+}
+
+func TestShipment_UnitLoads(t *testing.T) {
+	sm := logistics.Shipment{Code: "SPN00123", CurrentStatusKey: logistics.Preparation}
+
+	for i := 1; i <= 3; i++ {
+		err := sm.AddUnitLoad(&logistics.UnitLoad{
+			Quantity: i,
+			Weight:   decimal.NewFromInt(int64(i * 10)),
+			Length:   100,
+			Width:    100,
+			Height:   100,
+		})
+		assert.NoError(t, err)
+	}
+	assert.Error(t, sm.AddUnitLoad(&logistics.UnitLoad{Quantity: 0}))
+	assert.Equal(t, "60", sm.Weight().String())
+	assert.Equal(t, "3", sm.Cubage().String())
+	assert.Equal(t, "20", sm.Density().String())
+
+	err := sm.EditUnitLoad(2, logistics.UnitLoad{Quantity: 2, Weight: decimal.NewFromInt(50), Length: 50, Width: 100, Height: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, "90", sm.Weight().String())
+	assert.Equal(t, "2.5", sm.Cubage().String())
+
+	assert.NoError(t, sm.ReorderUnitLoads([]int{3, 1, 2}))
+	assert.Equal(t, 3, sm.UnitLoads[0].Quantity)
+	assert.Equal(t, 1, sm.UnitLoads[0].Sequence)
+	assert.Error(t, sm.ReorderUnitLoads([]int{1, 1, 2}))
+
+	removed, err := sm.RemoveUnitLoad(1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, removed.Quantity)
+	}
+	assert.Len(t, sm.UnitLoads, 2)
+	assert.Equal(t, 2, sm.UnitLoads[1].Sequence)
+
+	_, err = sm.RemoveUnitLoad(5)
+	assert.Equal(t, logistics.ErrUnitLoadNotFound, err)
+
+	sm.CurrentStatusKey = logistics.Packed
+	assert.Error(t, sm.EditUnitLoad(1, logistics.UnitLoad{Quantity: 1}))
+}
@@ -0,0 +1,77 @@
+package logistics
+
+import (
+	"time"
+)
+
+const (
+	StatusSourceAPI    = "api"
+	StatusSourcePoller = "poller"
+)
+
+// StatusChange is a record of shipment status history
+type StatusChange struct {
+	Code      string         `json:"code"`
+	From      ShipmentStatus `json:"from"`
+	To        ShipmentStatus `json:"to"`
+	ChangedAt time.Time      `json:"changed_at"`
+	Actor     string         `json:"actor"`
+	// Source is where change was detected: api or poller
+	Source string `json:"source"`
+}
+
+// StatusDwell is the period shipment stayed in the status
+type StatusDwell struct {
+	Status    ShipmentStatus `json:"status"`
+	EnteredAt time.Time      `json:"entered_at"`
+	LeftAt    *time.Time     `json:"left_at,omitempty"`
+	// Seconds in the status, for the current status till now
+	Seconds  int64  `json:"seconds"`
+	Duration string `json:"duration"`
+}
+
+type Timeline struct {
+	Code    string         `json:"code"`
+	Changes []StatusChange `json:"changes"`
+	Dwells  []StatusDwell  `json:"dwells"`
+	// TotalSeconds is the total time spent in every status
+	TotalSeconds map[string]int64 `json:"total_seconds"`
+}
+
+// BuildTimeline calculates how long shipment stayed in every status.
+// Changes should be sorted by time
+func BuildTimeline(code string, changes []StatusChange, now time.Time) Timeline {
+	tl := Timeline{
+		Code:         code,
+		Changes:      changes,
+		Dwells:       []StatusDwell{},
+		TotalSeconds: make(map[string]int64),
+	}
+	if tl.Changes == nil {
+		tl.Changes = []StatusChange{}
+	}
+
+	for i, c := range changes {
+		d := StatusDwell{
+			Status:    c.To,
+			EnteredAt: c.ChangedAt,
+		}
+		end := now
+		if i+1 < len(changes) {
+			leftAt := changes[i+1].ChangedAt
+			d.LeftAt = &leftAt
+			end = leftAt
+		}
+		dur := end.Sub(c.ChangedAt)
+		if dur < 0 {
+			dur = 0
+		}
+		d.Seconds = int64(dur / time.Second)
+		d.Duration = dur.Truncate(time.Minute).String()
+
+		tl.Dwells = append(tl.Dwells, d)
+		tl.TotalSeconds[c.To.String()] += d.Seconds
+	}
+
+	return tl
+}
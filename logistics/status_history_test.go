@@ -0,0 +1,30 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	start := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	changes := []logistics.StatusChange{
+		{Code: "SPN1", From: logistics.InvalidStatus, To: logistics.Planning, ChangedAt: start},
+		{Code: "SPN1", From: logistics.Planning, To: logistics.Packed, ChangedAt: start.Add(2 * time.Hour)},
+		{Code: "SPN1", From: logistics.Packed, To: logistics.Planning, ChangedAt: start.Add(3 * time.Hour)},
+		{Code: "SPN1", From: logistics.Planning, To: logistics.Packed, ChangedAt: start.Add(4 * time.Hour)},
+	}
+
+	tl := logistics.BuildTimeline("SPN1", changes, start.Add(5*time.Hour))
+
+	assert.Len(t, tl.Dwells, 4)
+	assert.Equal(t, int64(7200), tl.Dwells[0].Seconds)
+	assert.Nil(t, tl.Dwells[3].LeftAt)
+	assert.Equal(t, int64(3*3600), tl.TotalSeconds[logistics.Planning.String()])
+	assert.Equal(t, int64(2*3600), tl.TotalSeconds[logistics.Packed.String()])
+
+	empty := logistics.BuildTimeline("SPN2", nil, start)
+	assert.Empty(t, empty.Changes)
+	assert.Empty(t, empty.Dwells)
+}
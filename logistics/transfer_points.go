@@ -1,13 +1,179 @@
 package logistics
 
-var transferPointsMap = map[int]string{
-	0:   "moscow",
-	1:   "novosibirsk",
-	3:   "yekaterinburg",
-	4:   "ussuriysk",
-	5:   "vladivostok",
-	6:   "manchuria",
-	7:   "local",
-	8:   "almaty",
-	999: "unknown",
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	LangZh = "zh"
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+const UnknownTransferPointKey = 999
+
+// TransferPointsConfig sets where transfer points are loaded from. By default
+// DefaultTransferPoints are used, TRANSFER_POINTS_FILE replaces them with the
+// JSON list of transfer points and TRANSFER_POINTS_FROM_FILEMAKER loads them
+// from the database on start
+type TransferPointsConfig struct {
+	TransferPointsFile          string `split_words:"true"`
+	TransferPointsFromFilemaker bool   `split_words:"true"`
+}
+
+// TransferPoint is the place shipments are delivered to
+// before they are handed over to the partner
+type TransferPoint struct {
+	// Key is the number of transfer point in FileMaker
+	Key  int    `json:"key"`
+	Slug string `json:"slug"`
+	// Names are keyed by language: zh, ru, en
+	Names   map[string]string `json:"names"`
+	Country string            `json:"country"`
+	// CustomsCountry is the country goods are cleared in, Country is used
+	// if it's empty. Border crossings in China clear goods in Russia
+	CustomsCountry string `json:"customs_country"`
+	Address        string `json:"address"`
+	Contact        string `json:"contact"`
+	TransitDays    int    `json:"transit_days"`
+}
+
+// Name returns localized name of the transfer point,
+// slug is returned if there is no translation
+func (tp TransferPoint) Name(lang string) string {
+	if n := strings.TrimSpace(tp.Names[lang]); n != "" {
+		return n
+	}
+
+	return tp.Slug
+}
+
+func (tp TransferPoint) String() string {
+	return tp.Slug
+}
+
+func (tp TransferPoint) IsKnown() bool {
+	return tp.Key != UnknownTransferPointKey && tp.Slug != UnknownTransferPoint.Slug
+}
+
+var UnknownTransferPoint = TransferPoint{
+	Key:   UnknownTransferPointKey,
+	Slug:  "unknown",
+	Names: map[string]string{LangZh: "未知", LangRu: "Неизвестно", LangEn: "Unknown"},
+}
+
+var DefaultTransferPoints = []TransferPoint{
+	{Key: 0, Slug: "moscow", Country: "RU", TransitDays: 18,
+		Names: map[string]string{LangZh: "莫斯科", LangRu: "Москва", LangEn: "Moscow"}},
+	{Key: 1, Slug: "novosibirsk", Country: "RU", TransitDays: 14,
+		Names: map[string]string{LangZh: "新西伯利亚", LangRu: "Новосибирск", LangEn: "Novosibirsk"}},
+	{Key: 3, Slug: "yekaterinburg", Country: "RU", TransitDays: 16,
+		Names: map[string]string{LangZh: "叶卡捷琳堡", LangRu: "Екатеринбург", LangEn: "Yekaterinburg"}},
+	{Key: 4, Slug: "ussuriysk", Country: "RU", TransitDays: 7,
+		Names: map[string]string{LangZh: "乌苏里斯克", LangRu: "Уссурийск", LangEn: "Ussuriysk"}},
+	{Key: 5, Slug: "vladivostok", Country: "RU", TransitDays: 7,
+		Names: map[string]string{LangZh: "海参崴", LangRu: "Владивосток", LangEn: "Vladivostok"}},
+	{Key: 6, Slug: "manchuria", Country: "CN", CustomsCountry: "RU", TransitDays: 4,
+		Names: map[string]string{LangZh: "满洲里", LangRu: "Маньчжурия", LangEn: "Manzhouli"}},
+	{Key: 7, Slug: "local", Country: "CN", TransitDays: 0,
+		Names: map[string]string{LangZh: "本地", LangRu: "Местная доставка", LangEn: "Local"}},
+	{Key: 8, Slug: "almaty", Country: "KZ", TransitDays: 12,
+		Names: map[string]string{LangZh: "阿拉木图", LangRu: "Алматы", LangEn: "Almaty"}},
+}
+
+// ValidateTransferPoints checks that keys and slugs are unique
+func ValidateTransferPoints(tps []TransferPoint) error {
+	if len(tps) == 0 {
+		return errors.New("transfer points list is empty")
+	}
+
+	keys := make(map[int]bool)
+	slugs := make(map[string]bool)
+	for _, tp := range tps {
+		if strings.TrimSpace(tp.Slug) == "" {
+			return errors.Errorf("transfer point %d has no slug", tp.Key)
+		}
+		if keys[tp.Key] || slugs[tp.Slug] {
+			return errors.Errorf("transfer point %d %s is duplicated", tp.Key, tp.Slug)
+		}
+		keys[tp.Key] = true
+		slugs[tp.Slug] = true
+	}
+
+	return nil
+}
+
+// LoadTransferPointsFile reads JSON list of transfer points
+func LoadTransferPointsFile(path string) ([]TransferPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tps []TransferPoint
+	err = json.NewDecoder(f).Decode(&tps)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "transfer points file %s", path)
+	}
+
+	return tps, ValidateTransferPoints(tps)
+}
+
+// TransferPointRegistry keeps transfer points indexed by FileMaker number
+// and slug. It's not changed after creation, so it's safe for concurrent use
+type TransferPointRegistry struct {
+	list   []TransferPoint
+	byKey  map[int]TransferPoint
+	bySlug map[string]TransferPoint
+}
+
+// NewTransferPointRegistry validates transfer points and indexes them
+func NewTransferPointRegistry(tps []TransferPoint) (*TransferPointRegistry, error) {
+	err := ValidateTransferPoints(tps)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TransferPointRegistry{
+		list:   append([]TransferPoint{}, tps...),
+		byKey:  make(map[int]TransferPoint, len(tps)),
+		bySlug: make(map[string]TransferPoint, len(tps)),
+	}
+	sort.Slice(r.list, func(i, j int) bool {
+		return r.list[i].Key < r.list[j].Key
+	})
+	for _, tp := range r.list {
+		r.byKey[tp.Key] = tp
+		r.bySlug[tp.Slug] = tp
+	}
+
+	return r, nil
+}
+
+// List returns all transfer points sorted by key
+func (r *TransferPointRegistry) List() []TransferPoint {
+	return append([]TransferPoint{}, r.list...)
+}
+
+// ByKey returns transfer point by FileMaker number,
+// UnknownTransferPoint is returned if key is unknown
+func (r *TransferPointRegistry) ByKey(key int) (TransferPoint, bool) {
+	if tp, ok := r.byKey[key]; ok {
+		return tp, true
+	}
+
+	return UnknownTransferPoint, false
+}
+
+func (r *TransferPointRegistry) BySlug(slug string) (TransferPoint, bool) {
+	if tp, ok := r.bySlug[slug]; ok {
+		return tp, true
+	}
+
+	return UnknownTransferPoint, false
 }
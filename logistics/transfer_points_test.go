@@ -0,0 +1,36 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTransferPointRegistry(t *testing.T) {
+	tps, err := logistics.NewTransferPointRegistry(logistics.DefaultTransferPoints)
+	require.NoError(t, err)
+
+	tp, ok := tps.ByKey(8)
+	require.True(t, ok)
+	assert.Equal(t, "almaty", tp.Slug)
+	assert.Equal(t, "阿拉木图", tp.Name(logistics.LangZh))
+
+	tp, ok = tps.ByKey(42)
+	assert.False(t, ok)
+	assert.Equal(t, logistics.UnknownTransferPoint, tp)
+
+	_, err = logistics.NewTransferPointRegistry([]logistics.TransferPoint{{Key: 1, Slug: "a"}, {Key: 1, Slug: "b"}})
+	assert.Error(t, err)
+
+	tps, err = logistics.NewTransferPointRegistry([]logistics.TransferPoint{{Key: 42, Slug: "bishkek", Country: "KG"}})
+	require.NoError(t, err)
+	tp, ok = tps.ByKey(42)
+	require.True(t, ok)
+	assert.Equal(t, "bishkek", tp.Name(logistics.LangRu))
+	_, ok = tps.BySlug("almaty")
+	assert.False(t, ok)
+
+	sm := logistics.FileMakerShipment{TransferPointKey: 42}.ToShipment(tps)
+	assert.Equal(t, "KG", sm.TransferPoint.Country)
+}
@@ -4,29 +4,38 @@ package logistics
 
 import (
 	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 )
 
 type UnitLoad struct {
-	Sequence    int             `json:"sequence"`
-	Quantity    int             `json:"quantity"`
-	ProductName string          `json:"product_name"`
-	Weight      decimal.Decimal `json:"weight"` // kg
-	Length      int64           `json:"length"` // cm
-	Height      int64           `json:"height"` // cm
-	Width       int64           `json:"width"`  // cm
-	FMRecordID  int64           `json:"-"`
+	Sequence    int                `json:"sequence"`
+	Quantity    int                `json:"quantity"`
+	ProductName string             `json:"product_name"`
+	Weight      decimal.Decimal    `json:"weight"` // kg
+	Length      int64              `json:"length"` // cm
+	Height      int64              `json:"height"` // cm
+	Width       int64              `json:"width"`  // cm
+	Customs     customs.Attributes `json:"customs"`
+	FMRecordID  int64              `json:"-"`
 }
 
 type FileMakerUnitLoad struct {
-	Sequence    int     `json:"SequenceNumber"`
-	Quantity    int     `json:"Quantity"`
-	ProductName string  `json:"SD_ProductName"`
-	Weight      float64 `json:"SD_Weight"`
-	Length      int64   `json:"SD_Length"` // cm
-	Height      int64   `json:"SD_Height"` // cm
-	Width       int64   `json:"SD_Width"`  // cm
-	FMRecordID  int64   `json:"-"`
+	Sequence        int     `json:"SequenceNumber"`
+	Quantity        int     `json:"Quantity"`
+	ProductName     string  `json:"SD_ProductName"`
+	Weight          float64 `json:"SD_Weight"`
+	Length          int64   `json:"SD_Length"` // cm
+	Height          int64   `json:"SD_Height"` // cm
+	Width           int64   `json:"SD_Width"`  // cm
+	HSCode          string  `json:"SD_HSCode"`
+	Material        string  `json:"SD_Material"`
+	Brand           string  `json:"SD_Brand"`
+	CountryOfOrigin string  `json:"SD_CountryOfOrigin"`
+	UnitValue       float64 `json:"SD_UnitValue"`
+	NetWeight       float64 `json:"SD_NetWeight"`
+	FMRecordID      int64   `json:"-"`
 }
 
 func (fu FileMakerUnitLoad) ToUnitLoad() UnitLoad {
@@ -38,7 +47,15 @@ func (fu FileMakerUnitLoad) ToUnitLoad() UnitLoad {
 		Length:      fu.Length,
 		Height:      fu.Height,
 		Width:       fu.Width,
-		FMRecordID:  fu.FMRecordID,
+		Customs: customs.Attributes{
+			HSCode:          fu.HSCode,
+			Material:        fu.Material,
+			Brand:           fu.Brand,
+			CountryOfOrigin: fu.CountryOfOrigin,
+			UnitValue:       decimal.NewFromFloat(fu.UnitValue),
+			NetWeight:       decimal.NewFromFloat(fu.NetWeight),
+		},
+		FMRecordID: fu.FMRecordID,
 	}
 }
 
@@ -67,3 +84,27 @@ func (ul *UnitLoad) Cubage() decimal.Decimal {
 
 	return decimal.New(cmCubage, 0).DivRound(decimal.New(1000000, 0), 2)
 }
+
+// Validate checks that unit load has positive quantity, no negative
+// weight or dimensions and customs attributes are well formed
+func (ul *UnitLoad) Validate() error {
+	if ul.Quantity < 1 {
+		return errors.New("unitLoad.Validate: quantity should be positive")
+	}
+	if ul.Weight.IsNegative() {
+		return errors.New("unitLoad.Validate: weight should not be negative")
+	}
+	if ul.Length < 0 || ul.Width < 0 || ul.Height < 0 {
+		return errors.New("unitLoad.Validate: dimensions should not be negative")
+	}
+	ul.Customs.Normalize()
+	err := ul.Customs.Validate()
+	if err != nil {
+		return err
+	}
+	if ul.Customs.NetWeight.GreaterThan(ul.Weight) && ul.Weight.IsPositive() {
+		return errors.New("unitLoad.Validate: net weight should not be greater than weight")
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package logistics
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Density of the usual cargo in kg/m3, shipments out of
+// this range are most likely measured wrong
+var (
+	MinNormalDensity = decimal.NewFromInt(30)
+	MaxNormalDensity = decimal.NewFromInt(1500)
+)
+
+type ValidationIssue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// UnitLoad is sequence of the unit load issue relates to
+	UnitLoad int `json:"unit_load,omitempty"`
+	// EntryID is id of the entry issue relates to
+	EntryID string `json:"entry_id,omitempty"`
+}
+
+type ValidationReport struct {
+	Code     string            `json:"code"`
+	Valid    bool              `json:"valid"`
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Issues   []ValidationIssue `json:"issues"`
+}
+
+func (r ValidationReport) HasErrors() bool {
+	return r.Errors > 0
+}
+
+// ErrorMessages returns messages of all the errors
+func (r ValidationReport) ErrorMessages() string {
+	var msgs []string
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			msgs = append(msgs, i.Message)
+		}
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ValidationRule checks one aspect of the shipment
+type ValidationRule func(s Shipment) []ValidationIssue
+
+// DefaultValidationRules are checked before the shipment is dispatched,
+// partner is checked with PartnerRegistry.ValidationRules. Customs are
+// not checked here, so partner info label can be printed before customs
+// data is filled, see ValidateCustoms
+var DefaultValidationRules = []ValidationRule{
+	ValidateUnitLoadDimensions,
+	ValidateUnitLoadWeights,
+	ValidateDensity,
+	ValidatePackagesQty,
+	ValidateEntryBoxes,
+	ValidateRecipient,
+	ValidateEntriesLinked,
+}
+
+// ValidateShipment checks shipment with the given rules
+// or with DefaultValidationRules if no rules are given
+func ValidateShipment(s Shipment, rules ...ValidationRule) ValidationReport {
+	if len(rules) == 0 {
+		rules = DefaultValidationRules
+	}
+
+	report := ValidationReport{
+		Code:   s.Code,
+		Issues: []ValidationIssue{},
+	}
+	for _, rule := range rules {
+		for _, issue := range rule(s) {
+			switch issue.Severity {
+			case SeverityError:
+				report.Errors++
+			case SeverityWarning:
+				report.Warnings++
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	report.Valid = !report.HasErrors()
+
+	return report
+}
+
+func ValidateUnitLoadDimensions(s Shipment) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, ul := range s.AllUnitLoads() {
+		if ul.Length <= 0 || ul.Width <= 0 || ul.Height <= 0 {
+			issues = append(issues, ValidationIssue{
+				Rule:     "unit_load_dimensions",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("第 %d 包没有尺寸", ul.Sequence),
+				UnitLoad: ul.Sequence,
+			})
+		}
+	}
+
+	return issues
+}
+
+func ValidateUnitLoadWeights(s Shipment) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, ul := range s.AllUnitLoads() {
+		if ul.Weight.LessThanOrEqual(decimal.Zero) {
+			issues = append(issues, ValidationIssue{
+				Rule:     "unit_load_weight",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("第 %d 包没有重量", ul.Sequence),
+				UnitLoad: ul.Sequence,
+			})
+		}
+	}
+
+	return issues
+}
+
+// ValidateDensity warns about shipment or unit loads with
+// density out of the normal range
+func ValidateDensity(s Shipment) []ValidationIssue {
+	var issues []ValidationIssue
+	outlier := func(d decimal.Decimal) bool {
+		return d.LessThan(MinNormalDensity) || d.GreaterThan(MaxNormalDensity)
+	}
+
+	unitLoads := s.AllUnitLoads()
+	for _, ul := range unitLoads {
+		cubage := ul.Cubage()
+		if cubage.IsZero() || ul.Weight.IsZero() {
+			continue
+		}
+		density := ul.Weight.Div(cubage).Round(2)
+		if outlier(density) {
+			issues = append(issues, ValidationIssue{
+				Rule:     "density",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("第 %d 包密度异常: %v kg/m3", ul.Sequence, density),
+				UnitLoad: ul.Sequence,
+			})
+		}
+	}
+
+	if len(unitLoads) > 0 && !s.Density().IsZero() && outlier(s.Density()) {
+		issues = append(issues, ValidationIssue{
+			Rule:     "density",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("整票密度异常: %v kg/m3", s.Density()),
+		})
+	}
+
+	return issues
+}
+
+// ValidatePackagesQty compares packages quantity with the count of unit loads,
+// consolidation is checked against unit loads of the consolidated shipments
+func ValidatePackagesQty(s Shipment) []ValidationIssue {
+	unitLoads := s.AllUnitLoads()
+	if len(unitLoads) == 0 {
+		return []ValidationIssue{{
+			Rule:     "packages_qty",
+			Severity: SeverityError,
+			Message:  "没有包装信息",
+		}}
+	}
+
+	if qty := s.AllPackagesQty(); qty != len(unitLoads) {
+		return []ValidationIssue{{
+			Rule:     "packages_qty",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("包数 %d 与录入的包装信息 %d 包不一致", qty, len(unitLoads)),
+		}}
+	}
+
+	return nil
+}
+
+// ValidateEntryBoxes compares count of boxes received
+// with the count of boxes packed into unit loads
+func ValidateEntryBoxes(s Shipment) []ValidationIssue {
+	if len(s.Entries) == 0 || len(s.UnitLoads) == 0 {
+		return nil
+	}
+
+	var entryBoxes, packedBoxes int
+	for _, e := range s.Entries {
+		entryBoxes += e.BoxQty
+	}
+	for _, ul := range s.UnitLoads {
+		packedBoxes += ul.Quantity
+	}
+
+	if entryBoxes != packedBoxes {
+		return []ValidationIssue{{
+			Rule:     "entry_boxes",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("入库箱数 %d 与包装件数 %d 不一致", entryBoxes, packedBoxes),
+		}}
+	}
+
+	return nil
+}
+
+func ValidateRecipient(s Shipment) []ValidationIssue {
+	if s.PartnerInfo.Code == "" {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	if strings.TrimSpace(s.PartnerInfo.Recipient.PhoneNumber) == "" {
+		issues = append(issues, ValidationIssue{
+			Rule:     "recipient",
+			Severity: SeverityError,
+			Message:  "没有收货人电话",
+		})
+	}
+	if strings.TrimSpace(s.PartnerInfo.Recipient.Name) == "" {
+		issues = append(issues, ValidationIssue{
+			Rule:     "recipient",
+			Severity: SeverityWarning,
+			Message:  "没有收货人姓名",
+		})
+	}
+	for _, w := range s.PartnerInfo.Recipient.Normalize(s.TransferPoint).Warnings {
+		issues = append(issues, ValidationIssue{
+			Rule:     "recipient",
+			Severity: SeverityWarning,
+			Message:  w,
+		})
+	}
+
+	return issues
+}
+
+// ValidateEntriesLinked checks that all the entries belong
+// to the shipment and were found in the warehouse
+func ValidateEntriesLinked(s Shipment) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, e := range s.Entries {
+		if !strings.EqualFold(e.ShipmentCode, s.Code) {
+			issues = append(issues, ValidationIssue{
+				Rule:     "entries_linked",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("入库 %s 没有关联此票号", e.ID),
+				EntryID:  e.ID,
+			})
+			continue
+		}
+		if !e.IsFoundForShipment {
+			issues = append(issues, ValidationIssue{
+				Rule:     "entries_linked",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("入库 %s 还没有找到", e.ID),
+				EntryID:  e.ID,
+			})
+		}
+	}
+
+	return issues
+}
+
+// ValidateCustoms checks that every unit load of the declared
+// shipment has complete customs attributes. It's checked on status
+// change and declaration export only
+func ValidateCustoms(s Shipment) []ValidationIssue {
+	if !s.NeedDeclare {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	for _, ul := range s.AllUnitLoads() {
+		missing := ul.Customs.Missing()
+		if len(missing) > 0 {
+			issues = append(issues, ValidationIssue{
+				Rule:     "customs",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("第 %d 包报关信息不完整: %s", ul.Sequence, strings.Join(missing, ", ")),
+				UnitLoad: ul.Sequence,
+			})
+		}
+	}
+	for _, e := range s.Entries {
+		if e.HasBrand && e.Customs.Brand == "" {
+			issues = append(issues, ValidationIssue{
+				Rule:     "customs",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("入库 %s 有品牌，但没有品牌名称", e.ID),
+				EntryID:  e.ID,
+			})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,114 @@
+package logistics_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateShipment(t *testing.T) {
+	sm := logistics.Shipment{
+		Code:        "SPN00123",
+		PackagesQty: 2,
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Quantity: 2, Weight: decimal.NewFromInt(100), Length: 100, Width: 50, Height: 50},
+			{Sequence: 2, Quantity: 1, Weight: decimal.NewFromInt(20), Length: 100, Width: 100, Height: 100},
+		},
+		Entries: []*warehouse.Entry{
+			{ID: "EN0001", ShipmentCode: "SPN00123", BoxQty: 2, IsFoundForShipment: true},
+			{ID: "EN0002", ShipmentCode: "SPN00123", BoxQty: 1, IsFoundForShipment: true},
+		},
+		PartnerInfo: logistics.PartnerInfo{
+			Code:      "XX-PARTNER",
+			Recipient: logistics.Recipient{Name: "Михаил", PhoneNumber: "+77001234567"},
+		},
+	}
+
+	report := logistics.ValidateShipment(sm)
+	assert.True(t, report.Valid)
+	// second unit load is 20 kg/m3
+	assert.Equal(t, 1, report.Warnings)
+
+	sm.PackagesQty = 3
+	sm.UnitLoads[0].Height = 0
+	sm.PartnerInfo.Recipient.PhoneNumber = ""
+	sm.Entries[1].ShipmentCode = ""
+	sm.Entries[0].BoxQty = 5
+
+	report = logistics.ValidateShipment(sm)
+	assert.False(t, report.Valid)
+	rules := make(map[string]logistics.Severity)
+	for _, i := range report.Issues {
+		rules[i.Rule] = i.Severity
+	}
+	assert.Equal(t, logistics.SeverityError, rules["unit_load_dimensions"])
+	assert.Equal(t, logistics.SeverityError, rules["packages_qty"])
+	assert.Equal(t, logistics.SeverityError, rules["recipient"])
+	assert.Equal(t, logistics.SeverityError, rules["entries_linked"])
+	assert.Equal(t, logistics.SeverityWarning, rules["entry_boxes"])
+	assert.Equal(t, 4, report.Errors)
+}
+
+func TestValidatePackagesQty_Consolidation(t *testing.T) {
+	cons := logistics.NewConsolidation(logistics.TransferPoint{Slug: "almaty"}, logistics.Auto, "GZWH2")
+	assert.Len(t, logistics.ValidatePackagesQty(cons), 1)
+
+	cons.Consolidation = []*logistics.Shipment{
+		{Code: "SPN00101", PackagesQty: 1, UnitLoads: []*logistics.UnitLoad{{Sequence: 1, Quantity: 1}}},
+		{Code: "SPN00102", PackagesQty: 2, UnitLoads: []*logistics.UnitLoad{{Sequence: 1, Quantity: 1}, {Sequence: 2, Quantity: 1}}},
+	}
+	assert.Empty(t, logistics.ValidatePackagesQty(cons))
+
+	cons.Consolidation[1].PackagesQty = 3
+	assert.Len(t, logistics.ValidatePackagesQty(cons), 1)
+}
+
+func TestValidateCustoms(t *testing.T) {
+	sm := logistics.Shipment{
+		Code:        "SPN00124",
+		NeedDeclare: true,
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Quantity: 1, Weight: decimal.NewFromInt(10)},
+		},
+	}
+
+	issues := logistics.ValidateCustoms(sm)
+	assert.Len(t, issues, 1)
+
+	sm.UnitLoads[0].Customs = customs.Attributes{
+		HSCode:          "6201930000",
+		Material:        "涤纶",
+		CountryOfOrigin: "CN",
+		UnitValue:       decimal.NewFromInt(5),
+		NetWeight:       decimal.NewFromInt(9),
+	}
+	assert.Empty(t, logistics.ValidateCustoms(sm))
+
+	sm.NeedDeclare = false
+	sm.UnitLoads[0].Customs = customs.Attributes{}
+	assert.Empty(t, logistics.ValidateCustoms(sm))
+}
+
+func TestRecipient_Normalize(t *testing.T) {
+	r := logistics.Recipient{Name: "Иванов Иван", PhoneNumber: "8 701 123 45 67", Destination: "Алматы"}
+	nr := r.Normalize(logistics.TransferPoint{Country: "KZ"})
+	assert.Equal(t, "Ivanov Ivan", nr.NameLatin)
+	assert.Equal(t, "+77011234567", nr.Phone)
+	assert.Equal(t, "KZ", nr.PhoneCountry)
+	assert.Empty(t, nr.Warnings)
+
+	r = logistics.Recipient{Name: "Ivanov", PhoneNumber: "8 912 345 67 89", Destination: "Алмааты"}
+	nr = r.Normalize(logistics.TransferPoint{Country: "KZ"})
+	assert.Equal(t, "Иванов", nr.NameCyrillic)
+	// fuzzy city and phone from Russia
+	assert.Len(t, nr.Warnings, 2)
+
+	r.PhoneNumber = "123"
+	r.Destination = "Париж"
+	nr = r.Normalize(logistics.TransferPoint{})
+	assert.Empty(t, nr.Phone)
+	assert.Len(t, nr.Warnings, 2)
+}
@@ -0,0 +1,112 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TariffCSVHeader is the header of the tariff import file. Every row is
+// a density bracket, rows with the same transfer point and delivery method
+// make one tariff. Min charge, insurance rate, minimal insurance and
+// currency are taken from the first row of the tariff
+var TariffCSVHeader = []string{
+	"transfer_point",
+	"delivery_method",
+	"min_density",
+	"price_per_kg",
+	"price_per_m3",
+	"min_charge",
+	"insurance_rate",
+	"min_insurance",
+	"currency",
+}
+
+// ParseTariffsCSV parses tariffs from the CSV file with TariffCSVHeader columns
+func ParseTariffsCSV(r io.Reader, tps *logistics.TransferPointRegistry) ([]Tariff, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	tariffs := make(map[string]*Tariff)
+	for i, rec := range records {
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if i == 0 && strings.TrimSpace(rec[0]) == TariffCSVHeader[0] {
+			continue
+		}
+		if len(rec) < 5 {
+			return nil, errors.Errorf("line %d: at least %d columns expected", i+1, 5)
+		}
+
+		col := func(n int) string {
+			if n >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[n])
+		}
+		num := func(n int) (decimal.Decimal, error) {
+			if col(n) == "" {
+				return decimal.Zero, nil
+			}
+			d, err := decimal.NewFromString(col(n))
+			if err != nil {
+				return decimal.Zero, errors.Errorf("line %d: wrong %s %q", i+1, TariffCSVHeader[n], col(n))
+			}
+			return d, nil
+		}
+
+		var values [9]decimal.Decimal
+		for n := 2; n <= 7; n++ {
+			values[n], err = num(n)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		dm := logistics.DeliveryMethod(col(1))
+		key := TariffKey(col(0), dm)
+		t, ok := tariffs[key]
+		if !ok {
+			t = &Tariff{
+				TransferPoint:  col(0),
+				DeliveryMethod: dm,
+				Currency:       col(8),
+				MinCharge:      values[5],
+				InsuranceRate:  values[6],
+				MinInsurance:   values[7],
+			}
+			tariffs[key] = t
+		}
+		t.Brackets = append(t.Brackets, DensityBracket{
+			MinDensity: values[2],
+			PricePerKg: values[3],
+			PricePerM3: values[4],
+		})
+	}
+
+	var res []Tariff
+	for _, t := range tariffs {
+		err = t.Validate(tps)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *t)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key() < res[j].Key()
+	})
+
+	return res, nil
+}
@@ -0,0 +1,213 @@
+// Package pricing calculates cost of the shipment delivery
+// by the tariffs of transfer points
+package pricing
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"sort"
+)
+
+const DefaultCurrency = "USD"
+
+var (
+	ErrTariffNotFound = errors.New("pricing: tariff not found")
+	ErrNoBracket      = errors.New("pricing: no density bracket for the cargo")
+	ErrEmptyCargo     = errors.New("pricing: cargo weight and cubage should be positive")
+)
+
+// DensityBracket is the price of the cargo with density
+// greater or equal to MinDensity. Light cargo is usually
+// priced per m3, dense cargo per kg
+type DensityBracket struct {
+	// MinDensity in kg/m3
+	MinDensity decimal.Decimal `json:"min_density"`
+	PricePerKg decimal.Decimal `json:"price_per_kg"`
+	PricePerM3 decimal.Decimal `json:"price_per_m3"`
+}
+
+// Tariff is the price list of delivery to the transfer point
+type Tariff struct {
+	TransferPoint  string                   `json:"transfer_point"`
+	DeliveryMethod logistics.DeliveryMethod `json:"delivery_method"`
+	Currency       string                   `json:"currency"`
+	Brackets       []DensityBracket         `json:"brackets"`
+	MinCharge      decimal.Decimal          `json:"min_charge"`
+	// InsuranceRate is percentage of cargo value
+	InsuranceRate decimal.Decimal `json:"insurance_rate"`
+	MinInsurance  decimal.Decimal `json:"min_insurance"`
+}
+
+// Key is unique key of the tariff
+func (t Tariff) Key() string {
+	return TariffKey(t.TransferPoint, t.DeliveryMethod)
+}
+
+func TariffKey(transferPoint string, dm logistics.DeliveryMethod) string {
+	return fmt.Sprintf("%s/%s", transferPoint, dm)
+}
+
+// Validate checks tariff and sorts its brackets, transfer point
+// should be known to the registry
+func (t *Tariff) Validate(tps *logistics.TransferPointRegistry) error {
+	if _, ok := tps.BySlug(t.TransferPoint); !ok {
+		return errors.Errorf("unknown transfer point %s", t.TransferPoint)
+	}
+	if !t.DeliveryMethod.IsValid() {
+		return errors.Errorf("unknown delivery method %s", t.DeliveryMethod)
+	}
+	if len(t.Brackets) == 0 {
+		return errors.Errorf("tariff %s has no density brackets", t.Key())
+	}
+	for _, b := range t.Brackets {
+		if b.MinDensity.IsNegative() || b.PricePerKg.IsNegative() || b.PricePerM3.IsNegative() {
+			return errors.Errorf("tariff %s has negative density or price", t.Key())
+		}
+		if b.PricePerKg.IsZero() && b.PricePerM3.IsZero() {
+			return errors.Errorf("tariff %s has bracket without price", t.Key())
+		}
+	}
+	if t.MinCharge.IsNegative() || t.InsuranceRate.IsNegative() || t.MinInsurance.IsNegative() {
+		return errors.Errorf("tariff %s has negative charges", t.Key())
+	}
+	if t.Currency == "" {
+		t.Currency = DefaultCurrency
+	}
+
+	sort.SliceStable(t.Brackets, func(i, j int) bool {
+		return t.Brackets[i].MinDensity.GreaterThan(t.Brackets[j].MinDensity)
+	})
+
+	return nil
+}
+
+// Bracket returns bracket with the highest min density
+// which is not greater than the given density
+func (t Tariff) Bracket(density decimal.Decimal) (DensityBracket, error) {
+	for _, b := range t.Brackets {
+		if density.GreaterThanOrEqual(b.MinDensity) {
+			return b, nil
+		}
+	}
+
+	return DensityBracket{}, ErrNoBracket
+}
+
+// Cargo is the subject of the quote
+type Cargo struct {
+	TransferPoint  string                   `json:"transfer_point"`
+	DeliveryMethod logistics.DeliveryMethod `json:"delivery_method"`
+	// Weight in kg
+	Weight decimal.Decimal `json:"weight"`
+	// Cubage in m3
+	Cubage decimal.Decimal `json:"cubage"`
+	// ChargeableWeight in kg is the greater of actual and volumetric weights,
+	// see VolumetricRules. Actual weight is used if it's not set
+	ChargeableWeight decimal.Decimal `json:"chargeable_weight"`
+	CargoValue       decimal.Decimal `json:"cargo_value"`
+}
+
+// CargoFromShipment takes cargo figures from the shipment,
+// chargeable weight is calculated with the rules
+func CargoFromShipment(s logistics.Shipment, rules VolumetricRules) Cargo {
+	return Cargo{
+		TransferPoint:    s.TransferPoint.Slug,
+		DeliveryMethod:   s.PartnerInfo.DeliveryMethod,
+		Weight:           s.Weight(),
+		Cubage:           s.Cubage(),
+		ChargeableWeight: rules.CalculateWeight(s).ChargeableWeight,
+		CargoValue:       decimal.NewFromFloat(s.PartnerInfo.CargoValue),
+	}
+}
+
+func (c Cargo) Density() decimal.Decimal {
+	if c.Cubage.LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero
+	}
+
+	return c.Weight.Div(c.Cubage).Round(2)
+}
+
+type LineItem struct {
+	Code        string          `json:"code"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Unit        string          `json:"unit"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+type Quote struct {
+	Cargo
+	Density  decimal.Decimal `json:"density"`
+	Currency string          `json:"currency"`
+	Lines    []LineItem      `json:"lines"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// Quote calculates cost of the cargo delivery
+func (t Tariff) Quote(c Cargo) (Quote, error) {
+	if c.Weight.LessThanOrEqual(decimal.Zero) || c.Cubage.LessThanOrEqual(decimal.Zero) {
+		return Quote{}, ErrEmptyCargo
+	}
+
+	q := Quote{
+		Cargo:    c,
+		Density:  c.Density(),
+		Currency: t.Currency,
+		Lines:    []LineItem{},
+	}
+
+	b, err := t.Bracket(q.Density)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	freight := LineItem{
+		Code:        "freight",
+		Description: fmt.Sprintf("运费 (密度 ≥ %v kg/m3)", b.MinDensity),
+	}
+	if b.PricePerM3.GreaterThan(decimal.Zero) {
+		freight.Quantity = c.Cubage
+		freight.Unit = "m3"
+		freight.UnitPrice = b.PricePerM3
+	} else {
+		freight.Quantity = decimal.Max(c.Weight, c.ChargeableWeight)
+		freight.Unit = "kg"
+		freight.UnitPrice = b.PricePerKg
+	}
+	freight.Amount = freight.Quantity.Mul(freight.UnitPrice).Round(2)
+	q.Lines = append(q.Lines, freight)
+
+	if freight.Amount.LessThan(t.MinCharge) {
+		q.Lines = append(q.Lines, LineItem{
+			Code:        "min_charge",
+			Description: fmt.Sprintf("最低收费 %v %s", t.MinCharge, t.Currency),
+			Quantity:    decimal.New(1, 0),
+			UnitPrice:   t.MinCharge.Sub(freight.Amount),
+			Amount:      t.MinCharge.Sub(freight.Amount),
+		})
+	}
+
+	if c.CargoValue.GreaterThan(decimal.Zero) && t.InsuranceRate.GreaterThan(decimal.Zero) {
+		insurance := c.CargoValue.Mul(t.InsuranceRate).Div(decimal.New(100, 0)).Round(2)
+		if insurance.LessThan(t.MinInsurance) {
+			insurance = t.MinInsurance
+		}
+		q.Lines = append(q.Lines, LineItem{
+			Code:        "insurance",
+			Description: fmt.Sprintf("保险 %v%% × %v %s", t.InsuranceRate, c.CargoValue, t.Currency),
+			Quantity:    decimal.New(1, 0),
+			UnitPrice:   insurance,
+			Amount:      insurance,
+		})
+	}
+
+	for _, l := range q.Lines {
+		q.Total = q.Total.Add(l.Amount)
+	}
+
+	return q, nil
+}
@@ -0,0 +1,92 @@
+package pricing_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const tariffsCSV = `transfer_point,delivery_method,min_density,price_per_kg,price_per_m3,min_charge,insurance_rate,min_insurance,currency
+almaty,land_road_express,0,,250,100,1,5,USD
+almaty,land_road_express,200,1.3,,,,,
+almaty,land_road_express,400,1.1,,,,,
+moscow,land_rail,0,,180,80,,,
+`
+
+func transferPoints(t *testing.T) *logistics.TransferPointRegistry {
+	tps, err := logistics.NewTransferPointRegistry(logistics.DefaultTransferPoints)
+	require.NoError(t, err)
+
+	return tps
+}
+
+func TestParseTariffsCSV(t *testing.T) {
+	tariffs, err := pricing.ParseTariffsCSV(strings.NewReader(tariffsCSV), transferPoints(t))
+	if !assert.NoError(t, err) || !assert.Len(t, tariffs, 2) {
+		return
+	}
+
+	almaty := tariffs[0]
+	assert.Equal(t, "almaty/land_road_express", almaty.Key())
+	assert.Len(t, almaty.Brackets, 3)
+	// brackets are sorted by density descending
+	assert.Equal(t, "400", almaty.Brackets[0].MinDensity.String())
+	assert.Equal(t, "USD", tariffs[1].Currency)
+
+	_, err = pricing.ParseTariffsCSV(strings.NewReader("nowhere,land_rail,0,1,,,,,"), transferPoints(t))
+	assert.Error(t, err)
+}
+
+func TestTariff_Quote(t *testing.T) {
+	tariffs, err := pricing.ParseTariffsCSV(strings.NewReader(tariffsCSV), transferPoints(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	almaty := tariffs[0]
+
+	// 320 kg / 1.8 m3 ≈ 177.78 kg/m3 is priced per m3
+	q, err := almaty.Quote(pricing.Cargo{
+		TransferPoint:  "almaty",
+		DeliveryMethod: logistics.DMLandRoadExpress,
+		Weight:         decimal.NewFromInt(320),
+		Cubage:         decimal.NewFromFloat(1.8),
+		CargoValue:     decimal.NewFromInt(2000),
+	})
+	if assert.NoError(t, err) && assert.Len(t, q.Lines, 2) {
+		assert.Equal(t, "m3", q.Lines[0].Unit)
+		assert.Equal(t, "450", q.Lines[0].Amount.String())
+		assert.Equal(t, "insurance", q.Lines[1].Code)
+		assert.Equal(t, "20", q.Lines[1].Amount.String())
+		assert.Equal(t, "470", q.Total.String())
+	}
+
+	// dense and small cargo hits min charge
+	q, err = almaty.Quote(pricing.Cargo{
+		Weight: decimal.NewFromInt(50),
+		Cubage: decimal.NewFromFloat(0.1),
+	})
+	if assert.NoError(t, err) && assert.Len(t, q.Lines, 2) {
+		assert.Equal(t, "kg", q.Lines[0].Unit)
+		assert.Equal(t, "55", q.Lines[0].Amount.String())
+		assert.Equal(t, "min_charge", q.Lines[1].Code)
+		assert.Equal(t, "100", q.Total.String())
+	}
+
+	// light cargo priced per kg is billed by chargeable weight
+	q, err = almaty.Quote(pricing.Cargo{
+		Weight:           decimal.NewFromInt(100),
+		Cubage:           decimal.NewFromFloat(0.4),
+		ChargeableWeight: decimal.NewFromInt(120),
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "120", q.Lines[0].Quantity.String())
+		assert.Equal(t, "156", q.Lines[0].Amount.String())
+	}
+
+	_, err = almaty.Quote(pricing.Cargo{Weight: decimal.NewFromInt(1)})
+	assert.Equal(t, pricing.ErrEmptyCargo, err)
+}
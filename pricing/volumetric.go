@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// VolumetricConfig is used to override default volumetric rules.
+// Divisors are in cm3/kg and keyed by transport method (air, auto...)
+// or partner delivery method (air_express, land_rail...),
+// e.g. VOLUMETRIC_DIVISORS=air:6000,land_rail:3000
+type VolumetricConfig struct {
+	VolumetricDivisors map[string]int64 `split_words:"true"`
+	// ChargeableWeightStep is the step chargeable weight is rounded up to, in kg
+	ChargeableWeightStep float64 `split_words:"true" default:"0.5"`
+}
+
+// VolumetricRules defines how partners calculate volumetric weight.
+// Divisor of the delivery method has priority over the transport method's one.
+// Zero divisor means that volumetric weight is not used
+type VolumetricRules struct {
+	ByTransportMethod map[logistics.TransportMethod]int64
+	ByDeliveryMethod  map[logistics.DeliveryMethod]int64
+	RoundingStep      decimal.Decimal
+}
+
+var DefaultVolumetricRules = VolumetricRules{
+	ByTransportMethod: map[logistics.TransportMethod]int64{
+		logistics.Air:     6000,
+		logistics.Express: 5000,
+		logistics.Auto:    3000,
+		logistics.Train:   3000,
+		logistics.Sea:     1000,
+		logistics.Local:   0,
+	},
+	ByDeliveryMethod: map[logistics.DeliveryMethod]int64{
+		logistics.DMParcelExpress: 5000,
+		logistics.DMAirExpress:    5000,
+		logistics.DMAirEconomy:    6000,
+		logistics.DMLandContainer: 1000,
+		logistics.DMWater:         1000,
+	},
+	RoundingStep: decimal.NewFromFloat(0.5),
+}
+
+// NewVolumetricRules applies config on top of the default rules
+func NewVolumetricRules(config VolumetricConfig) (VolumetricRules, error) {
+	rules := VolumetricRules{
+		ByTransportMethod: make(map[logistics.TransportMethod]int64),
+		ByDeliveryMethod:  make(map[logistics.DeliveryMethod]int64),
+		RoundingStep:      DefaultVolumetricRules.RoundingStep,
+	}
+	for k, v := range DefaultVolumetricRules.ByTransportMethod {
+		rules.ByTransportMethod[k] = v
+	}
+	for k, v := range DefaultVolumetricRules.ByDeliveryMethod {
+		rules.ByDeliveryMethod[k] = v
+	}
+
+	for k, v := range config.VolumetricDivisors {
+		if v < 0 {
+			return VolumetricRules{}, errors.Errorf("volumetric divisor of %s should not be negative", k)
+		}
+		if tm, err := logistics.TransportMethodString(k); err == nil {
+			rules.ByTransportMethod[tm] = v
+			continue
+		}
+		if logistics.DeliveryMethod(k).IsValid() {
+			rules.ByDeliveryMethod[logistics.DeliveryMethod(k)] = v
+			continue
+		}
+
+		return VolumetricRules{}, errors.Errorf("unknown transport or delivery method %s", k)
+	}
+
+	if config.ChargeableWeightStep < 0 {
+		return VolumetricRules{}, errors.New("chargeable weight step should not be negative")
+	}
+	if config.ChargeableWeightStep > 0 {
+		rules.RoundingStep = decimal.NewFromFloat(config.ChargeableWeightStep)
+	}
+
+	return rules, nil
+}
+
+// Divisor returns volumetric divisor in cm3/kg
+func (r VolumetricRules) Divisor(tm *logistics.TransportMethod, dm logistics.DeliveryMethod) int64 {
+	if d, ok := r.ByDeliveryMethod[dm]; ok {
+		return d
+	}
+	if tm != nil {
+		return r.ByTransportMethod[*tm]
+	}
+
+	return 0
+}
+
+// RoundUp rounds weight up to the rounding step
+func (r VolumetricRules) RoundUp(w decimal.Decimal) decimal.Decimal {
+	if r.RoundingStep.LessThanOrEqual(decimal.Zero) {
+		return w.Round(2)
+	}
+
+	return w.Div(r.RoundingStep).Ceil().Mul(r.RoundingStep)
+}
+
+// ChargeableWeight returns chargeable weight of the cargo. Its unit loads
+// are unknown, so volumetric weight is calculated of the total cubage with
+// the divisor of the delivery method
+func (r VolumetricRules) ChargeableWeight(c Cargo) decimal.Decimal {
+	w := c.Weight
+	if d := r.Divisor(nil, c.DeliveryMethod); d > 0 {
+		cm3 := c.Cubage.Mul(decimal.New(1000000, 0))
+		w = decimal.Max(w, cm3.DivRound(decimal.New(d, 0), 2))
+	}
+
+	return r.RoundUp(w)
+}
+
+// CalculateWeight calculates volumetric weight of every unit load
+// and chargeable weight of the shipment, which is the greater of
+// actual and volumetric weights rounded up
+func (r VolumetricRules) CalculateWeight(s logistics.Shipment) logistics.WeightCalculation {
+	calc := logistics.WeightCalculation{
+		Divisor:   r.Divisor(s.TransportMethod, s.PartnerInfo.DeliveryMethod),
+		Weight:    s.Weight(),
+		UnitLoads: []logistics.UnitLoadWeight{},
+	}
+
+	for _, ul := range s.AllUnitLoads() {
+		vw := ul.VolumetricWeight(calc.Divisor)
+		calc.VolumetricWeight = calc.VolumetricWeight.Add(vw)
+		calc.UnitLoads = append(calc.UnitLoads, logistics.UnitLoadWeight{
+			Sequence:         ul.Sequence,
+			Weight:           ul.Weight,
+			VolumetricWeight: vw,
+		})
+	}
+
+	calc.ChargeableWeight = r.RoundUp(decimal.Max(calc.Weight, calc.VolumetricWeight))
+
+	return calc
+}
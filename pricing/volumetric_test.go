@@ -0,0 +1,69 @@
+package pricing_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVolumetricRules_CalculateWeight(t *testing.T) {
+	rules, err := pricing.NewVolumetricRules(pricing.VolumetricConfig{
+		VolumetricDivisors:   map[string]int64{"air": 5000, "land_rail": 4000},
+		ChargeableWeightStep: 1,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sm := logistics.Shipment{
+		TransportMethod: logistics.TransportMethodP(int(logistics.Air)),
+		UnitLoads: []*logistics.UnitLoad{
+			{Sequence: 1, Quantity: 1, Weight: decimal.NewFromFloat(10.2), Length: 50, Width: 40, Height: 30},
+			{Sequence: 2, Quantity: 1, Weight: decimal.NewFromFloat(3), Length: 60, Width: 50, Height: 40},
+		},
+	}
+
+	calc := rules.CalculateWeight(sm)
+	assert.Equal(t, int64(5000), calc.Divisor)
+	assert.Equal(t, "12", calc.UnitLoads[0].VolumetricWeight.String())
+	assert.Equal(t, "24", calc.UnitLoads[1].VolumetricWeight.String())
+	assert.Equal(t, "36", calc.VolumetricWeight.String())
+	assert.Equal(t, "36", calc.ChargeableWeight.String())
+
+	// divisor of delivery method has priority
+	sm.PartnerInfo.DeliveryMethod = logistics.DMLandRail
+	calc = rules.CalculateWeight(sm)
+	assert.Equal(t, int64(4000), calc.Divisor)
+	assert.Equal(t, "45", calc.VolumetricWeight.String())
+
+	// dense cargo is billed by actual weight
+	sm.UnitLoads[0].Weight = decimal.NewFromFloat(100.2)
+	calc = rules.CalculateWeight(sm)
+	assert.Equal(t, "104", calc.ChargeableWeight.String())
+
+	_, err = pricing.NewVolumetricRules(pricing.VolumetricConfig{
+		VolumetricDivisors: map[string]int64{"teleport": 1},
+	})
+	assert.Error(t, err)
+}
+
+func TestVolumetricRules_ChargeableWeight(t *testing.T) {
+	cargo := pricing.Cargo{
+		DeliveryMethod: logistics.DMAirExpress,
+		Weight:         decimal.NewFromInt(100),
+		Cubage:         decimal.NewFromFloat(1.2),
+	}
+
+	// 1.2 m3 / 5000 cm3/kg = 240 kg
+	assert.Equal(t, "240", pricing.DefaultVolumetricRules.ChargeableWeight(cargo).String())
+
+	cargo.Weight = decimal.NewFromFloat(300.2)
+	assert.Equal(t, "300.5", pricing.DefaultVolumetricRules.ChargeableWeight(cargo).String())
+
+	// delivery method without divisor is billed by actual weight
+	cargo.DeliveryMethod = logistics.DMLandRail
+	cargo.Weight = decimal.NewFromInt(100)
+	assert.Equal(t, "100", pricing.DefaultVolumetricRules.ChargeableWeight(cargo).String())
+}
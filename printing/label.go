@@ -222,7 +222,7 @@ func (lm LabelManager) CreateUnitLoadLabels(shipment logistics.Shipment) (*Label
 			}
 		}
 
-		br, err := qr.Encode(fmt.Sprintf("%s-%d/%d", strings.ToLower(shipment.Code), ul.Sequence, ulCount), qr.H, qr.Auto)
+		br, err := qr.Encode(logistics.NewUnitLoadQR(shipment.Code, ul.Sequence, ulCount).String(), qr.H, qr.Auto)
 		if err != nil {
 			return nil, errors.WithMessage(err, "could not encode qr code")
 		}
@@ -430,11 +430,14 @@ func (lm LabelManager) CreateShipmentPartnerInfoLabel(shipment logistics.Shipmen
 		pdf.SetY(pdf.GetY() + 16)
 	}
 
+	weightCalc := shipment.WeightCalculation
 	cargoInfo := []string{
 		fmt.Sprintf("总重量: %v kg", shipment.Weight()),
 		fmt.Sprintf("总体积: %v m3", shipment.Cubage()),
 		fmt.Sprintf("密度: %v kg/m3", shipment.Density()),
 		fmt.Sprintf("箱数: %d 箱", len(shipment.UnitLoads)),
+		fmt.Sprintf("体积重: %v kg", weightCalc.VolumetricWeight),
+		fmt.Sprintf("计费重: %v kg", weightCalc.ChargeableWeight),
 	}
 
 	pdf.SetY(basicInfoStartY)
@@ -449,10 +452,27 @@ func (lm LabelManager) CreateShipmentPartnerInfoLabel(shipment logistics.Shipmen
 	}
 
 	// Recipient
+	recipient := shipment.PartnerInfo.Recipient
+	nr := recipient.Normalize(shipment.TransferPoint)
+	name := recipient.Name
+	if nr.NameLatin != "" && nr.NameLatin != recipient.Name {
+		name = fmt.Sprintf("%s (%s)", recipient.Name, nr.NameLatin)
+	}
+	phone := recipient.PhoneNumber
+	if nr.Phone != "" {
+		phone = nr.Phone
+	}
+	destination := recipient.Destination
+	if nr.City != nil {
+		destination = fmt.Sprintf("%s [%s %s]", recipient.Destination, nr.City.NameRu, nr.City.NameZh)
+	}
 	recipientInfo := []string{
-		fmt.Sprintf("收货人：%s", shipment.PartnerInfo.Recipient.Name),
-		fmt.Sprintf("电话：%s", shipment.PartnerInfo.Recipient.PhoneNumber),
-		fmt.Sprintf("目的地：%s", shipment.PartnerInfo.Recipient.Destination),
+		fmt.Sprintf("收货人：%s", name),
+		fmt.Sprintf("电话：%s", phone),
+		fmt.Sprintf("目的地：%s", destination),
+	}
+	for _, w := range nr.Warnings {
+		recipientInfo = append(recipientInfo, safeSplitText(pdf, fmt.Sprintf("注意：%s", w), PAPER_W-10)...)
 	}
 
 	pdf.SetY(pdf.GetY() + 30)
@@ -561,6 +581,144 @@ func (lm LabelManager) CreateShipmentPartnerInfoLabel(shipment logistics.Shipmen
 	return res, nil
 }
 
+// CreateConsolidationLabel creates master label of the consolidation
+// with the list of all consolidated shipments
+func (lm LabelManager) CreateConsolidationLabel(shipment logistics.Shipment) (Label, error) {
+	if !shipment.IsConsolidation() {
+		return Label{}, api.NewError(nil, fmt.Sprintf("%s 不是集运票号", shipment.Code), "")
+	}
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: gopdf.Rect{
+		W: PAPER_W,
+		H: PAPER_H,
+	}})
+	pdf.AddPage()
+	err := pdf.AddTTFFont("noto-cjk", lm.fontPath)
+	if err != nil {
+		return Label{}, err
+	}
+
+	// SPN00123
+	err = pdf.SetFont("noto-cjk", "", 36)
+	if err != nil {
+		return Label{}, err
+	}
+	pdf.SetY(40)
+	err = writeCenteredText(pdf, strings.ToUpper(shipment.Code))
+	if err != nil {
+		return Label{}, err
+	}
+
+	err = pdf.SetFont("noto-cjk", "", 14)
+	if err != nil {
+		return Label{}, err
+	}
+	pdf.SetY(pdf.GetY() + 30)
+	err = writeCenteredText(pdf, "КОНСОЛИДАЦИЯ/集运")
+	if err != nil {
+		return Label{}, err
+	}
+
+	// Basic info
+	err = pdf.SetFont("noto-cjk", "", 12)
+	if err != nil {
+		return Label{}, err
+	}
+	pdf.SetY(pdf.GetY() + 20)
+
+	unitLoads := shipment.AllUnitLoads()
+	basicInformation := []string{
+		fmt.Sprintf("转运点: %s", shipment.TransferPoint.Name(logistics.LangZh)),
+		fmt.Sprintf("运输方式: %s", shipment.TransportMethod),
+		fmt.Sprintf("票数: %d", len(shipment.Consolidation)),
+		fmt.Sprintf("箱数: %d 箱", len(unitLoads)),
+		fmt.Sprintf("ВЕС/总重量: %v kg", shipment.Weight()),
+		fmt.Sprintf("ОБЪЕМ/总体积: %v m3", shipment.Cubage()),
+	}
+
+	for _, l := range basicInformation {
+		pdf.SetX(5)
+		err = safeCell(pdf, l)
+		if err != nil {
+			return Label{}, err
+		}
+		pdf.SetY(pdf.GetY() + 16)
+	}
+
+	// Line
+	pdf.SetY(pdf.GetY() + 5)
+	pdf.RectFromUpperLeft(5, pdf.GetY(), PAPER_W-10, 1)
+	pdf.SetY(pdf.GetY() + 10)
+
+	// Consolidated shipments
+	for i, child := range shipment.Consolidation {
+		pdf.SetX(5)
+		err = safeCell(pdf, fmt.Sprintf("%d. %s", i+1, strings.ToUpper(child.Code)))
+		if err != nil {
+			return Label{}, err
+		}
+
+		pdf.SetX(120)
+		err = safeCell(pdf, fmt.Sprintf("%d箱", len(child.AllUnitLoads())))
+		if err != nil {
+			return Label{}, err
+		}
+
+		pdf.SetX(170)
+		err = safeCell(pdf, fmt.Sprintf("%v kg %v m3", child.Weight(), child.Cubage()))
+		if err != nil {
+			return Label{}, err
+		}
+
+		safeSetY(pdf, pdf.GetY()+16, 15)
+	}
+
+	br, err := qr.Encode(strings.ToLower(shipment.Code), qr.H, qr.Auto)
+	if err != nil {
+		return Label{}, errors.WithMessage(err, "could not encode qr code")
+	}
+
+	bc, err := barcode.Scale(br, 100, 100)
+	if err != nil {
+		return Label{}, errors.WithMessage(err, "could not scale barcode")
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	err = jpeg.Encode(buf, bc, &jpeg.Options{Quality: 100})
+	if err != nil {
+		return Label{}, errors.WithStack(err)
+	}
+
+	img, err := gopdf.ImageHolderByReader(buf)
+	if err != nil {
+		return Label{}, err
+	}
+	err = pdf.ImageByHolder(img, PAPER_W-70, PAPER_H-70, nil)
+	if err != nil {
+		return Label{}, errors.WithStack(err)
+	}
+
+	tmpFilePath := path.Join(os.TempDir(), fmt.Sprintf("%s-ConsolidationLabel.pdf", xid.New()))
+	file, err := os.Create(tmpFilePath)
+	if err != nil {
+		return Label{}, errors.WithMessage(err, "could not create tmp file")
+	}
+	defer file.Close()
+
+	err = pdf.WritePdf(tmpFilePath)
+	if err != nil {
+		return Label{}, err
+	}
+
+	res := Label{
+		File:     file,
+		FullPath: tmpFilePath,
+	}
+
+	return res, nil
+}
+
 func safeSetY(pdf *gopdf.GoPdf, y float64, newPageY float64) {
 	if pdf.GetY()+40 > PAPER_H {
 		pdf.AddPage()
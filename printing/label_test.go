@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+	"time"
 )
 
 var fontPath = os.Getenv("FONT_PATH")
@@ -143,3 +144,39 @@ func TestLabelManager_CreateShipmentPartnerInfoLabel(t *testing.T) {
 		exec.Command("open", partnerInfoLabel.FullPath).Run()
 	}
 }
+
+func TestLabelManager_CreateConsolidationLabel(t *testing.T) {
+	lm, err := NewLabelManger(fontPath)
+	assert.NoError(t, err)
+
+	cons := logistics.NewConsolidation(logistics.TransferPoint{Slug: "almaty", Names: map[string]string{logistics.LangZh: "阿拉木图"}}, logistics.Auto, "GZWH2")
+	cons.Code = "SPN007000"
+	child := sp
+	cons.Consolidation = []*logistics.Shipment{&child, &child}
+
+	consolidationLabel, err := lm.CreateConsolidationLabel(cons)
+	if assert.NoError(t, err) {
+		exec.Command("open", consolidationLabel.FullPath).Run()
+	}
+}
+
+func TestLabelManager_CreateDispatchManifest(t *testing.T) {
+	lm, err := NewLabelManger(fontPath)
+	assert.NoError(t, err)
+
+	d := logistics.Dispatch{
+		ID:             "DSP000001",
+		Vehicle:        "粤A12345",
+		CapacityWeight: decimal.NewFromInt(20000),
+		CapacityCubage: decimal.NewFromInt(80),
+		TransferPoint:  logistics.TransferPoint{Key: 8, Slug: "almaty", Names: map[string]string{logistics.LangZh: "阿拉木图"}},
+	}
+	for i := 0; i < 60; i++ {
+		d.Shipments = append(d.Shipments, logistics.NewDispatchShipment(sp, time.Now()))
+	}
+
+	manifest, err := lm.CreateDispatchManifest(d)
+	if assert.NoError(t, err) {
+		exec.Command("open", manifest.FullPath).Run()
+	}
+}
@@ -0,0 +1,146 @@
+package printing
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/signintech/gopdf"
+	"os"
+	"path"
+	"strconv"
+)
+
+// A4 page size in points
+const A4_W, A4_H = 595, 842
+
+type manifestColumn struct {
+	title string
+	x     float64
+	value func(i int, ds logistics.DispatchShipment) string
+}
+
+var manifestColumns = []manifestColumn{
+	{"#", 20, func(i int, ds logistics.DispatchShipment) string { return strconv.Itoa(i + 1) }},
+	{"票号", 45, func(i int, ds logistics.DispatchShipment) string { return ds.Code }},
+	{"客户", 125, func(i int, ds logistics.DispatchShipment) string { return ds.CustomerCode }},
+	{"箱数", 190, func(i int, ds logistics.DispatchShipment) string { return strconv.Itoa(ds.UnitLoads) }},
+	{"重量 kg", 230, func(i int, ds logistics.DispatchShipment) string { return ds.Weight.String() }},
+	{"体积 m3", 295, func(i int, ds logistics.DispatchShipment) string { return ds.Cubage.String() }},
+	{"收货人", 355, func(i int, ds logistics.DispatchShipment) string {
+		return fmt.Sprintf("%s %s %s", ds.Recipient.Name, ds.Recipient.PhoneNumber, ds.Recipient.Destination)
+	}},
+}
+
+// CreateDispatchManifest creates A4 manifest with the list of all
+// shipments loaded to the dispatch
+func (lm LabelManager) CreateDispatchManifest(d logistics.Dispatch) (Label, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: gopdf.Rect{
+		W: A4_W,
+		H: A4_H,
+	}})
+	pdf.AddPage()
+	err := pdf.AddTTFFont("noto-cjk", lm.fontPath)
+	if err != nil {
+		return Label{}, err
+	}
+
+	err = pdf.SetFont("noto-cjk", "", 20)
+	if err != nil {
+		return Label{}, err
+	}
+	pdf.SetX(20)
+	pdf.SetY(30)
+	err = safeCell(pdf, fmt.Sprintf("装车清单 %s", d.ID))
+	if err != nil {
+		return Label{}, err
+	}
+
+	err = pdf.SetFont("noto-cjk", "", 11)
+	if err != nil {
+		return Label{}, err
+	}
+	load := d.Load()
+	basicInformation := []string{
+		fmt.Sprintf("车辆: %s", d.Vehicle),
+		fmt.Sprintf("转运点: %s", d.TransferPoint.Name(logistics.LangZh)),
+		fmt.Sprintf("出发日期: %s", d.DepartureDate.Format("2006-01-02")),
+		fmt.Sprintf("票数: %d  箱数: %d", len(d.Shipments), load.UnitLoads),
+		fmt.Sprintf("ВЕС/总重量: %v / %v kg", load.Weight, d.CapacityWeight),
+		fmt.Sprintf("ОБЪЕМ/总体积: %v / %v m3", load.Cubage, d.CapacityCubage),
+	}
+	pdf.SetY(pdf.GetY() + 30)
+	for _, l := range basicInformation {
+		pdf.SetX(20)
+		err = safeCell(pdf, l)
+		if err != nil {
+			return Label{}, err
+		}
+		pdf.SetY(pdf.GetY() + 16)
+	}
+
+	writeHeader := func() error {
+		pdf.SetY(pdf.GetY() + 10)
+		for _, col := range manifestColumns {
+			pdf.SetX(col.x)
+			err := safeCell(pdf, col.title)
+			if err != nil {
+				return err
+			}
+		}
+		pdf.SetY(pdf.GetY() + 16)
+		pdf.RectFromUpperLeft(20, pdf.GetY(), A4_W-40, 1)
+		pdf.SetY(pdf.GetY() + 6)
+		return nil
+	}
+
+	err = writeHeader()
+	if err != nil {
+		return Label{}, err
+	}
+
+	for i, ds := range d.Shipments {
+		if pdf.GetY()+40 > A4_H {
+			pdf.AddPage()
+			pdf.SetY(20)
+			err = writeHeader()
+			if err != nil {
+				return Label{}, err
+			}
+		}
+
+		for _, col := range manifestColumns {
+			pdf.SetX(col.x)
+			v := col.value(i, ds)
+			if col.x == manifestColumns[len(manifestColumns)-1].x {
+				lines := safeSplitText(pdf, v, A4_W-20-col.x)
+				if len(lines) > 0 {
+					v = lines[0]
+				}
+			}
+			err = safeCell(pdf, v)
+			if err != nil {
+				return Label{}, err
+			}
+		}
+		pdf.SetY(pdf.GetY() + 16)
+	}
+
+	tmpFilePath := path.Join(os.TempDir(), fmt.Sprintf("%s-DispatchManifest.pdf", xid.New()))
+	file, err := os.Create(tmpFilePath)
+	if err != nil {
+		return Label{}, errors.WithMessage(err, "could not create tmp file")
+	}
+	defer file.Close()
+
+	err = pdf.WritePdf(tmpFilePath)
+	if err != nil {
+		return Label{}, err
+	}
+
+	return Label{
+		File:     file,
+		FullPath: tmpFilePath,
+	}, nil
+}
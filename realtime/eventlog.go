@@ -0,0 +1,101 @@
+package realtime
+
+import (
+	"fmt"
+	"github.com/rs/xid"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEventLogSize is the number of the last events kept for
+// clients resuming the stream
+const DefaultEventLogSize = 1000
+
+// loggedEvent is the published event with all its topics
+type loggedEvent struct {
+	seq uint64
+	// id is <boot>-<seq>, see eventLog
+	id     string
+	event  string
+	data   []byte
+	topics []string
+	time   time.Time
+}
+
+// message returns event message for the topic
+func (e loggedEvent) message(topic string) Message {
+	return Message{Type: TypeEvent, Seq: e.seq, EventID: e.id, Topic: topic, Event: e.event, Data: e.data, Time: e.time}
+}
+
+// match returns the first of the event topics client is subscribed to
+func (e loggedEvent) match(topics map[string]bool) (string, bool) {
+	for _, t := range e.topics {
+		if topics[t] {
+			return t, true
+		}
+	}
+
+	return "", false
+}
+
+// eventLog is the ring buffer of the last events. It's not safe
+// for concurrent use, hub guards it with its mutex. Sequence starts
+// from zero on every start, so event ids are prefixed with boot id
+// and ids issued before restart are not mistaken for the new ones
+type eventLog struct {
+	boot   string
+	events []loggedEvent
+	// start is the index of the oldest event
+	start int
+	seq   uint64
+}
+
+func newEventLog(size int) *eventLog {
+	if size < 1 {
+		size = DefaultEventLogSize
+	}
+
+	return &eventLog{boot: xid.New().String(), events: make([]loggedEvent, 0, size)}
+}
+
+// append assigns next sequence number to the event and saves it,
+// the oldest event is dropped if log is full
+func (l *eventLog) append(e loggedEvent) loggedEvent {
+	l.seq++
+	e.seq = l.seq
+	e.id = fmt.Sprintf("%s-%d", l.boot, l.seq)
+	if len(l.events) < cap(l.events) {
+		l.events = append(l.events, e)
+	} else {
+		l.events[l.start] = e
+		l.start = (l.start + 1) % len(l.events)
+	}
+
+	return e
+}
+
+// since returns events after the event with the given id. False is
+// returned if some of them are already dropped or id is unknown,
+// e.g. issued before restart
+func (l *eventLog) since(id string) ([]loggedEvent, bool) {
+	i := strings.LastIndexByte(id, '-')
+	if i < 0 || id[:i] != l.boot {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil || seq > l.seq {
+		return nil, false
+	}
+	n := int(l.seq - seq)
+	if n > len(l.events) {
+		return nil, false
+	}
+
+	events := make([]loggedEvent, 0, n)
+	for i := len(l.events) - n; i < len(l.events); i++ {
+		events = append(events, l.events[(l.start+i)%len(l.events)])
+	}
+
+	return events, true
+}
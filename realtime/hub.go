@@ -0,0 +1,310 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/olahol/melody.v1"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// missedHeartbeats is the number of heartbeats client may not
+// answer before the session is closed
+const missedHeartbeats = 3
+
+// topicsKey is the melody session key of the topics
+// session is subscribed to on connect
+const topicsKey = "topics"
+
+// session is the state of one websocket connection
+type session struct {
+	id string
+	ms *melody.Session
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func (s *session) subscribe(topics []string, on bool) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range topics {
+		if on {
+			s.topics[t] = true
+		} else {
+			delete(s.topics, t)
+		}
+	}
+
+	current := make([]string, 0, len(s.topics))
+	for t := range s.topics {
+		current = append(current, t)
+	}
+	sort.Strings(current)
+
+	return current
+}
+
+// match returns the first of the topics session is subscribed to
+func (s *session) match(topics []string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range topics {
+		if s.topics[t] {
+			return t, true
+		}
+	}
+
+	return "", false
+}
+
+// ResyncFunc returns the current state of the topic
+type ResyncFunc func() (interface{}, error)
+
+type resync struct {
+	topic string
+	state ResyncFunc
+}
+
+// Hub keeps websocket sessions and sends them events of the topics
+// they are subscribed to
+type Hub struct {
+	m         *melody.Melody
+	sessions  sync.Map
+	heartbeat time.Duration
+	logger    *logrus.Logger
+	seq       uint64
+	// done stops SSE streams on close
+	done chan struct{}
+
+	resyncMu sync.RWMutex
+	resyncs  []resync
+
+	// mu guards event log and SSE streams, so stream resuming
+	// from the log does not miss events published meanwhile
+	mu      sync.Mutex
+	log     *eventLog
+	streams map[*stream]struct{}
+}
+
+// NewHub creates hub sending websocket ping frames with the given
+// interval, sessions not answering them with pong frames for
+// missedHeartbeats intervals are closed. Browsers answer pings
+// automatically, so clients don't need to do anything
+func NewHub(heartbeat time.Duration, logger *logrus.Logger) *Hub {
+	h := &Hub{
+		m:         melody.New(),
+		heartbeat: heartbeat,
+		logger:    logger,
+		done:      make(chan struct{}),
+		log:       newEventLog(DefaultEventLogSize),
+		streams:   make(map[*stream]struct{}),
+	}
+	h.m.Config.PingPeriod = heartbeat
+	h.m.Config.PongWait = missedHeartbeats * heartbeat
+	h.m.HandleConnect(h.connect)
+	h.m.HandleDisconnect(h.disconnect)
+	h.m.HandleMessage(h.message)
+
+	return h
+}
+
+// HandleRequest upgrades request to websocket connection,
+// session is subscribed to the given topics right away
+func (h *Hub) HandleRequest(w http.ResponseWriter, r *http.Request, topics ...string) error {
+	return h.m.HandleRequestWithKeys(w, r, map[string]interface{}{topicsKey: topics})
+}
+
+// Len returns number of connected sessions
+func (h *Hub) Len() int {
+	return h.m.Len()
+}
+
+// OnConnectResync registers state of the topic sent to sessions subscribed
+// to it, right after welcome message or after ack of the subscription
+func (h *Hub) OnConnectResync(topic string, state ResyncFunc) {
+	h.resyncMu.Lock()
+	defer h.resyncMu.Unlock()
+	h.resyncs = append(h.resyncs, resync{topic: topic, state: state})
+}
+
+func (h *Hub) Close() error {
+	close(h.done)
+	return h.m.Close()
+}
+
+// Publish sends event to sessions and SSE streams subscribed to any
+// of the topics and saves it in the event log. Client receives the event
+// once with the first topic it's subscribed to
+func (h *Hub) Publish(event string, data interface{}, topics ...string) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	e := h.log.append(loggedEvent{event: event, data: raw, topics: topics, time: time.Now()})
+	for st := range h.streams {
+		if !st.push(e) {
+			delete(h.streams, st)
+		}
+	}
+	h.mu.Unlock()
+
+	messages := make(map[string][]byte)
+	var errs []string
+	h.sessions.Range(func(k, v interface{}) bool {
+		s := v.(*session)
+		topic, ok := s.match(topics)
+		if !ok {
+			return true
+		}
+		b, ok := messages[topic]
+		if !ok {
+			b, err = json.Marshal(e.message(topic))
+			if err != nil {
+				errs = append(errs, err.Error())
+				return false
+			}
+			messages[topic] = b
+		}
+		err = s.ms.Write(b)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("session %s: %v", s.id, err))
+		}
+		return true
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("realtime: failed to publish %s: %s", event, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// resyncMessages returns current state of the registered topics
+// accepted by filter
+func (h *Hub) resyncMessages(filter func(topic string) bool) []Message {
+	h.resyncMu.RLock()
+	resyncs := h.resyncs
+	h.resyncMu.RUnlock()
+
+	var messages []Message
+	for _, r := range resyncs {
+		if !filter(r.topic) {
+			continue
+		}
+		state, err := r.state()
+		if err != nil {
+			h.logger.Errorf("failed to get state of %s: %v", r.topic, err)
+			continue
+		}
+		raw, err := json.Marshal(state)
+		if err != nil {
+			h.logger.Errorf("failed to marshal state of %s: %v", r.topic, err)
+			continue
+		}
+		messages = append(messages, Message{Type: TypeResync, Topic: r.topic, Data: raw, Time: time.Now()})
+	}
+
+	return messages
+}
+
+func (h *Hub) connect(ms *melody.Session) {
+	s := &session{
+		id:     fmt.Sprintf("ws%d", atomic.AddUint64(&h.seq, 1)),
+		ms:     ms,
+		topics: make(map[string]bool),
+	}
+	if topics, ok := ms.Get(topicsKey); ok {
+		s.subscribe(topics.([]string), true)
+	}
+	h.sessions.Store(ms, s)
+	h.logger.Infof("ws session %s connected", s.id)
+
+	h.send(s, Message{Type: TypeWelcome}, Welcome{SessionID: s.id, Heartbeat: int(h.heartbeat.Seconds())})
+	for _, msg := range h.resyncMessages(func(topic string) bool {
+		_, ok := s.match([]string{topic})
+		return ok
+	}) {
+		h.send(s, msg, nil)
+	}
+}
+
+func (h *Hub) disconnect(ms *melody.Session) {
+	if v, ok := h.sessions.Load(ms); ok {
+		h.logger.Infof("ws session %s disconnected", v.(*session).id)
+	}
+	h.sessions.Delete(ms)
+}
+
+func (h *Hub) message(ms *melody.Session, b []byte) {
+	v, ok := h.sessions.Load(ms)
+	if !ok {
+		return
+	}
+	s := v.(*session)
+
+	var msg Message
+	err := json.Unmarshal(b, &msg)
+	if err != nil {
+		h.send(s, Message{Type: TypeError, Error: "message should be json"}, nil)
+		return
+	}
+
+	switch msg.Type {
+	case TypeSubscribe, TypeUnsubscribe:
+		var invalid []string
+		for _, t := range msg.Topics {
+			if !ValidTopic(t) {
+				invalid = append(invalid, t)
+			}
+		}
+		if len(msg.Topics) == 0 || len(invalid) > 0 {
+			h.send(s, Message{Type: TypeError, ID: msg.ID, Error: fmt.Sprintf("invalid topics: %s", strings.Join(invalid, ", "))}, nil)
+			return
+		}
+		topics := s.subscribe(msg.Topics, msg.Type == TypeSubscribe)
+		h.send(s, Message{Type: TypeAck, ID: msg.ID, Topics: topics}, nil)
+		if msg.Type == TypeSubscribe {
+			subscribed := make(map[string]bool)
+			for _, t := range msg.Topics {
+				subscribed[t] = true
+			}
+			for _, rm := range h.resyncMessages(func(topic string) bool { return subscribed[topic] }) {
+				h.send(s, rm, nil)
+			}
+		}
+	case TypePing:
+		h.send(s, Message{Type: TypePong, ID: msg.ID}, nil)
+	default:
+		h.send(s, Message{Type: TypeError, ID: msg.ID, Error: fmt.Sprintf("unknown message type %q", msg.Type)}, nil)
+	}
+}
+
+// send writes message to the session, data is set if it's not nil
+func (h *Hub) send(s *session, msg Message, data interface{}) {
+	msg.Time = time.Now()
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			h.logger.Errorf("failed to marshal %s message: %v", msg.Type, err)
+			return
+		}
+		msg.Data = raw
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Errorf("failed to marshal %s message: %v", msg.Type, err)
+		return
+	}
+	err = s.ms.Write(b)
+	if err != nil {
+		h.logger.Errorf("failed to send %s message to session %s: %v", msg.Type, s.id, err)
+	}
+}
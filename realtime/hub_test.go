@@ -0,0 +1,177 @@
+package realtime_test
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	require.NoError(t, err)
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) realtime.Message {
+	var msg realtime.Message
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestHub(t *testing.T) {
+	hub := realtime.NewHub(time.Hour, logrus.New())
+	defer hub.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.HandleRequest(w, r)
+	}))
+	defer srv.Close()
+
+	shipments := dial(t, srv.URL)
+	defer shipments.Close()
+	one := dial(t, srv.URL)
+	defer one.Close()
+
+	welcome := read(t, shipments)
+	assert.Equal(t, realtime.TypeWelcome, welcome.Type)
+	assert.Equal(t, realtime.TypeWelcome, read(t, one).Type)
+
+	require.NoError(t, shipments.WriteJSON(realtime.Message{Type: realtime.TypeSubscribe, ID: "1", Topics: []string{realtime.TopicShipments, realtime.TopicPrintJobs}}))
+	ack := read(t, shipments)
+	assert.Equal(t, realtime.TypeAck, ack.Type)
+	assert.Equal(t, "1", ack.ID)
+	assert.Equal(t, []string{realtime.TopicPrintJobs, realtime.TopicShipments}, ack.Topics)
+
+	require.NoError(t, one.WriteJSON(realtime.Message{Type: realtime.TypeSubscribe, Topics: []string{realtime.ShipmentTopic("SPN00123")}}))
+	assert.Equal(t, realtime.TypeAck, read(t, one).Type)
+
+	require.NoError(t, one.WriteJSON(realtime.Message{Type: realtime.TypeSubscribe, ID: "2", Topics: []string{"everything"}}))
+	e := read(t, one)
+	assert.Equal(t, realtime.TypeError, e.Type)
+	assert.Equal(t, "2", e.ID)
+
+	require.NoError(t, hub.Publish("shipment.status_changed", map[string]string{"code": "SPN00123"}, realtime.ShipmentTopic("SPN00123"), realtime.TopicShipments))
+	require.NoError(t, hub.Publish("shipment.status_changed", map[string]string{"code": "SPN00124"}, realtime.ShipmentTopic("SPN00124"), realtime.TopicShipments))
+
+	ev := read(t, one)
+	assert.Equal(t, realtime.TypeEvent, ev.Type)
+	assert.Equal(t, realtime.ShipmentTopic("SPN00123"), ev.Topic)
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(ev.Data, &data))
+	assert.Equal(t, "SPN00123", data["code"])
+
+	assert.Equal(t, realtime.TopicShipments, read(t, shipments).Topic)
+	assert.Equal(t, realtime.TopicShipments, read(t, shipments).Topic)
+
+	// only the ping answer is received, event of SPN00124 is not
+	require.NoError(t, one.WriteJSON(realtime.Message{Type: realtime.TypePing, ID: "3"}))
+	pong := read(t, one)
+	assert.Equal(t, realtime.TypePong, pong.Type)
+	assert.Equal(t, "3", pong.ID)
+
+	require.NoError(t, shipments.WriteJSON(realtime.Message{Type: realtime.TypeUnsubscribe, Topics: []string{realtime.TopicShipments}}))
+	assert.Equal(t, []string{realtime.TopicPrintJobs}, read(t, shipments).Topics)
+}
+
+func TestHub_OnConnectResync(t *testing.T) {
+	hub := realtime.NewHub(time.Hour, logrus.New())
+	defer hub.Close()
+	hub.OnConnectResync(realtime.TopicShipments, func() (interface{}, error) {
+		return []string{"SPN00123"}, nil
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws/shipments" {
+			_ = hub.HandleRequest(w, r, realtime.TopicShipments)
+			return
+		}
+		_ = hub.HandleRequest(w, r)
+	}))
+	defer srv.Close()
+
+	// session subscribed on connect gets resync after welcome
+	shipments := dial(t, srv.URL+"/ws/shipments")
+	defer shipments.Close()
+	assert.Equal(t, realtime.TypeWelcome, read(t, shipments).Type)
+	msg := read(t, shipments)
+	assert.Equal(t, realtime.TypeResync, msg.Type)
+	assert.Equal(t, realtime.TopicShipments, msg.Topic)
+	assert.JSONEq(t, `["SPN00123"]`, string(msg.Data))
+
+	// other sessions get it after subscription only
+	conn := dial(t, srv.URL)
+	defer conn.Close()
+	assert.Equal(t, realtime.TypeWelcome, read(t, conn).Type)
+	require.NoError(t, conn.WriteJSON(realtime.Message{Type: realtime.TypeSubscribe, Topics: []string{realtime.TopicPrintJobs}}))
+	assert.Equal(t, realtime.TypeAck, read(t, conn).Type)
+	require.NoError(t, conn.WriteJSON(realtime.Message{Type: realtime.TypeSubscribe, Topics: []string{realtime.TopicShipments}}))
+	assert.Equal(t, realtime.TypeAck, read(t, conn).Type)
+	msg = read(t, conn)
+	assert.Equal(t, realtime.TypeResync, msg.Type)
+	assert.Equal(t, realtime.TopicShipments, msg.Topic)
+}
+
+func TestHub_Heartbeat(t *testing.T) {
+	hub := realtime.NewHub(50*time.Millisecond, logrus.New())
+	defer hub.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.HandleRequest(w, r)
+	}))
+	defer srv.Close()
+
+	// websocket client answers ping frames with pong while reading
+	alive := dial(t, srv.URL)
+	defer alive.Close()
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	silent := dial(t, srv.URL)
+	defer silent.Close()
+	silent.SetPingHandler(func(string) error { return nil })
+	assert.Equal(t, realtime.TypeWelcome, read(t, silent).Type)
+
+	// session not answering ping frames is closed
+	require.NoError(t, silent.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, _, err := silent.ReadMessage()
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool { return hub.Len() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, hub.Len())
+}
+
+func TestHub_HandleRequestTopics(t *testing.T) {
+	hub := realtime.NewHub(time.Hour, logrus.New())
+	defer hub.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.HandleRequest(w, r, realtime.TopicShipments)
+	}))
+	defer srv.Close()
+
+	conn := dial(t, srv.URL)
+	defer conn.Close()
+	assert.Equal(t, realtime.TypeWelcome, read(t, conn).Type)
+
+	require.NoError(t, hub.Publish("shipment.updated", map[string]string{"code": "SPN00123"}, realtime.TopicShipments))
+	ev := read(t, conn)
+	assert.Equal(t, realtime.TypeEvent, ev.Type)
+	assert.Equal(t, realtime.TopicShipments, ev.Topic)
+}
+
+func TestValidTopic(t *testing.T) {
+	assert.True(t, realtime.ValidTopic(realtime.TopicEntries))
+	assert.True(t, realtime.ValidTopic("shipment:SPN00123"))
+	assert.False(t, realtime.ValidTopic("shipment:"))
+	assert.False(t, realtime.ValidTopic("all"))
+}
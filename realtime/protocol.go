@@ -0,0 +1,91 @@
+// Package realtime pushes events to web clients. Clients subscribe
+// to topics and receive only the events published to them
+package realtime
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Types of the messages sent by clients
+const (
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	TypePing        = "ping"
+)
+
+// Types of the messages sent by server. Heartbeat is sent as
+// websocket ping frames, client's ping message is answered with pong
+const (
+	TypeWelcome = "welcome"
+	TypeAck     = "ack"
+	TypeEvent   = "event"
+	TypePong    = "pong"
+	TypeError   = "error"
+	// TypeResync carries the current state of the topic, sent on connect
+	// and on subscription to the topic. Client should replace its state
+	// with it and apply events after
+	TypeResync = "resync"
+)
+
+// Topics clients can subscribe to
+const (
+	TopicShipments = "shipments"
+	TopicEntries   = "entries"
+	TopicPrintJobs = "print_jobs"
+	TopicLoading   = "loading"
+
+	shipmentTopicPrefix = "shipment:"
+	entryTopicPrefix    = "entry:"
+)
+
+// ShipmentTopic is the topic of events of one shipment, e.g. shipment:SPN00123
+func ShipmentTopic(code string) string {
+	return shipmentTopicPrefix + code
+}
+
+// EntryTopic is the topic of events of one entry, e.g. entry:EN00123
+func EntryTopic(id string) string {
+	return entryTopicPrefix + id
+}
+
+// ValidTopic checks if topic is known or is a topic of a shipment or an entry
+func ValidTopic(topic string) bool {
+	switch topic {
+	case TopicShipments, TopicEntries, TopicPrintJobs, TopicLoading:
+		return true
+	}
+	for _, prefix := range []string{shipmentTopicPrefix, entryTopicPrefix} {
+		if strings.HasPrefix(topic, prefix) && len(topic) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Message is the envelope of all the messages in both directions
+type Message struct {
+	Type string `json:"type"`
+	// ID is set by client and returned in ack or error
+	ID     string   `json:"id,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+	// Seq is the sequence number of the event since the service start
+	Seq uint64 `json:"seq,omitempty"`
+	// EventID is <boot id>-<seq>, used as SSE event id
+	EventID string `json:"event_id,omitempty"`
+	// Topic and Event are set for event messages
+	Topic string          `json:"topic,omitempty"`
+	Event string          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Time  time.Time       `json:"time"`
+}
+
+// Welcome is the data of the first message of the session
+type Welcome struct {
+	SessionID string `json:"session_id"`
+	// Heartbeat is interval of websocket ping frames in seconds
+	Heartbeat int `json:"heartbeat"`
+}
@@ -0,0 +1,159 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderLastEventID is sent by EventSource when it reconnects
+const HeaderLastEventID = "Last-Event-ID"
+
+const (
+	// streamBuffer is the number of events waiting to be written to
+	// SSE client. Stream is closed if client is slower, it resumes
+	// with Last-Event-ID after reconnect
+	streamBuffer = 256
+	// sseRetry is reconnection delay suggested to EventSource in milliseconds
+	sseRetry = 3000
+)
+
+// streamTopics are the topics of SSE stream if client did not set any
+var streamTopics = []string{TopicShipments, TopicEntries, TopicPrintJobs, TopicLoading}
+
+// stream is the SSE client
+type stream struct {
+	topics map[string]bool
+	events chan loggedEvent
+}
+
+// push queues event if client is subscribed to it, returns false
+// and closes stream if client does not keep up
+func (st *stream) push(e loggedEvent) bool {
+	if _, ok := e.match(st.topics); !ok {
+		return true
+	}
+	select {
+	case st.events <- e:
+		return true
+	default:
+		close(st.events)
+		return false
+	}
+}
+
+// ServeSSE streams events as Server-Sent Events. Topics are set by
+// comma separated topics query param. Client resuming with Last-Event-ID
+// header or last_event_id query param receives the missed events from
+// the log, if they are already dropped it receives resync messages instead
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	topics, err := parseStreamTopics(r.URL.Query().Get("topics"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lastID := r.Header.Get(HeaderLastEventID)
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	st := &stream{topics: topics, events: make(chan loggedEvent, streamBuffer)}
+	var missed []loggedEvent
+	resumed := false
+	h.mu.Lock()
+	if lastID != "" {
+		missed, resumed = h.log.since(lastID)
+	}
+	h.streams[st] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.streams, st)
+		h.mu.Unlock()
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disables response buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+
+	if !resumed {
+		for _, msg := range h.resyncMessages(func(topic string) bool { return topics[topic] }) {
+			writeSSE(w, msg)
+		}
+	}
+	for _, e := range missed {
+		if topic, ok := e.match(topics); ok {
+			writeSSE(w, e.message(topic))
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-ticker.C:
+			_, _ = fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case e, ok := <-st.events:
+			if !ok {
+				h.logger.Warnf("sse client %s is too slow, stream is closed", r.RemoteAddr)
+				return
+			}
+			topic, _ := e.match(topics)
+			writeSSE(w, e.message(topic))
+			flusher.Flush()
+		}
+	}
+}
+
+func parseStreamTopics(raw string) (map[string]bool, error) {
+	list := streamTopics
+	if strings.TrimSpace(raw) != "" {
+		list = strings.Split(raw, ",")
+	}
+
+	topics := make(map[string]bool)
+	for _, t := range list {
+		t = strings.TrimSpace(t)
+		if !ValidTopic(t) {
+			return nil, fmt.Errorf("invalid topic %q", t)
+		}
+		topics[t] = true
+	}
+
+	return topics, nil
+}
+
+// writeSSE writes message as SSE event, events have id to resume from
+func writeSSE(w http.ResponseWriter, msg Message) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if msg.EventID != "" {
+		_, _ = fmt.Fprintf(w, "id: %s\n", msg.EventID)
+	}
+	name := msg.Event
+	if msg.Type != TypeEvent {
+		name = msg.Type
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+}
@@ -0,0 +1,129 @@
+package realtime_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseFrame struct {
+	ID    string
+	Event string
+	Msg   realtime.Message
+}
+
+// readFrame reads the next SSE event skipping retry and comment lines
+func readFrame(t *testing.T, r *bufio.Reader) sseFrame {
+	var f sseFrame
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if f.Event != "" {
+				return f
+			}
+		case strings.HasPrefix(line, "id: "):
+			f.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			f.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &f.Msg))
+		}
+	}
+}
+
+func openStream(t *testing.T, url string, lastEventID string) (*bufio.Reader, func()) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set(realtime.HeaderLastEventID, lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	return bufio.NewReader(res.Body), func() { _ = res.Body.Close() }
+}
+
+func TestHub_ServeSSE(t *testing.T) {
+	hub := realtime.NewHub(time.Hour, logrus.New())
+	defer hub.Close()
+	hub.OnConnectResync(realtime.TopicShipments, func() (interface{}, error) {
+		return []string{"SPN00123"}, nil
+	})
+	srv := httptest.NewServer(http.HandlerFunc(hub.ServeSSE))
+	defer srv.Close()
+
+	// new client receives the state
+	r, closeStream := openStream(t, srv.URL+"?topics=shipments,print_jobs", "")
+	f := readFrame(t, r)
+	assert.Equal(t, realtime.TypeResync, f.Event)
+	assert.Empty(t, f.ID)
+	assert.JSONEq(t, `["SPN00123"]`, string(f.Msg.Data))
+
+	require.NoError(t, hub.Publish("shipment.created", "SPN00123", realtime.ShipmentTopic("SPN00123"), realtime.TopicShipments))
+	require.NoError(t, hub.Publish("print_job.printed", "EN1", realtime.TopicPrintJobs))
+	require.NoError(t, hub.Publish("shipment.updated", "SPN00123", realtime.ShipmentTopic("SPN00123"), realtime.TopicShipments))
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, readFrame(t, r).ID)
+	}
+	closeStream()
+	boot := strings.TrimSuffix(ids[0], "-1")
+	require.NotEqual(t, ids[0], boot)
+	assert.Equal(t, boot+"-3", ids[2])
+
+	// resumed client receives only missed events of its topics
+	r, closeStream = openStream(t, srv.URL+"?topics=shipments", ids[0])
+	defer closeStream()
+	f = readFrame(t, r)
+	assert.Equal(t, ids[2], f.ID)
+	assert.Equal(t, "shipment.updated", f.Event)
+	assert.Equal(t, realtime.TopicShipments, f.Msg.Topic)
+	assert.Equal(t, uint64(3), f.Msg.Seq)
+	assert.Equal(t, ids[2], f.Msg.EventID)
+
+	require.NoError(t, hub.Publish("entry.updated", "EN1", realtime.TopicEntries))
+	require.NoError(t, hub.Publish("shipment.removed", "SPN00123", realtime.ShipmentTopic("SPN00123"), realtime.TopicShipments))
+	f = readFrame(t, r)
+	assert.Equal(t, boot+"-5", f.ID)
+	assert.Equal(t, "shipment.removed", f.Event)
+
+	// id of the previous start gets resync even if its seq is known
+	r2, closeStream2 := openStream(t, srv.URL, "previous-1")
+	defer closeStream2()
+	assert.Equal(t, realtime.TypeResync, readFrame(t, r2).Event)
+
+	// unknown seq gets resync
+	r3, closeStream3 := openStream(t, srv.URL, boot+"-999")
+	defer closeStream3()
+	assert.Equal(t, realtime.TypeResync, readFrame(t, r3).Event)
+
+	// events dropped from the log are not replayed
+	for i := 0; i < realtime.DefaultEventLogSize; i++ {
+		require.NoError(t, hub.Publish("entry.updated", "EN1", realtime.TopicEntries))
+	}
+	r4, closeStream4 := openStream(t, srv.URL, boot+"-4")
+	defer closeStream4()
+	assert.Equal(t, realtime.TypeResync, readFrame(t, r4).Event)
+}
+
+func TestHub_ServeSSE_InvalidTopic(t *testing.T) {
+	hub := realtime.NewHub(time.Hour, logrus.New())
+	defer hub.Close()
+
+	w := httptest.NewRecorder()
+	hub.ServeSSE(w, httptest.NewRequest(http.MethodGet, "/events?topics=everything", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
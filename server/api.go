@@ -3,15 +3,22 @@ package server
 import (
 	"fmt"
 	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/boltdb"
 	"github.com/amanbolat/ca-warehouse-client/crm"
+	"github.com/amanbolat/ca-warehouse-client/documents"
 	"github.com/amanbolat/ca-warehouse-client/filemaker"
+	"github.com/amanbolat/ca-warehouse-client/integration"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
 	"github.com/amanbolat/ca-warehouse-client/printing"
 	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
 	"github.com/gorilla/schema"
 	"github.com/labstack/echo/v4"
 	"github.com/patrickmn/go-cache"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type JSONResponse struct {
@@ -20,19 +27,43 @@ type JSONResponse struct {
 }
 
 type API struct {
-	entryStore       *filemaker.EntryStore
-	shipmentStore    *filemaker.ShipmentStore
-	customerStore    *filemaker.CustomerStore
-	memCache         *cache.Cache
-	kdniaoApi        *api.KDNiaoApi
-	printer          printing.Printer
-	labelManager     printing.LabelManager
-	apiRequestsCache *cache.Cache
+	entryStore         *filemaker.EntryStore
+	shipmentStore      *filemaker.ShipmentStore
+	customerStore      *filemaker.CustomerStore
+	memCache           *cache.Cache
+	kdniaoApi          *api.KDNiaoApi
+	printer            printing.Printer
+	labelManager       printing.LabelManager
+	apiRequestsCache   *cache.Cache
+	preAdviceStore     *boltdb.PreAdviceStore
+	events             eventPublisher
+	tariffStore        *boltdb.TariffStore
+	volumetricRules    pricing.VolumetricRules
+	statusHistoryStore *boltdb.StatusHistoryStore
+	noteStore          *filemaker.NoteStore
+	loadingStore       *boltdb.LoadingStore
+	dispatchStore      *boltdb.DispatchStore
+	documentGenerator  documents.Generator
+	documentsConfig    documents.Config
+	hsCodeStore        *boltdb.HSCodeStore
+	partnerStore       *boltdb.PartnerStore
+	partners           *logistics.PartnerRegistry
+	transferPoints     *logistics.TransferPointRegistry
+	partnerDeliveries  *boltdb.PartnerDeliveryStore
+	partnerPusher      *integration.Pusher
+	webhookStore       *boltdb.WebhookStore
+	webhooks           *webhook.Dispatcher
+	// warehouse is the code of the warehouse shipments and entries are listed for
+	warehouse string
 }
 
 // XApiRequestId used to prevent duplicated POST requests
 const XApiRequestId = "X-API-REQUEST-ID"
 
+// XApiUser is the name of the user, who made the request.
+// Used in history of changes
+const XApiUser = "X-API-USER"
+
 var singleRecordMeta = api.ResponseMeta{
 	Page:  1,
 	Count: 1,
@@ -48,7 +79,7 @@ func (a API) GetEntryList(c echo.Context) error {
 	}
 
 	meta = warehouse.MapEntryFields(meta)
-	meta.InternalFilter["Warehouse"] = "=GZWH2"
+	meta.InternalFilter["Warehouse"] = "=" + a.warehouse
 	meta.InternalFilter["Id_shipmentNumber"] = "="
 	meta.InternalFilter["is_utilized"] = "="
 
@@ -98,6 +129,12 @@ func (a API) GetShipmentList(c echo.Context) error {
 		return api.NewError(err, "请求有误", "建议您联系管理员")
 	}
 
+	meta, err = logistics.MapShipmentFields(meta, a.transferPoints)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("筛选条件有误: %v", err), "")
+	}
+	meta.InternalFilter["Departure_Warehouse"] = "==" + a.warehouse
+
 	shipments, res, err := a.shipmentStore.GetShipmentList(meta)
 	if err != nil {
 		return err
@@ -117,7 +154,7 @@ func (a API) PrintEntryBarcode(c echo.Context) error {
 		return api.NewError(err, "无法生成入库标签", "建议您联系管理员")
 	}
 
-	err = a.printer.PrintFiles(1, "", bc.FullPath)
+	err = printFiles(a.printer, a.events, PrintJob{Kind: PrintJobEntryBarcode, Reference: entryId}, 1, bc.FullPath)
 	if err != nil {
 		return api.NewError(err, "无法打印入库标签", "建议您联系管理员")
 	}
@@ -185,7 +222,7 @@ func (a API) PrintShipmentULLabels(c echo.Context) error {
 		return api.NewError(err, "生成货物标签遇到错误", "建议您联系管理员")
 	}
 
-	err = a.printer.PrintFiles(copies, "", label.FullPath)
+	err = printFiles(a.printer, a.events, PrintJob{Kind: PrintJobUnitLoadLabels, Reference: sm.Code}, copies, label.FullPath)
 	if err != nil {
 		return api.NewError(err, "打印货物标签遇到错误", "建议您联系管理员")
 	}
@@ -206,7 +243,7 @@ func (a API) PrintShipmentPreparationInfo(c echo.Context) error {
 		return api.NewError(err, "无法生成发货明细", "建议您联系管理员")
 	}
 
-	err = a.printer.PrintFiles(1, "", l.FullPath)
+	err = printFiles(a.printer, a.events, PrintJob{Kind: PrintJobPreparationInfo, Reference: sm.Code}, 1, l.FullPath)
 	if err != nil {
 		return api.NewError(err, "打印货物明细遇到错误", "建议您联系管理员")
 	}
@@ -222,12 +259,22 @@ func (a API) PrintShipmentPartnerInfo(c echo.Context) error {
 		return err
 	}
 
+	report := logistics.ValidateShipment(sm, a.partners.ValidationRules()...)
+	if report.HasErrors() {
+		return api.NewError(nil, fmt.Sprintf("票号 %s 信息不完整，无法打印合作方货物明细", code), report.ErrorMessages())
+	}
+
 	l, err := a.labelManager.CreateShipmentPartnerInfoLabel(sm)
 	if err != nil {
 		return api.NewError(err, "无法生成合作方货物明细", "建议您联系管理员")
 	}
 
-	err = a.printer.PrintFiles(1, "", l.FullPath)
+	copies := 1
+	if p, ok := a.partners.ByCode(sm.PartnerInfo.Code); ok {
+		copies = p.Labels.Copies
+	}
+
+	err = printFiles(a.printer, a.events, PrintJob{Kind: PrintJobPartnerInfo, Reference: sm.Code}, copies, l.FullPath)
 	if err != nil {
 		return api.NewError(err, "打印合作方货物明细遇到错误", "建议您联系管理员")
 	}
@@ -241,6 +288,10 @@ func (a API) EditEntry(c echo.Context) error {
 	if err != nil {
 		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
 	}
+	err = a.prepareEntryCustoms(e)
+	if err != nil {
+		return err
+	}
 
 	updatedEntry, err := a.entryStore.UpdateEntry(*e)
 	if err != nil {
@@ -258,6 +309,11 @@ func (a API) CreateEntry(c echo.Context) error {
 		a.removeApiRequestId(c)
 		return api.NewError(err, "请求有误", "有可能新加的入库数据有误。建议您联系管理员")
 	}
+	err = a.prepareEntryCustoms(entry)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return err
+	}
 
 	newEntry, err := a.entryStore.CreateEntry(*entry)
 	if err != nil {
@@ -265,9 +321,31 @@ func (a API) CreateEntry(c echo.Context) error {
 		return err
 	}
 
+	a.matchPreAdvice(c, newEntry)
+
 	return c.JSON(http.StatusOK, newEntry)
 }
 
+// prepareEntryCustoms normalizes and checks customs attributes of the entry
+func (a API) prepareEntryCustoms(e *warehouse.Entry) error {
+	err := e.PrepareCustoms()
+	if err != nil {
+		return api.NewError(err, "报关信息有误", customsHint(err))
+	}
+
+	return a.checkHSCode(e.Customs.HSCode)
+}
+
+// apiUser returns name of the user who made the request
+func apiUser(c echo.Context) string {
+	user := strings.TrimSpace(c.Request().Header.Get(XApiUser))
+	if user == "" {
+		return logistics.StatusSourceAPI
+	}
+
+	return user
+}
+
 // removeApiRequestId removes XApiRequestId from
 // apiRequestsCache. Used when request is failed
 func (a API) removeApiRequestId(c echo.Context) {
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type createConsolidationRequest struct {
+	CustomerCode    string                    `json:"customer_code"`
+	TransferPoint   string                    `json:"transfer_point"`
+	TransportMethod logistics.TransportMethod `json:"transport_method"`
+}
+
+func (a API) CreateConsolidation(c echo.Context) error {
+	req := createConsolidationRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对转运点和运输方式")
+	}
+
+	tp, ok := a.transferPoints.BySlug(req.TransferPoint)
+	if !ok {
+		a.removeApiRequestId(c)
+		return api.NewError(errors.Errorf("unknown transfer point %s", req.TransferPoint), fmt.Sprintf("没有找到转运点 %s", req.TransferPoint), "请核对转运点")
+	}
+
+	sm := logistics.NewConsolidation(tp, req.TransportMethod, a.warehouse)
+	sm.CustomerCode = req.CustomerCode
+
+	created, err := a.shipmentStore.CreateConsolidation(sm)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "集运票号创建失败", "请核对转运点和运输方式")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: created,
+	})
+}
+
+// GetConsolidation returns consolidation with weight, cubage
+// and unit loads of all consolidated shipments
+func (a API) GetConsolidation(c echo.Context) error {
+	code := c.Param("code")
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到集运票号 %s", code), "")
+	}
+	if !sm.IsConsolidation() {
+		return api.NewError(logistics.ErrNotConsolidation, fmt.Sprintf("%s 不是集运票号", code), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: sm,
+	})
+}
+
+type attachShipmentRequest struct {
+	Code string `json:"code"`
+}
+
+func (a API) AttachShipment(c echo.Context) error {
+	code := c.Param("code")
+
+	req := attachShipmentRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到集运票号 %s", code), "")
+	}
+
+	child, err := a.shipmentStore.GetShipmentByCode(req.Code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", req.Code), "")
+	}
+
+	err = sm.Attach(&child)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法加入集运 %s", req.Code, code), consolidationHint(err))
+	}
+
+	_, err = a.shipmentStore.UpdateConsolidationID(child)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法加入集运 %s", req.Code, code), "原因无知，请联系管理员")
+	}
+
+	return a.GetConsolidation(c)
+}
+
+func (a API) DetachShipment(c echo.Context) error {
+	code := c.Param("code")
+	childCode := c.Param("child_code")
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到集运票号 %s", code), "")
+	}
+
+	child, err := sm.Detach(childCode)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法从集运 %s 移除", childCode, code), consolidationHint(err))
+	}
+
+	_, err = a.shipmentStore.UpdateConsolidationID(*child)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法从集运 %s 移除", childCode, code), "原因无知，请联系管理员")
+	}
+
+	return a.GetConsolidation(c)
+}
+
+func (a API) PrintConsolidationLabel(c echo.Context) error {
+	code := c.Param("code")
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到集运票号 %s", code), "")
+	}
+
+	l, err := a.labelManager.CreateConsolidationLabel(sm)
+	if err != nil {
+		return api.NewError(err, "无法生成集运标签", "建议您联系管理员")
+	}
+
+	err = printFiles(a.printer, a.events, PrintJob{Kind: PrintJobConsolidationLabel, Reference: sm.Code}, 1, l.FullPath)
+	if err != nil {
+		return api.NewError(err, "打印集运标签遇到错误", "建议您联系管理员")
+	}
+
+	return c.String(http.StatusOK, "done")
+}
+
+func consolidationHint(err error) string {
+	switch errors.Cause(err) {
+	case logistics.ErrNotConsolidation:
+		return "此票号不是集运票号"
+	case logistics.ErrChildIsConsolidation:
+		return "集运票号不能加入另一个集运"
+	case logistics.ErrChildAlreadyAttached:
+		return "此票号已加入另一个集运，请先移除"
+	case logistics.ErrChildNotAttached:
+		return "此票号不属于这个集运"
+	case logistics.ErrTransferPointMismatch:
+		return "票号的转运点与集运不一致"
+	case logistics.ErrTransportMethodMismatch:
+		return "票号的运输方式与集运不一致"
+	case logistics.ErrChildAlreadySentOut:
+		return "此票号已出库"
+	}
+
+	return fmt.Sprintf("集运只能在 %s 状态修改", logistics.Preparation)
+}
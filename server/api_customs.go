@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/boltdb"
+	"github.com/amanbolat/ca-warehouse-client/customs"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultHSCodeSearchLimit = 20
+
+// GetHSCodeList searches HS codes by code prefix
+// or description, q and limit query params are optional
+func (a API) GetHSCodeList(c echo.Context) error {
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultHSCodeSearchLimit
+	}
+
+	codes, err := a.hsCodeStore.SearchHSCodes(c.QueryParam("q"), limit)
+	if err != nil {
+		return api.NewError(err, "无法获取 HS 编码列表", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(codes), Total: len(codes)},
+		Data: codes,
+	})
+}
+
+func (a API) GetHSCode(c echo.Context) error {
+	code := c.Param("code")
+	h, err := a.hsCodeStore.GetHSCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到 HS 编码 %s", code), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: h,
+	})
+}
+
+// ImportHSCodes replaces HS codes reference table with codes from CSV file.
+// See customs.HSCodeCSVHeader for the format
+func (a API) ImportHSCodes(c echo.Context) error {
+	codes, err := customs.ParseHSCodesCSV(c.Request().Body)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("HS 编码文件有误: %v", err), fmt.Sprintf("文件列: %s", strings.Join(customs.HSCodeCSVHeader, ", ")))
+	}
+
+	err = a.hsCodeStore.ReplaceHSCodes(codes)
+	if err != nil {
+		return api.NewError(err, "HS 编码导入失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(codes), Total: len(codes)},
+		Data: map[string]int{"imported": len(codes)},
+	})
+}
+
+// checkHSCode checks that HS code exists in the reference table.
+// Any code is accepted until the table is imported
+func (a API) checkHSCode(code string) error {
+	code = customs.NormalizeHSCode(code)
+	if code == "" {
+		return nil
+	}
+	if !customs.ValidHSCode(code) {
+		return api.NewError(customs.ErrInvalidHSCode, fmt.Sprintf("HS 编码 %s 有误", code), fmt.Sprintf("HS 编码应为 %d 位数字", customs.HSCodeLength))
+	}
+
+	empty, err := a.hsCodeStore.IsEmpty()
+	if err != nil || empty {
+		return err
+	}
+
+	_, err = a.hsCodeStore.GetHSCode(code)
+	if errors.Cause(err) == boltdb.ErrNotFound {
+		return api.NewError(err, fmt.Sprintf("HS 编码 %s 不存在", code), "请在 HS 编码表中查找")
+	}
+
+	return err
+}
+
+func customsHint(err error) string {
+	switch errors.Cause(err) {
+	case customs.ErrInvalidHSCode:
+		return fmt.Sprintf("HS 编码应为 %d 位数字", customs.HSCodeLength)
+	case customs.ErrInvalidCountry:
+		return "原产国应为两位字母代码，例如 CN"
+	case customs.ErrNegativeValue:
+		return "单价和净重不能为负数"
+	}
+
+	return ""
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"time"
+)
+
+type createDispatchRequest struct {
+	Vehicle        string          `json:"vehicle"`
+	CapacityWeight decimal.Decimal `json:"capacity_weight"`
+	CapacityCubage decimal.Decimal `json:"capacity_cubage"`
+	TransferPoint  string          `json:"transfer_point"`
+	// DepartureDate in 2006-01-02 format
+	DepartureDate string `json:"departure_date"`
+}
+
+type assignShipmentRequest struct {
+	Code string `json:"code"`
+}
+
+type dispatchResponse struct {
+	logistics.Dispatch
+	Load logistics.DispatchLoad `json:"load"`
+}
+
+func newDispatchResponse(d logistics.Dispatch) dispatchResponse {
+	return dispatchResponse{Dispatch: d, Load: d.Load()}
+}
+
+func (a API) CreateDispatch(c echo.Context) error {
+	req := createDispatchRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对车辆信息")
+	}
+
+	departure, err := time.ParseInLocation("2006-01-02", req.DepartureDate, time.Local)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "出发日期有误", "日期格式为 2006-01-02")
+	}
+
+	tp, ok := a.transferPoints.BySlug(req.TransferPoint)
+	if !ok {
+		a.removeApiRequestId(c)
+		return api.NewError(errors.Errorf("unknown transfer point %s", req.TransferPoint), fmt.Sprintf("没有找到转运点 %s", req.TransferPoint), "请核对转运点")
+	}
+
+	d, err := a.dispatchStore.CreateDispatch(logistics.Dispatch{
+		Vehicle:        req.Vehicle,
+		CapacityWeight: req.CapacityWeight,
+		CapacityCubage: req.CapacityCubage,
+		TransferPoint:  tp,
+		DepartureDate:  departure,
+		CreatedAt:      time.Now(),
+		CreatedBy:      apiUser(c),
+	})
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "装车计划创建失败", "请核对车辆、载重、体积和转运点")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newDispatchResponse(d),
+	})
+}
+
+func (a API) GetDispatchList(c echo.Context) error {
+	dispatches, err := a.dispatchStore.ListDispatches()
+	if err != nil {
+		return api.NewError(err, "无法获取装车计划列表", "原因无知，请联系管理员")
+	}
+
+	res := []dispatchResponse{}
+	for _, d := range dispatches {
+		res = append(res, newDispatchResponse(d))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(res), Total: len(res)},
+		Data: res,
+	})
+}
+
+func (a API) GetDispatch(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.dispatchStore.GetDispatch(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到装车计划 %s", id), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newDispatchResponse(d),
+	})
+}
+
+// AssignDispatchShipment adds packed shipment to the dispatch.
+// Exceeding the vehicle capacity is shown in load warnings
+func (a API) AssignDispatchShipment(c echo.Context) error {
+	id := c.Param("id")
+
+	req := assignShipmentRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	sm, err := a.shipmentStore.GetShipmentByCode(req.Code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", req.Code), "")
+	}
+
+	d, err := a.dispatchStore.AssignShipment(id, sm, time.Now())
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法加入装车计划 %s", req.Code, id), dispatchHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newDispatchResponse(d),
+	})
+}
+
+func (a API) UnassignDispatchShipment(c echo.Context) error {
+	id := c.Param("id")
+	code := c.Param("code")
+
+	d, err := a.dispatchStore.UnassignShipment(id, code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("票号 %s 无法从装车计划 %s 移除", code, id), dispatchHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newDispatchResponse(d),
+	})
+}
+
+// GetDispatchManifest returns manifest of the dispatch as PDF,
+// or as CSV if format=csv
+func (a API) GetDispatchManifest(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.dispatchStore.GetDispatch(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到装车计划 %s", id), "")
+	}
+
+	if c.QueryParam("format") == "csv" {
+		buf := &bytes.Buffer{}
+		err = logistics.WriteDispatchManifestCSV(buf, d)
+		if err != nil {
+			return api.NewError(err, "无法生成装车清单", "建议您联系管理员")
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s-manifest.csv", d.ID))
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	}
+
+	l, err := a.labelManager.CreateDispatchManifest(d)
+	if err != nil {
+		return api.NewError(err, "无法生成装车清单", "建议您联系管理员")
+	}
+
+	return c.Attachment(l.FullPath, fmt.Sprintf("%s-manifest.pdf", d.ID))
+}
+
+func dispatchHint(err error) string {
+	switch errors.Cause(err) {
+	case logistics.ErrDispatchShipmentNotReady:
+		return fmt.Sprintf("只有 %s 状态的票号才能装车", logistics.Packed)
+	case logistics.ErrDispatchShipmentAssigned:
+		return "此票号已在装车计划中"
+	case logistics.ErrDispatchShipmentNotAssigned:
+		return "此票号不在这个装车计划中"
+	case logistics.ErrDispatchShipmentConsolidated:
+		return "此票号属于集运，请把集运票号加入装车计划"
+	case logistics.ErrTransferPointMismatch:
+		return "票号的转运点与装车计划不一致"
+	}
+
+	return "原因无知，请联系管理员"
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/documents"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GetShipmentDocument returns invoice or packing list of the declared
+// shipment, format query param is pdf (default) or xlsx
+func (a API) GetShipmentDocument(c echo.Context) error {
+	code := c.Param("code")
+	docType := documents.Type(c.Param("type"))
+	format := documents.Format(c.QueryParam("format"))
+	if format == "" {
+		format = documents.PDF
+	}
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+	}
+
+	doc, err := documents.NewCommercialDocument(sm, a.documentsConfig, time.Now())
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法生成票号 %s 的报关文件", code), documentHint(err))
+	}
+
+	file, err := a.documentGenerator.Generate(doc, docType, format)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法生成票号 %s 的报关文件", code), documentHint(err))
+	}
+
+	return c.Attachment(file.FullPath, file.Name)
+}
+
+// GetShipmentDeclaration returns preliminary goods declaration of the
+// shipment for customs broker, format query param is xml (default) or csv
+func (a API) GetShipmentDeclaration(c echo.Context) error {
+	code := c.Param("code")
+	format := documents.Format(c.QueryParam("format"))
+	if format == "" {
+		format = documents.XML
+	}
+	if format != documents.XML && format != documents.CSV {
+		return api.NewError(documents.ErrUnknownFormat, "请求有误", fmt.Sprintf("文件格式只能是 %s 或 %s", documents.XML, documents.CSV))
+	}
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+	}
+
+	d, err := documents.NewDeclaration(sm, a.documentsConfig, time.Now())
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法生成票号 %s 的报关单", code), documentHint(err))
+	}
+
+	var data []byte
+	contentType := "text/csv; charset=utf-8"
+	if format == documents.XML {
+		contentType = echo.MIMEApplicationXMLCharsetUTF8
+		data, err = d.XMLDocument()
+	} else {
+		buf := &bytes.Buffer{}
+		err = d.WriteCSV(buf)
+		data = buf.Bytes()
+	}
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法生成票号 %s 的报关单", code), documentHint(err))
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s-declaration.%s", d.Number, format))
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+func documentHint(err error) string {
+	if se, ok := errors.Cause(err).(documents.SchemaError); ok {
+		return fmt.Sprintf("报关单数据不符合格式要求: %s", strings.Join(se.Problems, "; "))
+	}
+
+	switch errors.Cause(err) {
+	case logistics.ErrIncompleteCustomsData:
+		return strings.TrimSuffix(err.Error(), ": "+logistics.ErrIncompleteCustomsData.Error())
+	case documents.ErrNotDeclared:
+		return "此票号不需要报关"
+	case documents.ErrNoConsignor:
+		return "没有设置发货人，请在配置中设置 DOCUMENTS_SHIPPER_NAME"
+	case documents.ErrNoDestination:
+		return fmt.Sprintf("转运点不在报关国家 (%s)，请设置转运点的清关国家", strings.Join(documents.DeclarationCountries, ", "))
+	case documents.ErrNoUnitLoads:
+		return "此票货物没有包装信息，请先录入每包信息"
+	case documents.ErrUnknownType:
+		return fmt.Sprintf("文件类型只能是 %s 或 %s", documents.Invoice, documents.PackingList)
+	case documents.ErrUnknownFormat:
+		return fmt.Sprintf("文件格式只能是 %s 或 %s", documents.PDF, documents.XLSX)
+	}
+
+	return "建议您联系管理员"
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type openLoadingSessionRequest struct {
+	Truck string `json:"truck"`
+}
+
+type loadingScanRequest struct {
+	Code string `json:"code"`
+}
+
+// LoadingScanEvent is sent to websocket clients on every scan
+type LoadingScanEvent struct {
+	SessionID string                  `json:"session_id"`
+	Scan      logistics.LoadingScan   `json:"scan"`
+	Report    logistics.LoadingReport `json:"report"`
+}
+
+type closeLoadingSessionRequest struct {
+	// SendOut moves complete shipments to sent_out status
+	SendOut bool `json:"send_out"`
+}
+
+type sendOutFailure struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+type closeLoadingSessionResponse struct {
+	Report  logistics.LoadingReport `json:"report"`
+	SentOut []string                `json:"sent_out"`
+	Failed  []sendOutFailure        `json:"failed"`
+}
+
+func (a API) OpenLoadingSession(c echo.Context) error {
+	req := openLoadingSessionRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对车辆信息")
+	}
+	if strings.TrimSpace(req.Truck) == "" {
+		a.removeApiRequestId(c)
+		return api.NewError(errors.New("truck is empty"), "请输入车牌号", "")
+	}
+
+	ls, err := a.loadingStore.CreateSession(logistics.NewLoadingSession(req.Truck, apiUser(c), time.Now()))
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "装车任务创建失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: ls,
+	})
+}
+
+// GetLoadingSessionList returns loading sessions, only open ones if open=true
+func (a API) GetLoadingSessionList(c echo.Context) error {
+	sessions, err := a.loadingStore.ListSessions(c.QueryParam("open") == "true")
+	if err != nil {
+		return api.NewError(err, "无法获取装车任务列表", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(sessions), Total: len(sessions)},
+		Data: sessions,
+	})
+}
+
+func (a API) GetLoadingSession(c echo.Context) error {
+	id := c.Param("id")
+	ls, err := a.loadingStore.GetSession(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到装车任务 %s", id), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: map[string]interface{}{
+			"session": ls,
+			"report":  ls.Report(),
+		},
+	})
+}
+
+// ScanUnitLoad registers scan of the unit load label's qr code.
+// Shipment is fetched from the database on the first scan of its unit load
+func (a API) ScanUnitLoad(c echo.Context) error {
+	id := c.Param("id")
+
+	req := loadingScanRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请重新扫描")
+	}
+
+	ls, err := a.loadingStore.GetSession(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到装车任务 %s", id), "")
+	}
+	if ls.IsClosed() {
+		return api.NewError(logistics.ErrLoadingSessionClosed, fmt.Sprintf("装车任务 %s 已结束", id), "请新建装车任务")
+	}
+
+	var sm *logistics.Shipment
+	qr, err := logistics.ParseUnitLoadQR(req.Code)
+	if err == nil && !ls.HasShipment(qr.Code) {
+		found, err := a.shipmentStore.GetShipmentByCode(qr.Code)
+		if err != nil {
+			c.Logger().Errorf("failed to get shipment %s of loading session %s: %v", qr.Code, id, err)
+		} else {
+			sm = &found
+		}
+	}
+
+	var scan logistics.LoadingScan
+	ls, err = a.loadingStore.UpdateSession(id, func(ls *logistics.LoadingSession) error {
+		if sm != nil {
+			ls.AddShipment(qr.Code, sm)
+		}
+		var err error
+		scan, err = ls.Scan(req.Code, time.Now())
+		return err
+	})
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("装车任务 %s 扫描失败", id), "请重新扫描")
+	}
+
+	ev := LoadingScanEvent{
+		SessionID: ls.ID,
+		Scan:      scan,
+		Report:    ls.Report(),
+	}
+	topics := []string{realtime.TopicLoading}
+	if scan.Code != "" {
+		topics = append(topics, realtime.ShipmentTopic(scan.Code))
+	}
+	a.events.Publish(EventLoadingScanned, ev, topics...)
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: ev,
+	})
+}
+
+// CloseLoadingSession closes the session and returns loading report.
+// If requested, shipments with all unit loads scanned are sent out
+func (a API) CloseLoadingSession(c echo.Context) error {
+	id := c.Param("id")
+
+	req := closeLoadingSessionRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	ls, err := a.loadingStore.UpdateSession(id, func(ls *logistics.LoadingSession) error {
+		return ls.Close(time.Now())
+	})
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("装车任务 %s 无法结束", id), "")
+	}
+
+	res := closeLoadingSessionResponse{
+		Report:  ls.Report(),
+		SentOut: []string{},
+		Failed:  []sendOutFailure{},
+	}
+
+	if req.SendOut {
+		for _, code := range ls.CompleteShipments() {
+			sm, err := a.shipmentStore.GetShipmentByCode(code)
+			if err == nil {
+				_, err = a.changeStatus(c, sm, logistics.SentOut)
+			}
+			if err != nil {
+				failure := sendOutFailure{Code: code, Message: fmt.Sprintf("票号 %s 状态更新失败", code)}
+				if apiErr, ok := err.(api.Error); ok {
+					failure.Message = apiErr.Message
+					failure.Hint = apiErr.Hint
+				}
+				res.Failed = append(res.Failed, failure)
+				continue
+			}
+			res.SentOut = append(res.SentOut, code)
+		}
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: res,
+	})
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/crm"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+const (
+	NoteSubjectShipment = "shipment"
+	NoteSubjectEntry    = "entry"
+)
+
+var errNoteOfAnotherSubject = errors.New("note belongs to another shipment or entry")
+
+// NoteEvent is sent to websocket clients when note is created, edited or deleted
+type NoteEvent struct {
+	// Subject is shipment or entry
+	Subject string `json:"subject"`
+	// Key is code of the shipment or id of the entry
+	Key  string   `json:"key"`
+	Note crm.Note `json:"note"`
+}
+
+// topics returns topics of the note's shipment or entry
+func (ev NoteEvent) topics() []string {
+	if ev.Subject == NoteSubjectShipment {
+		return []string{realtime.ShipmentTopic(ev.Key), realtime.TopicShipments}
+	}
+
+	return []string{realtime.EntryTopic(ev.Key), realtime.TopicEntries}
+}
+
+type noteRequest struct {
+	Content string `json:"content"`
+}
+
+// noteSubject returns id the notes are related to. Shipment's notes
+// are related by shipment id, entry's notes by entry id
+func (a API) noteSubject(c echo.Context) (NoteEvent, string, error) {
+	if code := c.Param("code"); code != "" {
+		sm, err := a.shipmentStore.GetShipmentByCode(code)
+		if err != nil {
+			return NoteEvent{}, "", api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+		}
+		return NoteEvent{Subject: NoteSubjectShipment, Key: sm.Code}, sm.ID, nil
+	}
+
+	e, err := a.entryStore.GetEntryById(c.Param("id"))
+	if err != nil {
+		return NoteEvent{}, "", err
+	}
+
+	return NoteEvent{Subject: NoteSubjectEntry, Key: e.ID}, e.ID, nil
+}
+
+// subjectNote returns note of the subject by note_id param
+func (a API) subjectNote(c echo.Context, relatedID string) (crm.Note, error) {
+	id := c.Param("note_id")
+	n, err := a.noteStore.GetNoteByID(id)
+	if err != nil {
+		return crm.Note{}, api.NewError(err, fmt.Sprintf("没有找到备注 %s", id), "")
+	}
+	if n.RelatedID != relatedID {
+		return crm.Note{}, api.NewError(errNoteOfAnotherSubject, fmt.Sprintf("备注 %s 不属于此票号或入库", id), "")
+	}
+
+	return n, nil
+}
+
+func (a API) GetNoteList(c echo.Context) error {
+	_, relatedID, err := a.noteSubject(c)
+	if err != nil {
+		return err
+	}
+
+	notes, err := a.noteStore.GetNoteList(relatedID)
+	if err != nil {
+		return api.NewError(err, "无法获取备注列表", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(notes), Total: len(notes)},
+		Data: notes,
+	})
+}
+
+// CreateNote adds note to the shipment or the entry,
+// author is taken from X-API-USER header
+func (a API) CreateNote(c echo.Context) error {
+	req := noteRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对备注内容")
+	}
+
+	ev, relatedID, err := a.noteSubject(c)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return err
+	}
+
+	n := crm.Note{
+		Content:   req.Content,
+		Author:    apiUser(c),
+		RelatedID: relatedID,
+	}
+	err = n.Validate()
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "备注内容有误", fmt.Sprintf("备注不能为空，不能超过 %d 个字", crm.MaxNoteLength))
+	}
+
+	ev.Note, err = a.noteStore.CreateNote(n)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "备注创建失败", "原因无知，请联系管理员")
+	}
+
+	a.events.Publish(EventNoteCreated, ev, ev.topics()...)
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: ev.Note,
+	})
+}
+
+func (a API) EditNote(c echo.Context) error {
+	req := noteRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对备注内容")
+	}
+
+	ev, relatedID, err := a.noteSubject(c)
+	if err != nil {
+		return err
+	}
+
+	n, err := a.subjectNote(c, relatedID)
+	if err != nil {
+		return err
+	}
+
+	n.Content = req.Content
+	n.UpdatedBy = apiUser(c)
+	err = n.Validate()
+	if err != nil {
+		return api.NewError(err, "备注内容有误", fmt.Sprintf("备注不能为空，不能超过 %d 个字", crm.MaxNoteLength))
+	}
+
+	ev.Note, err = a.noteStore.UpdateNote(n)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("更新备注 %s 失败", n.ID), "原因无知，请联系管理员")
+	}
+
+	a.events.Publish(EventNoteUpdated, ev, ev.topics()...)
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: ev.Note,
+	})
+}
+
+func (a API) DeleteNote(c echo.Context) error {
+	ev, relatedID, err := a.noteSubject(c)
+	if err != nil {
+		return err
+	}
+
+	ev.Note, err = a.subjectNote(c, relatedID)
+	if err != nil {
+		return err
+	}
+
+	err = a.noteStore.DeleteNote(ev.Note)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("删除备注 %s 失败", ev.Note.ID), "原因无知，请联系管理员")
+	}
+
+	a.events.Publish(EventNoteDeleted, ev, ev.topics()...)
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: ev.Note,
+	})
+}
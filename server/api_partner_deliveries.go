@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/integration"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/gorilla/schema"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+// deliveryPageSize is the default page size of delivery logs
+const deliveryPageSize = 50
+
+// deliveryPage reads page and per_page query params of delivery logs
+func deliveryPage(c echo.Context) (api.RequestMeta, error) {
+	meta := api.RequestMeta{}
+	d := schema.NewDecoder()
+	d.IgnoreUnknownKeys(true)
+	err := d.Decode(&meta, c.QueryParams())
+	if err != nil {
+		return meta, api.NewError(err, "请求有误", "建议您联系管理员")
+	}
+	if meta.PerPage < 1 {
+		meta.PerPage = deliveryPageSize
+	}
+	meta.Check()
+
+	return meta, nil
+}
+
+// GetPartnerDeliveryList returns the page of manifests pushed to partners,
+// shipment_code query param filters deliveries of one shipment
+func (a API) GetPartnerDeliveryList(c echo.Context) error {
+	meta, err := deliveryPage(c)
+	if err != nil {
+		return err
+	}
+	deliveries, total, err := a.partnerDeliveries.ListDeliveries(c.QueryParam("shipment_code"), meta.Page, meta.PerPage)
+	if err != nil {
+		return api.NewError(err, "无法获取合作方推送记录", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: meta.Page, Count: len(deliveries), Total: total},
+		Data: deliveries,
+	})
+}
+
+func (a API) GetPartnerDelivery(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.partnerDeliveries.GetDelivery(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到推送记录 %s", id), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: d,
+	})
+}
+
+// RedeliverPartnerDelivery pushes the same manifest to the partner again
+func (a API) RedeliverPartnerDelivery(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.partnerPusher.Redeliver(id)
+	if err != nil {
+		hint := ""
+		if errors.Cause(err) == delivery.ErrInProgress {
+			hint = "正在推送中，请稍后再试"
+		}
+		return api.NewError(err, fmt.Sprintf("推送记录 %s 无法重新推送", id), hint)
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: d,
+	})
+}
+
+// pushPartnerManifest sends manifest of the sent out shipment
+// to the partner if partner has integration enabled
+func (a API) pushPartnerManifest(c echo.Context, sm logistics.Shipment) {
+	_, err := a.partnerPusher.PushShipment(sm, time.Now())
+	if err != nil && errors.Cause(err) != integration.ErrNoIntegration {
+		c.Logger().Errorf("failed to push manifest of shipment %s: %v", sm.Code, err)
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+// partnerResponse is the partner without integration secret
+type partnerResponse struct {
+	logistics.Partner
+	Integration partnerIntegrationResponse `json:"integration"`
+}
+
+type partnerIntegrationResponse struct {
+	Enabled   bool   `json:"enabled"`
+	Endpoint  string `json:"endpoint"`
+	HasSecret bool   `json:"has_secret"`
+}
+
+func newPartnerResponse(p logistics.Partner) partnerResponse {
+	return partnerResponse{
+		Partner: p,
+		Integration: partnerIntegrationResponse{
+			Enabled:   p.Integration.Enabled,
+			Endpoint:  p.Integration.Endpoint,
+			HasSecret: p.Integration.Secret != "",
+		},
+	}
+}
+
+func (a API) GetPartnerList(c echo.Context) error {
+	partners, err := a.partnerStore.ListPartners()
+	if err != nil {
+		return api.NewError(err, "无法获取合作方列表", "原因无知，请联系管理员")
+	}
+
+	res := make([]partnerResponse, 0, len(partners))
+	for _, p := range partners {
+		res = append(res, newPartnerResponse(p))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(res), Total: len(res)},
+		Data: res,
+	})
+}
+
+func (a API) GetPartner(c echo.Context) error {
+	code := c.Param("code")
+	p, err := a.partnerStore.GetPartner(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到合作方 %s", code), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newPartnerResponse(p),
+	})
+}
+
+func (a API) CreatePartner(c echo.Context) error {
+	p := logistics.Partner{}
+	err := c.Bind(&p)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对合作方信息")
+	}
+
+	p, err = a.partnerStore.CreatePartner(p)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "合作方创建失败", partnerHint(err))
+	}
+	err = a.reloadPartners()
+	if err != nil {
+		return api.NewError(err, "合作方列表更新失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newPartnerResponse(p),
+	})
+}
+
+// UpdatePartner replaces all partner settings, code can not be changed.
+// Integration secret is kept if it's omitted
+func (a API) UpdatePartner(c echo.Context) error {
+	p := logistics.Partner{}
+	err := c.Bind(&p)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对合作方信息")
+	}
+	p.Code = c.Param("code")
+
+	p, err = a.partnerStore.UpdatePartner(p)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("合作方 %s 修改失败", c.Param("code")), partnerHint(err))
+	}
+	err = a.reloadPartners()
+	if err != nil {
+		return api.NewError(err, "合作方列表更新失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newPartnerResponse(p),
+	})
+}
+
+func (a API) DeletePartner(c echo.Context) error {
+	code := c.Param("code")
+	err := a.partnerStore.DeletePartner(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("合作方 %s 删除失败", code), partnerHint(err))
+	}
+	err = a.reloadPartners()
+	if err != nil {
+		return api.NewError(err, "合作方列表更新失败", "原因无知，请联系管理员")
+	}
+
+	return c.String(http.StatusOK, "done")
+}
+
+// reloadPartners sets partners registry shipments are validated with
+func (a API) reloadPartners() error {
+	partners, err := a.partnerStore.ListPartners()
+	if err != nil {
+		return err
+	}
+	a.partners.Set(partners)
+
+	return nil
+}
+
+func partnerHint(err error) string {
+	switch errors.Cause(err) {
+	case logistics.ErrPartnerNotFound:
+		return "没有找到此合作方，建议您刷新页面再试试"
+	case logistics.ErrPartnerExists:
+		return "此合作方代码已存在"
+	case logistics.ErrPartnerEmptyCode:
+		return "请填写合作方代码"
+	case logistics.ErrPartnerEmptyName:
+		return "请填写合作方名称"
+	case logistics.ErrPartnerNoDeliveryMethods:
+		return "请选择合作方支持的运输方式"
+	case logistics.ErrUnknownDeliveryMethod:
+		var methods []string
+		for _, dm := range logistics.DeliveryMethods() {
+			methods = append(methods, string(dm))
+		}
+		return fmt.Sprintf("运输方式只能是: %s", strings.Join(methods, ", "))
+	case logistics.ErrUnknownManifestFormat:
+		return fmt.Sprintf("清单格式只能是 %s、%s 或 %s", logistics.ManifestCSV, logistics.ManifestXML, logistics.ManifestJSON)
+	}
+
+	return "原因无知，请联系管理员"
+}
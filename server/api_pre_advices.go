@@ -0,0 +1,259 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/boltdb"
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	fm "github.com/amanbolat/gofmcon"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (a API) GetPreAdviceList(c echo.Context) error {
+	customerCode := c.QueryParam("customer_code")
+	status := c.QueryParam("status")
+
+	parcels, err := a.preAdviceStore.ListParcels(customerCode)
+	if err != nil {
+		return api.NewError(err, "无法获取预报列表", "原因无知，请联系管理员")
+	}
+
+	var filtered []warehouse.ExpectedParcel
+	for _, p := range parcels {
+		switch status {
+		case "arrived":
+			if !p.IsArrived() {
+				continue
+			}
+		case "expected":
+			if p.IsArrived() {
+				continue
+			}
+		}
+		filtered = append(filtered, p)
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{
+			Page:  1,
+			Count: len(filtered),
+			Total: len(filtered),
+		},
+		Data: filtered,
+	})
+}
+
+func (a API) GetPreAdviceByTrackCode(c echo.Context) error {
+	p, err := a.findPreAdvice(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: p,
+	})
+}
+
+// findPreAdvice returns parcel by track_code param. Customer is set by
+// customer_code query param, it's required only if several customers
+// expect parcels with the same track code
+func (a API) findPreAdvice(c echo.Context) (warehouse.ExpectedParcel, error) {
+	trackCode := c.Param("track_code")
+
+	parcels, err := a.preAdviceStore.FindParcels(c.QueryParam("customer_code"), trackCode)
+	if err != nil {
+		return warehouse.ExpectedParcel{}, api.NewError(err, fmt.Sprintf("无法获取 %s 快递单的预报", trackCode), "原因无知，请联系管理员")
+	}
+	switch len(parcels) {
+	case 0:
+		return warehouse.ExpectedParcel{}, api.NewError(boltdb.ErrNotFound, fmt.Sprintf("没有找到 %s 快递单的预报", trackCode), "")
+	case 1:
+		return parcels[0], nil
+	}
+
+	return warehouse.ExpectedParcel{}, api.NewError(nil, fmt.Sprintf("%d 个客户预报了 %s 快递单", len(parcels), trackCode), "请指定客户号 customer_code")
+}
+
+func (a API) CreatePreAdvices(c echo.Context) error {
+	var parcels []warehouse.ExpectedParcel
+	err := c.Bind(&parcels)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "有可能预报数据有误。建议您联系管理员")
+	}
+
+	saved, err := a.preAdviceStore.SaveParcels(parcels...)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "预报创建失败", "请核对快递单号和客户号")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{
+			Page:  1,
+			Count: len(saved),
+			Total: len(saved),
+		},
+		Data: saved,
+	})
+}
+
+// ImportPreAdvices imports CSV file with expected parcels of the customer.
+// See warehouse.ParseExpectedParcelsCSV for the format
+func (a API) ImportPreAdvices(c echo.Context) error {
+	customerCode := strings.TrimSpace(c.QueryParam("customer_code"))
+	if customerCode == "" {
+		a.removeApiRequestId(c)
+		return api.NewError(nil, "客户号不能为空", "")
+	}
+
+	parcels, err := warehouse.ParseExpectedParcelsCSV(c.Request().Body, customerCode)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, fmt.Sprintf("预报文件有误: %v", err), "文件列: 快递单号, 品名, 数量, 箱数, 备注")
+	}
+
+	saved, err := a.preAdviceStore.SaveParcels(parcels...)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "预报导入失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{
+			Page:  1,
+			Count: len(saved),
+			Total: len(saved),
+		},
+		Data: saved,
+	})
+}
+
+func (a API) DeletePreAdvice(c echo.Context) error {
+	p, err := a.findPreAdvice(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.preAdviceStore.DeleteParcel(p.CustomerCode, p.TrackCode)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法删除 %s 快递单的预报", p.TrackCode), "")
+	}
+
+	return c.String(http.StatusOK, "done")
+}
+
+// GetPreAdviceReport compares expected parcels with the entries received
+// in the given period and returns not arrived and not expected parcels.
+// Parcels are not updated, see MatchPreAdvices
+func (a API) GetPreAdviceReport(c echo.Context) error {
+	customerCode := c.QueryParam("customer_code")
+	entries, err := a.preAdviceEntries(c, customerCode)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.preAdviceStore.Report(customerCode, entries...)
+	if err != nil {
+		return api.NewError(err, "无法生成预报报告", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: filterNotArrived(c, report),
+	})
+}
+
+// MatchPreAdvices links expected parcels with the entries received
+// in the given period and returns the report
+func (a API) MatchPreAdvices(c echo.Context) error {
+	customerCode := c.QueryParam("customer_code")
+	entries, err := a.preAdviceEntries(c, customerCode)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.preAdviceStore.MatchEntries(customerCode, entries...)
+	if err != nil {
+		return api.NewError(err, "预报匹配失败", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: filterNotArrived(c, report),
+	})
+}
+
+// preAdviceEntries returns entries received between from and to
+// query params, for the last 30 days by default
+func (a API) preAdviceEntries(c echo.Context, customerCode string) ([]warehouse.Entry, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, api.NewError(err, "请求有误", "日期格式: 2006-01-02")
+		}
+		from = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, api.NewError(err, "请求有误", "日期格式: 2006-01-02")
+		}
+		to = t
+	}
+
+	meta := api.RequestMeta{
+		PerPage: -1,
+		InternalFilter: map[string]string{
+			"Warehouse":              "=" + a.warehouse,
+			"Date_Created_Timestamp": fmt.Sprintf("%s...%s", from.Format(fm.DATE_FORMAT), to.Format(fm.DATE_FORMAT)),
+		},
+	}
+	if customerCode != "" {
+		meta.InternalFilter["CustomerCode"] = "=" + customerCode
+	}
+
+	entries, _, err := a.entryStore.GetEntryList(meta)
+
+	return entries, err
+}
+
+// filterNotArrived keeps only parcels expected longer
+// than older_than_days query param
+func filterNotArrived(c echo.Context, report warehouse.PreAdviceReport) warehouse.PreAdviceReport {
+	days, _ := strconv.Atoi(c.QueryParam("older_than_days"))
+	if days <= 0 {
+		return report
+	}
+
+	deadline := time.Now().AddDate(0, 0, -days)
+	notArrived := []warehouse.ExpectedParcel{}
+	for _, p := range report.NotArrived {
+		if p.CreatedAt.Before(deadline) {
+			notArrived = append(notArrived, p)
+		}
+	}
+	report.NotArrived = notArrived
+
+	return report
+}
+
+// matchPreAdvice links newly created entry with the expected parcel
+func (a API) matchPreAdvice(c echo.Context, e warehouse.Entry) {
+	if strings.TrimSpace(e.TrackCode) == "" {
+		return
+	}
+
+	_, err := a.preAdviceStore.MatchEntries("", e)
+	if err != nil {
+		c.Logger().Errorf("failed to match entry %s with pre-advice: %v", e.ID, err)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+)
+
+func (a API) GetTariffList(c echo.Context) error {
+	tariffs, err := a.tariffStore.ListTariffs()
+	if err != nil {
+		return api.NewError(err, "无法获取运价列表", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{
+			Page:  1,
+			Count: len(tariffs),
+			Total: len(tariffs),
+		},
+		Data: tariffs,
+	})
+}
+
+// ImportTariffs replaces all the tariffs with tariffs from CSV file.
+// See pricing.TariffCSVHeader for the format
+func (a API) ImportTariffs(c echo.Context) error {
+	tariffs, err := pricing.ParseTariffsCSV(c.Request().Body, a.transferPoints)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("运价文件有误: %v", err), fmt.Sprintf("文件列: %s", strings.Join(pricing.TariffCSVHeader, ", ")))
+	}
+
+	err = a.tariffStore.ReplaceTariffs(tariffs)
+	if err != nil {
+		return api.NewError(err, "运价导入失败", "原因无知，请联系管理员")
+	}
+
+	return a.GetTariffList(c)
+}
+
+type quoteRequest struct {
+	ShipmentCode   string                   `json:"shipment_code"`
+	TransferPoint  string                   `json:"transfer_point"`
+	DeliveryMethod logistics.DeliveryMethod `json:"delivery_method"`
+	Weight         decimal.Decimal          `json:"weight"`
+	Cubage         decimal.Decimal          `json:"cubage"`
+	CargoValue     decimal.Decimal          `json:"cargo_value"`
+}
+
+// CreateQuote prices either the given cargo or the existing shipment.
+// Transfer point, delivery method and cargo value of the request
+// override the shipment's ones
+func (a API) CreateQuote(c echo.Context) error {
+	req := quoteRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	cargo := pricing.Cargo{
+		TransferPoint:  req.TransferPoint,
+		DeliveryMethod: req.DeliveryMethod,
+		Weight:         req.Weight,
+		Cubage:         req.Cubage,
+		CargoValue:     req.CargoValue,
+	}
+	cargo.ChargeableWeight = a.volumetricRules.ChargeableWeight(cargo)
+
+	if req.ShipmentCode != "" {
+		sm, err := a.shipmentStore.GetShipmentByCode(req.ShipmentCode)
+		if err != nil {
+			return api.NewError(err, fmt.Sprintf("没有找到票号 %s", req.ShipmentCode), "")
+		}
+		// volumetric divisor depends on the delivery method
+		if req.DeliveryMethod != "" {
+			sm.PartnerInfo.DeliveryMethod = req.DeliveryMethod
+		}
+		cargo = pricing.CargoFromShipment(sm, a.volumetricRules)
+		if req.TransferPoint != "" {
+			cargo.TransferPoint = req.TransferPoint
+		}
+		if !req.CargoValue.IsZero() {
+			cargo.CargoValue = req.CargoValue
+		}
+	}
+
+	tariff, err := a.tariffStore.GetTariff(cargo.TransferPoint, cargo.DeliveryMethod)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到 %s %s 的运价", cargo.TransferPoint, cargo.DeliveryMethod), "请先导入运价")
+	}
+
+	quote, err := tariff.Quote(cargo)
+	if err != nil {
+		return api.NewError(err, "无法计算运费", quoteHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: quote,
+	})
+}
+
+func quoteHint(err error) string {
+	switch errors.Cause(err) {
+	case pricing.ErrEmptyCargo:
+		return "重量和体积必须大于零"
+	case pricing.ErrNoBracket:
+		return "货物密度不在运价范围内"
+	}
+
+	return "原因无知，请联系管理员"
+}
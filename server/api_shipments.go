@@ -0,0 +1,251 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type changeStatusRequest struct {
+	Status logistics.ShipmentStatus `json:"status"`
+}
+
+// ChangeShipmentStatus moves shipment to the next status
+// if all the conditions of the status are met
+func (a API) ChangeShipmentStatus(c echo.Context) error {
+	code := c.Param("code")
+
+	req := changeStatusRequest{Status: logistics.InvalidStatus}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+	}
+
+	updated, err := a.changeStatus(c, sm, req.Status)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: updated,
+	})
+}
+
+// changeStatus saves new status of the shipment, records
+// it in the status history and notifies websocket clients
+func (a API) changeStatus(c echo.Context, sm logistics.Shipment, sts logistics.ShipmentStatus) (logistics.Shipment, error) {
+	previous := sm.CurrentStatusKey
+	err := sm.ChangeStatus(sts)
+	if err != nil {
+		return logistics.Shipment{}, api.NewError(err, fmt.Sprintf("票号 %s 无法改为 %s 状态", sm.Code, sts), statusChangeHint(sm, err))
+	}
+
+	updated, err := a.shipmentStore.UpdateShipmentStatus(sm)
+	if err != nil {
+		return logistics.Shipment{}, api.NewError(err, fmt.Sprintf("票号 %s 状态更新失败", sm.Code), "原因无知，请联系管理员")
+	}
+
+	err = a.statusHistoryStore.AddChange(logistics.StatusChange{
+		Code:      updated.Code,
+		From:      previous,
+		To:        updated.CurrentStatusKey,
+		ChangedAt: time.Now(),
+		Actor:     apiUser(c),
+		Source:    logistics.StatusSourceAPI,
+	})
+	if err != nil {
+		c.Logger().Errorf("failed to record status change of shipment %s: %v", sm.Code, err)
+	}
+
+	change := map[string]interface{}{
+		"code":            updated.Code,
+		"previous_status": previous,
+		"current_status":  updated.CurrentStatusKey,
+		"shipment":        updated,
+	}
+	a.events.Publish(EventShipmentStatusChanged, change, realtime.ShipmentTopic(updated.Code), realtime.TopicShipments)
+	a.events.Notify(EventShipmentStatusChanged, change)
+
+	if updated.CurrentStatusKey == logistics.SentOut {
+		a.pushPartnerManifest(c, updated)
+	}
+
+	return updated, nil
+}
+
+func statusChangeHint(sm logistics.Shipment, err error) string {
+	switch errors.Cause(err) {
+	case logistics.ErrInvalidStatusTransition:
+		return fmt.Sprintf("当前状态为 %s，只能改为 %s", sm.CurrentStatusKey, sm.CurrentStatusKey.NextValid())
+	case logistics.ErrNoUnitLoads:
+		return "此票货物没有包装信息，请先录入每包信息"
+	case logistics.ErrUnitLoadWithoutWeight:
+		return "有的包裹没有重量，请先录入每包重量"
+	case logistics.ErrIncompleteCustomsData:
+		return logistics.ValidateShipment(sm, logistics.ValidateCustoms).ErrorMessages()
+	}
+
+	return "原因无知，请联系管理员"
+}
+
+func (a API) AddUnitLoad(c echo.Context) error {
+	code := c.Param("code")
+
+	ul := &logistics.UnitLoad{}
+	err := c.Bind(ul)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+	err = a.checkHSCode(ul.Customs.HSCode)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return err
+	}
+
+	return a.changeUnitLoads(c, code, func(sm *logistics.Shipment) ([]*logistics.UnitLoad, error) {
+		return nil, sm.AddUnitLoad(ul)
+	})
+}
+
+func (a API) EditUnitLoad(c echo.Context) error {
+	code := c.Param("code")
+	sequence, err := strconv.Atoi(c.Param("sequence"))
+	if err != nil {
+		return api.NewError(err, "请求有误", "包裹序号有误")
+	}
+
+	changes := logistics.UnitLoad{}
+	err = c.Bind(&changes)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+	err = a.checkHSCode(changes.Customs.HSCode)
+	if err != nil {
+		return err
+	}
+
+	return a.changeUnitLoads(c, code, func(sm *logistics.Shipment) ([]*logistics.UnitLoad, error) {
+		return nil, sm.EditUnitLoad(sequence, changes)
+	})
+}
+
+func (a API) DeleteUnitLoad(c echo.Context) error {
+	code := c.Param("code")
+	sequence, err := strconv.Atoi(c.Param("sequence"))
+	if err != nil {
+		return api.NewError(err, "请求有误", "包裹序号有误")
+	}
+
+	return a.changeUnitLoads(c, code, func(sm *logistics.Shipment) ([]*logistics.UnitLoad, error) {
+		removed, err := sm.RemoveUnitLoad(sequence)
+		if err != nil {
+			return nil, err
+		}
+		return []*logistics.UnitLoad{removed}, nil
+	})
+}
+
+type reorderUnitLoadsRequest struct {
+	Sequences []int `json:"sequences"`
+}
+
+// ReorderUnitLoads accepts current sequence numbers of unit loads
+// in the new order
+func (a API) ReorderUnitLoads(c echo.Context) error {
+	code := c.Param("code")
+
+	req := reorderUnitLoadsRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对信息或者联系管理员")
+	}
+
+	return a.changeUnitLoads(c, code, func(sm *logistics.Shipment) ([]*logistics.UnitLoad, error) {
+		return nil, sm.ReorderUnitLoads(req.Sequences)
+	})
+}
+
+// changeUnitLoads applies change to the unit loads of the shipment
+// and saves them into FileMaker
+func (a API) changeUnitLoads(c echo.Context, code string, change func(sm *logistics.Shipment) ([]*logistics.UnitLoad, error)) error {
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+	}
+
+	removed, err := change(&sm)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, fmt.Sprintf("无法修改票号 %s 的包裹信息", code), unitLoadChangeHint(sm, err))
+	}
+
+	updated, err := a.shipmentStore.SaveUnitLoads(sm, removed...)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, fmt.Sprintf("票号 %s 的包裹信息保存失败", code), "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: updated,
+	})
+}
+
+func unitLoadChangeHint(sm logistics.Shipment, err error) string {
+	if errors.Cause(err) == logistics.ErrUnitLoadNotFound {
+		return "没有找到此序号的包裹，建议您刷新页面再试试"
+	}
+	if hint := customsHint(err); hint != "" {
+		return hint
+	}
+	if sm.CurrentStatusKey != logistics.Preparation {
+		return fmt.Sprintf("只能修改 %s 状态票号的包裹信息", logistics.Preparation)
+	}
+
+	return "请核对数量、重量和尺寸"
+}
+
+// GetShipmentValidation checks if shipment is ready to be dispatched
+func (a API) GetShipmentValidation(c echo.Context) error {
+	code := c.Param("code")
+
+	sm, err := a.shipmentStore.GetShipmentByCode(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到票号 %s", code), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: logistics.ValidateShipment(sm, append(a.partners.ValidationRules(), logistics.ValidateCustoms)...),
+	})
+}
+
+// GetShipmentTimeline returns status history of the shipment
+// and how long it stayed in every status
+func (a API) GetShipmentTimeline(c echo.Context) error {
+	code := c.Param("code")
+
+	changes, err := a.statusHistoryStore.ListChanges(code)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("无法获取票号 %s 的状态记录", code), "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: logistics.BuildTimeline(code, changes, time.Now()),
+	})
+}
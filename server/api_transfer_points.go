@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func (a API) GetTransferPointList(c echo.Context) error {
+	tps := a.transferPoints.List()
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(tps), Total: len(tps)},
+		Data: tps,
+	})
+}
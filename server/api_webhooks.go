@@ -0,0 +1,174 @@
+package server
+
+import (
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// subscriptionResponse is the subscription without secret
+type subscriptionResponse struct {
+	webhook.Subscription
+	HasSecret bool `json:"has_secret"`
+}
+
+func newSubscriptionResponse(sub webhook.Subscription) subscriptionResponse {
+	res := subscriptionResponse{Subscription: sub, HasSecret: sub.Secret != ""}
+	res.Secret = ""
+
+	return res
+}
+
+func (a API) GetWebhookList(c echo.Context) error {
+	subscriptions, err := a.webhookStore.ListSubscriptions()
+	if err != nil {
+		return api.NewError(err, "无法获取Webhook列表", "原因无知，请联系管理员")
+	}
+
+	res := make([]subscriptionResponse, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		res = append(res, newSubscriptionResponse(sub))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: 1, Count: len(res), Total: len(res)},
+		Data: res,
+	})
+}
+
+func (a API) GetWebhook(c echo.Context) error {
+	id := c.Param("id")
+	sub, err := a.webhookStore.GetSubscription(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到Webhook %s", id), webhookHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newSubscriptionResponse(sub),
+	})
+}
+
+func (a API) CreateWebhook(c echo.Context) error {
+	sub := webhook.Subscription{}
+	err := c.Bind(&sub)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "请求有误", "请核对Webhook信息")
+	}
+
+	sub, err = a.webhookStore.CreateSubscription(sub)
+	if err != nil {
+		a.removeApiRequestId(c)
+		return api.NewError(err, "Webhook创建失败", webhookHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newSubscriptionResponse(sub),
+	})
+}
+
+// UpdateWebhook replaces all subscription settings, omitted secret is
+// kept. Pending deliveries of the disabled subscription are stopped
+func (a API) UpdateWebhook(c echo.Context) error {
+	sub := webhook.Subscription{}
+	err := c.Bind(&sub)
+	if err != nil {
+		return api.NewError(err, "请求有误", "请核对Webhook信息")
+	}
+	sub.ID = c.Param("id")
+
+	sub, err = a.webhookStore.UpdateSubscription(sub)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("Webhook %s 修改失败", c.Param("id")), webhookHint(err))
+	}
+	if !sub.Enabled {
+		a.webhooks.Stop(sub.ID)
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: newSubscriptionResponse(sub),
+	})
+}
+
+// DeleteWebhook removes subscription and stops its pending deliveries
+func (a API) DeleteWebhook(c echo.Context) error {
+	id := c.Param("id")
+	err := a.webhookStore.DeleteSubscription(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("Webhook %s 删除失败", id), webhookHint(err))
+	}
+	a.webhooks.Stop(id)
+
+	return c.String(http.StatusOK, "done")
+}
+
+// GetWebhookDeliveryList returns the page of the delivered events,
+// webhook_id query param filters deliveries of one subscription
+func (a API) GetWebhookDeliveryList(c echo.Context) error {
+	meta, err := deliveryPage(c)
+	if err != nil {
+		return err
+	}
+	deliveries, total, err := a.webhookStore.ListDeliveries(c.QueryParam("webhook_id"), meta.Page, meta.PerPage)
+	if err != nil {
+		return api.NewError(err, "无法获取Webhook推送记录", "原因无知，请联系管理员")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: api.ResponseMeta{Page: meta.Page, Count: len(deliveries), Total: total},
+		Data: deliveries,
+	})
+}
+
+func (a API) GetWebhookDelivery(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.webhookStore.GetDelivery(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("没有找到Webhook推送记录 %s", id), "")
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: d,
+	})
+}
+
+// RedeliverWebhookDelivery sends the same event to the subscription again
+func (a API) RedeliverWebhookDelivery(c echo.Context) error {
+	id := c.Param("id")
+	d, err := a.webhooks.Redeliver(id)
+	if err != nil {
+		return api.NewError(err, fmt.Sprintf("Webhook推送记录 %s 无法重新推送", id), webhookHint(err))
+	}
+
+	return c.JSON(http.StatusOK, JSONResponse{
+		Meta: singleRecordMeta,
+		Data: d,
+	})
+}
+
+func webhookHint(err error) string {
+	switch errors.Cause(err) {
+	case webhook.ErrSubscriptionNotFound:
+		return "没有找到此Webhook，建议您刷新页面再试试"
+	case webhook.ErrInvalidURL:
+		return "URL必须是以 http:// 或 https:// 开头的完整地址"
+	case webhook.ErrNoEvents:
+		return "请选择要推送的事件"
+	case webhook.ErrInvalidEvent:
+		return "可订阅的事件为 entry.created、shipment.status_changed、print_job.failed 或 *"
+	case webhook.ErrEmptySecret:
+		return "请填写签名密钥"
+	case delivery.ErrInProgress:
+		return "正在推送中，请稍后再试"
+	}
+
+	return "原因无知，请联系管理员"
+}
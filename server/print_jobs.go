@@ -0,0 +1,44 @@
+package server
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/printing"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"time"
+)
+
+const (
+	PrintJobEntryBarcode       = "entry_barcode"
+	PrintJobUnitLoadLabels     = "unit_load_labels"
+	PrintJobPreparationInfo    = "preparation_info"
+	PrintJobPartnerInfo        = "partner_info"
+	PrintJobConsolidationLabel = "consolidation_label"
+)
+
+// PrintJob is sent to clients subscribed to print jobs
+// when the file is sent to the printer
+type PrintJob struct {
+	Kind string `json:"kind"`
+	// Reference is code of the shipment or id of the entry
+	Reference string    `json:"reference"`
+	Copies    int       `json:"copies"`
+	Error     string    `json:"error,omitempty"`
+	PrintedAt time.Time `json:"printed_at"`
+}
+
+// printFiles prints the files and publishes print job event,
+// webhooks are notified about failed ones
+func printFiles(printer printing.Printer, events eventPublisher, job PrintJob, copies int, paths ...string) error {
+	err := printer.PrintFiles(copies, "", paths...)
+
+	job.Copies = copies
+	job.PrintedAt = time.Now()
+	if err != nil {
+		job.Error = err.Error()
+		events.Publish(EventPrintJobFailed, job, realtime.TopicPrintJobs)
+		events.Notify(EventPrintJobFailed, job)
+		return err
+	}
+	events.Publish(EventPrintJobPrinted, job, realtime.TopicPrintJobs)
+
+	return nil
+}
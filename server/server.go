@@ -4,44 +4,64 @@ import (
 	"context"
 	"fmt"
 	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/boltdb"
 	"github.com/amanbolat/ca-warehouse-client/config"
+	"github.com/amanbolat/ca-warehouse-client/documents"
 	"github.com/amanbolat/ca-warehouse-client/filemaker"
+	"github.com/amanbolat/ca-warehouse-client/integration"
 	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/pricing"
 	"github.com/amanbolat/ca-warehouse-client/printing"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
 	"github.com/amanbolat/gofmcon"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
-	"gopkg.in/olahol/melody.v1"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"time"
 )
 
 type Server struct {
-	router            *echo.Echo
-	memCache          *cache.Cache
-	logger            *logrus.Logger
-	wsServer          *melody.Melody
-	wsSessions        *sync.Map
-	shipmentStore     *filemaker.ShipmentStore
-	boltDB            *bolt.DB
-	printer           *printing.Printer
-	labelManger       *printing.LabelManager
-	shipmentsForPrint chan []logistics.Shipment
+	router             *echo.Echo
+	memCache           *cache.Cache
+	logger             *logrus.Logger
+	wsHub              *realtime.Hub
+	shipments          *shipmentSnapshot
+	shipmentStore      *filemaker.ShipmentStore
+	entryStore         *filemaker.EntryStore
+	webhooks           *webhook.Dispatcher
+	warehouse          string
+	boltDB             *bolt.DB
+	printer            *printing.Printer
+	labelManger        *printing.LabelManager
+	shipmentsForPrint  chan []logistics.Shipment
+	statusHistoryStore *boltdb.StatusHistoryStore
 }
 
 func NewServer(config config.Config, logger *logrus.Logger) (*Server, error) {
+	volumetricRules, err := pricing.NewVolumetricRules(config.VolumetricConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	conn := gofmcon.NewFMConnector(config.FmHost, "", config.FmUser, config.FmPass)
+	transferPoints, err := loadTransferPoints(config.TransferPointsConfig, conn, config.FmDatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
 	entryStore := filemaker.NewEntryStore(conn, config.FmDatabaseName)
 	entryStore.FMConn().SetDebug(config.Debug)
-	shipmentStore := filemaker.NewShipmentStore(conn, config.FmDatabaseName)
+	shipmentStore := filemaker.NewShipmentStore(conn, config.FmDatabaseName, transferPoints, volumetricRules)
 	customerStore := filemaker.NewCustomerStore(conn, config.FmDatabaseName)
+	noteStore := filemaker.NewNoteStore(conn, config.FmDatabaseName)
 	boltDB, err := bolt.Open(config.BoltDbPath, 0600, nil)
 	if err != nil {
 		return nil, err
@@ -58,21 +78,106 @@ func NewServer(config config.Config, logger *logrus.Logger) (*Server, error) {
 		return nil, err
 	}
 
+	sourceCache, err := boltdb.NewSourceCache(boltDB, config.KdnCacheTtl)
+	if err != nil {
+		return nil, err
+	}
+	err = sourceCache.DeleteExpired()
+	if err != nil {
+		logger.Errorf("failed to delete expired track sources: %v", err)
+	}
+
+	preAdviceStore, err := boltdb.NewPreAdviceStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	tariffStore, err := boltdb.NewTariffStore(boltDB, transferPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	statusHistoryStore, err := boltdb.NewStatusHistoryStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	loadingStore, err := boltdb.NewLoadingStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchStore, err := boltdb.NewDispatchStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	hsCodeStore, err := boltdb.NewHSCodeStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
+	partnerStore, err := boltdb.NewPartnerStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+	partnerList, err := partnerStore.ListPartners()
+	if err != nil {
+		return nil, err
+	}
+	partners := logistics.NewPartnerRegistry(partnerList)
+
+	partnerDeliveries, err := boltdb.NewPartnerDeliveryStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+	partnerPusher := integration.NewPusher(config.PushConfig, partners, partnerDeliveries, logger)
+	pending, err := partnerDeliveries.ListPendingDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	// deliveries interrupted by restart are continued
+	for _, d := range pending {
+		go partnerPusher.Deliver(d)
+	}
+
+	webhookStore, err := boltdb.NewWebhookStore(boltDB)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := webhook.NewDispatcher(config.DispatchConfig, webhookStore, webhookStore, logger)
+	pendingWebhooks, err := webhookStore.ListPendingDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range pendingWebhooks {
+		go webhooks.Deliver(d)
+	}
+
 	lm, err := printing.NewLabelManger(config.FontPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	documentGenerator, err := documents.NewGenerator(config.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
 	s := Server{
-		memCache:          cache.New(time.Hour*24, time.Hour*30),
-		logger:            logger,
-		wsServer:          melody.New(),
-		wsSessions:        &sync.Map{},
-		shipmentStore:     shipmentStore,
-		boltDB:            boltDB,
-		printer:           &printing.Printer{Name: config.Printer},
-		labelManger:       &lm,
-		shipmentsForPrint: make(chan []logistics.Shipment, 20),
+		memCache:           cache.New(time.Hour*24, time.Hour*30),
+		logger:             logger,
+		wsHub:              realtime.NewHub(wsHeartbeat, logger),
+		shipments:          &shipmentSnapshot{},
+		shipmentStore:      shipmentStore,
+		entryStore:         entryStore,
+		webhooks:           webhooks,
+		warehouse:          config.Warehouse,
+		boltDB:             boltDB,
+		printer:            &printing.Printer{Name: config.Printer},
+		labelManger:        &lm,
+		shipmentsForPrint:  make(chan []logistics.Shipment, 20),
+		statusHistoryStore: statusHistoryStore,
 	}
 
 	e := echo.New()
@@ -81,14 +186,33 @@ func NewServer(config config.Config, logger *logrus.Logger) (*Server, error) {
 	}
 
 	var a = API{
-		entryStore:       entryStore,
-		shipmentStore:    shipmentStore,
-		customerStore:    customerStore,
-		memCache:         cache.New(time.Minute*5, time.Minute*7),
-		kdniaoApi:        api.NewKDNiaoApi(config.KDNiaoConfig),
-		printer:          printing.Printer{Name: config.Printer},
-		labelManager:     lm,
-		apiRequestsCache: s.memCache,
+		entryStore:         entryStore,
+		shipmentStore:      shipmentStore,
+		customerStore:      customerStore,
+		memCache:           cache.New(time.Minute*5, time.Minute*7),
+		kdniaoApi:          api.NewKDNiaoApi(config.KDNiaoConfig, sourceCache),
+		printer:            printing.Printer{Name: config.Printer},
+		labelManager:       lm,
+		apiRequestsCache:   s.memCache,
+		preAdviceStore:     preAdviceStore,
+		events:             &s,
+		tariffStore:        tariffStore,
+		volumetricRules:    volumetricRules,
+		statusHistoryStore: statusHistoryStore,
+		noteStore:          noteStore,
+		loadingStore:       loadingStore,
+		dispatchStore:      dispatchStore,
+		documentGenerator:  documentGenerator,
+		documentsConfig:    config.Config,
+		hsCodeStore:        hsCodeStore,
+		partnerStore:       partnerStore,
+		partners:           partners,
+		transferPoints:     transferPoints,
+		partnerDeliveries:  partnerDeliveries,
+		partnerPusher:      partnerPusher,
+		webhookStore:       webhookStore,
+		webhooks:           webhooks,
+		warehouse:          config.Warehouse,
 	}
 
 	e.HTTPErrorHandler = func(err error, c echo.Context) {
@@ -122,17 +246,81 @@ func NewServer(config config.Config, logger *logrus.Logger) (*Server, error) {
 	g.POST("/entries/:id/print_barcode", a.PrintEntryBarcode)
 	g.POST("/entries", s.duplicatePreventMiddleware(a.CreateEntry))
 	g.PATCH("/entries", a.EditEntry)
+	g.GET("/entries/:id/notes", a.GetNoteList)
+	g.POST("/entries/:id/notes", s.duplicatePreventMiddleware(a.CreateNote))
+	g.PATCH("/entries/:id/notes/:note_id", a.EditNote)
+	g.DELETE("/entries/:id/notes/:note_id", a.DeleteNote)
 	g.GET("/shipments", a.GetShipmentList)
 	g.GET("/shipments/:code", a.GetShipmentSingle)
+	g.GET("/shipments/:code/validation", a.GetShipmentValidation)
+	g.GET("/shipments/:code/timeline", a.GetShipmentTimeline)
+	g.POST("/shipments/:code/status", a.ChangeShipmentStatus)
+	g.POST("/shipments/:code/unit_loads", s.duplicatePreventMiddleware(a.AddUnitLoad))
+	g.POST("/shipments/:code/unit_loads/reorder", a.ReorderUnitLoads)
+	g.PATCH("/shipments/:code/unit_loads/:sequence", a.EditUnitLoad)
+	g.DELETE("/shipments/:code/unit_loads/:sequence", a.DeleteUnitLoad)
+	g.GET("/shipments/:code/notes", a.GetNoteList)
+	g.POST("/shipments/:code/notes", s.duplicatePreventMiddleware(a.CreateNote))
+	g.PATCH("/shipments/:code/notes/:note_id", a.EditNote)
+	g.DELETE("/shipments/:code/notes/:note_id", a.DeleteNote)
+	g.GET("/shipments/:code/documents/:type", a.GetShipmentDocument)
+	g.GET("/shipments/:code/declaration", a.GetShipmentDeclaration)
 	g.POST("/shipments/:code/print/unit_loads", a.PrintShipmentULLabels)
 	g.POST("/shipments/:code/print/preparation_info", a.PrintShipmentPreparationInfo)
 	g.POST("/shipments/:code/print/partner_info", a.PrintShipmentPartnerInfo)
+	g.POST("/consolidations", s.duplicatePreventMiddleware(a.CreateConsolidation))
+	g.GET("/consolidations/:code", a.GetConsolidation)
+	g.POST("/consolidations/:code/shipments", a.AttachShipment)
+	g.DELETE("/consolidations/:code/shipments/:child_code", a.DetachShipment)
+	g.POST("/consolidations/:code/print/master_label", a.PrintConsolidationLabel)
+	g.GET("/loading_sessions", a.GetLoadingSessionList)
+	g.POST("/loading_sessions", s.duplicatePreventMiddleware(a.OpenLoadingSession))
+	g.GET("/loading_sessions/:id", a.GetLoadingSession)
+	g.POST("/loading_sessions/:id/scans", a.ScanUnitLoad)
+	g.POST("/loading_sessions/:id/close", a.CloseLoadingSession)
+	g.GET("/dispatches", a.GetDispatchList)
+	g.POST("/dispatches", s.duplicatePreventMiddleware(a.CreateDispatch))
+	g.GET("/dispatches/:id", a.GetDispatch)
+	g.GET("/dispatches/:id/manifest", a.GetDispatchManifest)
+	g.POST("/dispatches/:id/shipments", a.AssignDispatchShipment)
+	g.DELETE("/dispatches/:id/shipments/:code", a.UnassignDispatchShipment)
+	g.GET("/transfer_points", a.GetTransferPointList)
+	g.GET("/partners", a.GetPartnerList)
+	g.POST("/partners", s.duplicatePreventMiddleware(a.CreatePartner))
+	g.GET("/partners/:code", a.GetPartner)
+	g.PUT("/partners/:code", a.UpdatePartner)
+	g.DELETE("/partners/:code", a.DeletePartner)
+	g.GET("/partner_deliveries", a.GetPartnerDeliveryList)
+	g.GET("/partner_deliveries/:id", a.GetPartnerDelivery)
+	g.POST("/partner_deliveries/:id/redeliver", a.RedeliverPartnerDelivery)
+	g.GET("/webhooks", a.GetWebhookList)
+	g.POST("/webhooks", s.duplicatePreventMiddleware(a.CreateWebhook))
+	g.GET("/webhooks/:id", a.GetWebhook)
+	g.PUT("/webhooks/:id", a.UpdateWebhook)
+	g.DELETE("/webhooks/:id", a.DeleteWebhook)
+	g.GET("/webhook_deliveries", a.GetWebhookDeliveryList)
+	g.GET("/webhook_deliveries/:id", a.GetWebhookDelivery)
+	g.POST("/webhook_deliveries/:id/redeliver", a.RedeliverWebhookDelivery)
+	g.GET("/hs_codes", a.GetHSCodeList)
+	g.GET("/hs_codes/:code", a.GetHSCode)
+	g.POST("/hs_codes/import", a.ImportHSCodes)
 	g.GET("/customers", a.GetCustomerList)
+	g.GET("/pre_advices", a.GetPreAdviceList)
+	g.POST("/pre_advices", s.duplicatePreventMiddleware(a.CreatePreAdvices))
+	g.POST("/pre_advices/import", s.duplicatePreventMiddleware(a.ImportPreAdvices))
+	g.GET("/pre_advices/report", a.GetPreAdviceReport)
+	g.POST("/pre_advices/match", a.MatchPreAdvices)
+	g.GET("/pre_advices/:track_code", a.GetPreAdviceByTrackCode)
+	g.DELETE("/pre_advices/:track_code", a.DeletePreAdvice)
 	g.GET("/kdniao/get_source/:track_code", a.GetSourceByTrackCode)
+	g.GET("/tariffs", a.GetTariffList)
+	g.POST("/tariffs/import", a.ImportTariffs)
+	g.POST("/quotes", a.CreateQuote)
 
 	s.router = e
 	s.SetupWsServer()
 	s.StartShipmentUpdates()
+	s.StartEntryUpdates()
 	s.PrintShipmentPrepLabelsOnUpdate()
 
 	return &s, nil
@@ -140,6 +328,7 @@ func NewServer(config config.Config, logger *logrus.Logger) (*Server, error) {
 
 func (s Server) Start(port int) {
 	defer func() {
+		_ = s.wsHub.Close()
 		_ = s.boltDB.Close()
 	}()
 	go func() {
@@ -148,7 +337,7 @@ func (s Server) Start(port int) {
 		}
 	}()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -158,18 +347,21 @@ func (s Server) Start(port int) {
 	}
 }
 
+// SetupWsServer serves websocket clients and SSE stream /events,
+// /ws/shipments is kept for clients using the old address, they
+// are subscribed to shipments topic on connect
 func (s Server) SetupWsServer() {
-	s.wsServer.HandleConnect(func(sess *melody.Session) {
-		s.wsSessions.Load(sess)
-		s.logger.Info("New ws user connection")
+	s.wsHub.OnConnectResync(realtime.TopicShipments, s.shipments.resync)
+
+	s.router.GET("/ws", func(c echo.Context) error {
+		return s.wsHub.HandleRequest(c.Response(), c.Request())
 	})
-	s.wsServer.HandleDisconnect(func(sess *melody.Session) {
-		s.wsSessions.Delete(sess)
-		s.logger.Info("Ws user disconnected")
+	s.router.GET("/ws/shipments", func(c echo.Context) error {
+		return s.wsHub.HandleRequest(c.Response(), c.Request(), realtime.TopicShipments)
 	})
-	wsGroup := s.router.Group("/ws")
-	wsGroup.GET("/shipments", func(c echo.Context) error {
-		return s.wsServer.HandleRequest(c.Response(), c.Request())
+	s.router.GET("/events", func(c echo.Context) error {
+		s.wsHub.ServeSSE(c.Response(), c.Request())
+		return nil
 	})
 }
 
@@ -195,3 +387,24 @@ func (s Server) duplicatePreventMiddleware(next echo.HandlerFunc) echo.HandlerFu
 		return next(c)
 	}
 }
+
+// loadTransferPoints creates registry of DefaultTransferPoints, or of the ones
+// from TRANSFER_POINTS_FILE or FileMaker if configured
+func loadTransferPoints(config logistics.TransferPointsConfig, conn *gofmcon.FMConnector, dbName string) (*logistics.TransferPointRegistry, error) {
+	tps := logistics.DefaultTransferPoints
+	var err error
+	switch {
+	case config.TransferPointsFromFilemaker:
+		tps, err = filemaker.NewTransferPointStore(conn, dbName).GetTransferPointList()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load transfer points from filemaker")
+		}
+	case config.TransferPointsFile != "":
+		tps, err = logistics.LoadTransferPointsFile(config.TransferPointsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return logistics.NewTransferPointRegistry(tps)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"time"
+)
+
+// ActorFileMaker is the actor of status changes
+// made in FileMaker and detected by the poller
+const ActorFileMaker = "filemaker"
+
+// recordStatusChanges compares statuses of polled shipments with the last
+// recorded ones. Shipments which left the polled list since the previous
+// tick are fetched to find out their new status. Returns codes of the
+// polled shipments to be passed on the next tick and the fetched shipments
+func (s *Server) recordStatusChanges(shipments []logistics.Shipment, previous map[string]bool) (map[string]bool, map[string]logistics.Shipment) {
+	current := make(map[string]bool)
+	left := make(map[string]logistics.Shipment)
+	for _, sm := range shipments {
+		current[sm.Code] = true
+		s.recordStatus(sm)
+	}
+
+	for code := range previous {
+		if current[code] {
+			continue
+		}
+		sm, err := s.shipmentStore.GetShipmentByCode(code)
+		if err != nil {
+			s.logger.Errorf("failed to get status of shipment %s: %v", code, err)
+			continue
+		}
+		s.recordStatus(sm)
+		left[code] = sm
+	}
+
+	return current, left
+}
+
+func (s *Server) recordStatus(sm logistics.Shipment) {
+	last, found, err := s.statusHistoryStore.LastStatus(sm.Code)
+	if err != nil {
+		s.logger.Errorf("failed to get last status of shipment %s: %v", sm.Code, err)
+		return
+	}
+	if found && last == sm.CurrentStatusKey {
+		return
+	}
+
+	// previous status of the shipment seen for the first time is unknown
+	from := logistics.InvalidStatus
+	if found {
+		from = last
+	}
+	changedAt := sm.DateModified
+	if changedAt.IsZero() || found {
+		changedAt = time.Now()
+	}
+
+	err = s.statusHistoryStore.AddChange(logistics.StatusChange{
+		Code:      sm.Code,
+		From:      from,
+		To:        sm.CurrentStatusKey,
+		ChangedAt: changedAt,
+		Actor:     ActorFileMaker,
+		Source:    logistics.StatusSourcePoller,
+	})
+	if err != nil {
+		s.logger.Errorf("failed to record status of shipment %s: %v", sm.Code, err)
+	}
+
+	// status changed in FileMaker, first seen shipments are not changed
+	if found {
+		change := map[string]interface{}{
+			"code":            sm.Code,
+			"previous_status": from,
+			"current_status":  sm.CurrentStatusKey,
+			"shipment":        sm,
+		}
+		s.Publish(EventShipmentStatusChanged, change, realtime.ShipmentTopic(sm.Code), realtime.TopicShipments)
+		s.Notify(EventShipmentStatusChanged, change)
+	}
+}
@@ -1,40 +1,114 @@
 package server
 
 import (
-	"encoding/json"
 	"github.com/amanbolat/ca-warehouse-client/logistics"
+	"github.com/amanbolat/ca-warehouse-client/realtime"
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
 	bolt "go.etcd.io/bbolt"
+	"sync"
 	"time"
 )
 
 var ShipmentsBucket = []byte("shipments")
 
+// wsHeartbeat is the interval of ping frames sent to websocket clients
+const wsHeartbeat = 25 * time.Second
+
+const (
+	EventShipmentStatusChanged = webhook.EventShipmentStatusChanged
+	EventShipmentCreated       = "shipment.created"
+	EventShipmentUpdated       = "shipment.updated"
+	EventShipmentRemoved       = "shipment.removed"
+	EventEntryCreated          = warehouse.EventEntryCreated
+	EventEntryUpdated          = warehouse.EventEntryUpdated
+	EventEntryUtilized         = warehouse.EventEntryUtilized
+	EventNoteCreated           = "note.created"
+	EventNoteUpdated           = "note.updated"
+	EventNoteDeleted           = "note.deleted"
+	EventLoadingScanned        = "loading.scanned"
+	EventPrintJobPrinted       = "print_job.printed"
+	EventPrintJobFailed        = webhook.EventPrintJobFailed
+)
+
+// eventPublisher is used by API to notify clients about changes
+// and webhooks about business events
+type eventPublisher interface {
+	Publish(name string, data interface{}, topics ...string)
+	Notify(name string, data interface{})
+}
+
+// Publish sends event to websocket and SSE clients subscribed to any of the topics
+func (s *Server) Publish(name string, data interface{}, topics ...string) {
+	err := s.wsHub.Publish(name, data, topics...)
+	if err != nil {
+		s.logger.Errorf("failed to publish %s event: %v", name, err)
+	}
+}
+
+// Notify sends business event to the webhooks subscribed to it,
+// only webhook.Events are sent
+func (s *Server) Notify(name string, data interface{}) {
+	_, err := s.webhooks.Dispatch(name, data, time.Now())
+	if err != nil {
+		s.logger.Errorf("failed to dispatch %s event to webhooks: %v", name, err)
+	}
+}
+
 func (s *Server) StartShipmentUpdates() {
 	go func() {
 		ticker := time.Tick(time.Second * 5)
+		var polledCodes map[string]bool
 		for {
 			select {
 			case <-ticker:
-				shipments, _, err := s.shipmentStore.GetShipmentUpdates()
+				shipments, _, err := s.shipmentStore.GetShipmentUpdates(s.warehouse)
 				if err != nil {
 					s.logger.Errorf("failed to get shipment updates: %v", err)
 					continue
 				}
 
+				var left map[string]logistics.Shipment
+				polledCodes, left = s.recordStatusChanges(shipments, polledCodes)
+
 				select {
 				case s.shipmentsForPrint <- shipments:
 				default:
 				}
 
-				b, err := json.Marshal(shipments)
+				s.publishShipmentChanges(shipments, left)
+			}
+		}
+	}()
+}
+
+var shipmentChangeEvents = map[string]string{
+	logistics.ShipmentCreated: EventShipmentCreated,
+	logistics.ShipmentUpdated: EventShipmentUpdated,
+	logistics.ShipmentRemoved: EventShipmentRemoved,
+}
+
+// StartEntryUpdates polls entries of the warehouse modified since
+// the previous tick and publishes their changes
+func (s *Server) StartEntryUpdates() {
+	go func() {
+		ticker := time.Tick(time.Second * 5)
+		tracker := warehouse.NewEntryTracker()
+		for {
+			select {
+			case <-ticker:
+				polledAt := time.Now()
+				entries, err := s.entryStore.GetEntryUpdates(s.warehouse, tracker.Since())
 				if err != nil {
-					s.logger.Errorf("failed to get shipment updates: %v", err)
+					s.logger.Errorf("failed to get entry updates: %v", err)
 					continue
 				}
 
-				err = s.wsServer.Broadcast(b)
-				if err != nil {
-					s.logger.Errorf("failed to get shipment updates: %v", err)
+				for _, c := range tracker.Track(entries, polledAt) {
+					s.Publish(c.Event, c, realtime.EntryTopic(c.Entry.ID), realtime.TopicEntries)
+					if c.Event == EventEntryCreated {
+						s.Notify(c.Event, c)
+					}
 				}
 			}
 		}
@@ -77,7 +151,7 @@ func (s *Server) PrintShipmentPrepLabelsOnUpdate() {
 							continue
 						}
 
-						err = s.printer.PrintFiles(1, "", label.FullPath)
+						err = printFiles(*s.printer, s, PrintJob{Kind: PrintJobPreparationInfo, Reference: sm.Code}, 1, label.FullPath)
 						if err != nil {
 							s.logger.Errorf("failed to print prep labels, %v", err)
 							continue
@@ -108,3 +182,55 @@ func (s *Server) PrintShipmentPrepLabelsOnUpdate() {
 
 	}()
 }
+
+// shipmentSnapshot keeps shipments of the last poll
+type shipmentSnapshot struct {
+	mu       sync.RWMutex
+	snapshot logistics.ShipmentSnapshot
+	seeded   bool
+}
+
+// replace saves polled shipments and returns changes since the last poll.
+// The first poll only seeds the snapshot, clients get it with resync.
+// Shipments which left the poll and were fetched are updated before
+// they are removed, so clients see their last status
+func (ss *shipmentSnapshot) replace(shipments []logistics.Shipment, left map[string]logistics.Shipment) []logistics.ShipmentChange {
+	current := logistics.NewShipmentSnapshot(shipments)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	previous := ss.snapshot
+	ss.snapshot = current
+	if !ss.seeded {
+		ss.seeded = true
+		return nil
+	}
+
+	var changes []logistics.ShipmentChange
+	for _, c := range current.Diff(previous) {
+		if sm, ok := left[c.Code]; ok && c.Kind == logistics.ShipmentRemoved {
+			changes = append(changes, previous.Left(sm)...)
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+// resync returns the last polled shipments, sent to websocket
+// clients subscribed to shipments topic
+func (ss *shipmentSnapshot) resync() (interface{}, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	return ss.snapshot.Shipments(), nil
+}
+
+// publishShipmentChanges compares polled shipments with the previous
+// snapshot and publishes only the changed ones
+func (s *Server) publishShipmentChanges(shipments []logistics.Shipment, left map[string]logistics.Shipment) {
+	for _, c := range s.shipments.replace(shipments, left) {
+		s.Publish(shipmentChangeEvents[c.Kind], c, realtime.ShipmentTopic(c.Code), realtime.TopicShipments)
+	}
+}
@@ -2,7 +2,9 @@ package warehouse
 
 import (
 	"github.com/amanbolat/ca-warehouse-client/api"
+	"github.com/amanbolat/ca-warehouse-client/customs"
 	"github.com/amanbolat/ca-warehouse-client/filemaker/fmutil"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -17,6 +19,7 @@ var entryFieldNamesMap = map[string]string{
 	"pcs_qty":         "PieceQuantity",
 	"product_name":    "ProductName",
 	"warehouse":       "Warehouse",
+	"date_modified":   "Date_Modified_Timestamp",
 }
 
 // MapEntryFields maps api field names into FileMaker field names
@@ -56,22 +59,37 @@ const (
 )
 
 type Entry struct {
-	ID                 string          `json:"id"`
-	CustomerCode       string          `json:"customer_code"`
-	ShipmentCode       string          `json:"shipment_code"`
-	Status             int             `json:"status"`
-	DateOfEntry        time.Time       `json:"date_of_entry"`
-	Source             string          `json:"source_of_entry"`
-	TrackCode          string          `json:"track_code"`
-	BoxQty             int             `json:"box_qty"`
-	PcsQty             int             `json:"pcs_qty"`
-	ProductName        string          `json:"product_name"`
-	Warehouse          string          `json:"warehouse"`
-	ImageUrls          []string        `json:"image_urls"`
-	HasBrand           bool            `json:"has_brand"`
-	IsFoundForShipment bool            `json:"is_found_for_shipment"`
-	ProductCategory    ProductCategory `json:"product_category"`
-	FMRecordID         int             `json:"fm_record_id"`
+	ID                 string             `json:"id"`
+	CustomerCode       string             `json:"customer_code"`
+	ShipmentCode       string             `json:"shipment_code"`
+	Status             int                `json:"status"`
+	DateOfEntry        time.Time          `json:"date_of_entry"`
+	Source             string             `json:"source_of_entry"`
+	TrackCode          string             `json:"track_code"`
+	BoxQty             int                `json:"box_qty"`
+	PcsQty             int                `json:"pcs_qty"`
+	ProductName        string             `json:"product_name"`
+	Warehouse          string             `json:"warehouse"`
+	ImageUrls          []string           `json:"image_urls"`
+	HasBrand           bool               `json:"has_brand"`
+	IsFoundForShipment bool               `json:"is_found_for_shipment"`
+	ProductCategory    ProductCategory    `json:"product_category"`
+	Customs            customs.Attributes `json:"customs"`
+	DateModified       time.Time          `json:"date_modified"`
+	// StockStatus is utilized for the entries removed from the stock
+	StockStatus EntryStatus `json:"stock_status"`
+	FMRecordID  int         `json:"fm_record_id"`
+}
+
+// PrepareCustoms normalizes customs attributes of the entry.
+// Entry with the brand name is marked as branded
+func (e *Entry) PrepareCustoms() error {
+	e.Customs.Normalize()
+	if e.Customs.Brand != "" {
+		e.HasBrand = true
+	}
+
+	return e.Customs.Validate()
 }
 
 type FileMakerEntry struct {
@@ -91,6 +109,14 @@ type FileMakerEntry struct {
 	HasBrand           int       `json:"has_brand"`
 	ProductCategory    string    `json:"product_category"`
 	ShipmentStatusKey  int       `json:"TO4a_Entries||Shipments::ShipmentStatus_number"`
+	HSCode             string    `json:"HSCode"`
+	Material           string    `json:"Material"`
+	Brand              string    `json:"Brand"`
+	CountryOfOrigin    string    `json:"CountryOfOrigin"`
+	UnitValue          float64   `json:"UnitValue"`
+	NetWeight          float64   `json:"NetWeight"`
+	DateModified       time.Time `json:"Date_Modified_Timestamp"`
+	IsUtilized         int       `json:"is_utilized"`
 	FMRecordID         int       `json:"-"`
 }
 
@@ -111,6 +137,24 @@ func (v *FileMakerEntry) ToEntry() Entry {
 		IsFoundForShipment: fmutil.ConvertToBool(v.IsFoundForShipment),
 		HasBrand:           fmutil.ConvertToBool(v.HasBrand),
 		ProductCategory:    ProductCategory(v.ProductCategory),
-		FMRecordID:         v.FMRecordID,
+		Customs: customs.Attributes{
+			HSCode:          v.HSCode,
+			Material:        v.Material,
+			Brand:           v.Brand,
+			CountryOfOrigin: v.CountryOfOrigin,
+			UnitValue:       decimal.NewFromFloat(v.UnitValue),
+			NetWeight:       decimal.NewFromFloat(v.NetWeight),
+		},
+		DateModified: v.DateModified,
+		StockStatus:  v.stockStatus(),
+		FMRecordID:   v.FMRecordID,
 	}
 }
+
+func (v *FileMakerEntry) stockStatus() EntryStatus {
+	if fmutil.ConvertToBool(v.IsUtilized) {
+		return EntryStatusUtilized
+	}
+
+	return EntryStatusReceived
+}
@@ -0,0 +1,99 @@
+package warehouse
+
+import (
+	"time"
+)
+
+// Events of the entries found by EntryTracker
+const (
+	EventEntryCreated  = "entry.created"
+	EventEntryUpdated  = "entry.updated"
+	EventEntryUtilized = "entry.utilized"
+)
+
+// EntryChange is the entry modified since the previous poll
+type EntryChange struct {
+	Event string `json:"event"`
+	Entry Entry  `json:"entry"`
+}
+
+// EntryTracker finds out what happened to the entries polled by
+// modification time. The first poll is only remembered, changes are
+// returned starting from the second one. It's not safe for concurrent use
+type EntryTracker struct {
+	since time.Time
+	// polledAt is the start of the previous poll on FileMaker
+	// clock, zero before the first one
+	polledAt time.Time
+	// seen keeps modification time of the polled entries
+	seen map[string]time.Time
+	// utilized are ids of utilized entries. It's not pruned with seen,
+	// so entry edited after utilization is not utilized again
+	utilized map[string]bool
+}
+
+func NewEntryTracker() *EntryTracker {
+	return &EntryTracker{seen: make(map[string]time.Time), utilized: make(map[string]bool)}
+}
+
+// Since is the latest modification time of the tracked entries, zero if
+// none is tracked yet. Next poll should query entries modified at or after it
+func (t *EntryTracker) Since() time.Time {
+	return t.since
+}
+
+// Track returns changes of the entries polled at polledAt. Entries polled
+// again without modification are skipped, so polls may overlap. Entry is
+// created if it's seen for the first time and was created after the start
+// of the previous poll, utilized if its status became utilized
+func (t *EntryTracker) Track(entries []Entry, polledAt time.Time) []EntryChange {
+	first := t.polledAt.IsZero()
+	previousPoll := t.polledAt
+	t.polledAt = fileMakerClock(polledAt)
+
+	var changes []EntryChange
+	for _, e := range entries {
+		modified, ok := t.seen[e.ID]
+		if ok && modified.Equal(e.DateModified) {
+			continue
+		}
+		t.seen[e.ID] = e.DateModified
+		if e.DateModified.After(t.since) {
+			t.since = e.DateModified
+		}
+		wasUtilized := t.utilized[e.ID]
+		if e.StockStatus == EntryStatusUtilized {
+			t.utilized[e.ID] = true
+		} else {
+			delete(t.utilized, e.ID)
+		}
+		if first {
+			continue
+		}
+
+		event := EventEntryUpdated
+		switch {
+		case e.StockStatus == EntryStatusUtilized && !wasUtilized:
+			event = EventEntryUtilized
+		case !ok && e.DateOfEntry.After(previousPoll):
+			event = EventEntryCreated
+		}
+		changes = append(changes, EntryChange{Event: event, Entry: e})
+	}
+
+	// entries modified before since are not polled anymore
+	for id, modified := range t.seen {
+		if modified.Before(t.since) {
+			delete(t.seen, id)
+		}
+	}
+
+	return changes
+}
+
+// fileMakerClock returns wall clock of t in its location as UTC time.
+// FileMaker timestamps have no zone and are decoded as UTC, service runs
+// next to FileMaker server, so local time is the same wall clock
+func fileMakerClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,100 @@
+package warehouse_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEntryTracker(t *testing.T) {
+	start := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	tracker := warehouse.NewEntryTracker()
+	assert.True(t, tracker.Since().IsZero())
+
+	// first poll is remembered only
+	old := warehouse.Entry{ID: "EN1", DateOfEntry: start.Add(-24 * time.Hour), DateModified: start, StockStatus: warehouse.EntryStatusReceived}
+	assert.Empty(t, tracker.Track([]warehouse.Entry{old}, start))
+	assert.Equal(t, start, tracker.Since())
+
+	created := warehouse.Entry{ID: "EN2", DateOfEntry: start.Add(time.Minute), DateModified: start.Add(time.Minute), StockStatus: warehouse.EntryStatusReceived}
+	edited := old
+	edited.DateModified = start.Add(2 * time.Minute)
+	changes := tracker.Track([]warehouse.Entry{old, created, edited}, start.Add(5*time.Second))
+	require.Len(t, changes, 2)
+	assert.Equal(t, warehouse.EventEntryCreated, changes[0].Event)
+	assert.Equal(t, "EN2", changes[0].Entry.ID)
+	assert.Equal(t, warehouse.EventEntryUpdated, changes[1].Event)
+	assert.Equal(t, start.Add(2*time.Minute), tracker.Since())
+
+	// overlapping poll returns the last entry again
+	assert.Empty(t, tracker.Track([]warehouse.Entry{edited}, start.Add(10*time.Second)))
+
+	utilized := edited
+	utilized.StockStatus = warehouse.EntryStatusUtilized
+	utilized.DateModified = start.Add(3 * time.Minute)
+	changes = tracker.Track([]warehouse.Entry{utilized}, start.Add(15*time.Second))
+	require.Len(t, changes, 1)
+	assert.Equal(t, warehouse.EventEntryUtilized, changes[0].Event)
+}
+
+func TestEntryTracker_Created(t *testing.T) {
+	start := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	tracker := warehouse.NewEntryTracker()
+	assert.Empty(t, tracker.Track(nil, start))
+
+	// both entries are seen for the first time, but only the second
+	// one is created after the start of the previous poll
+	before := warehouse.Entry{ID: "EN1", DateOfEntry: start.Add(-time.Second), DateModified: start.Add(3 * time.Second)}
+	after := warehouse.Entry{ID: "EN2", DateOfEntry: start.Add(time.Second), DateModified: start.Add(4 * time.Second)}
+	changes := tracker.Track([]warehouse.Entry{before, after}, start.Add(5*time.Second))
+	require.Len(t, changes, 2)
+	assert.Equal(t, warehouse.EventEntryUpdated, changes[0].Event)
+	assert.Equal(t, warehouse.EventEntryCreated, changes[1].Event)
+
+	// entry created before the previous poll start is updated
+	late := warehouse.Entry{ID: "EN3", DateOfEntry: start.Add(4 * time.Second), DateModified: start.Add(6 * time.Second)}
+	changes = tracker.Track([]warehouse.Entry{late}, start.Add(10*time.Second))
+	require.Len(t, changes, 1)
+	assert.Equal(t, warehouse.EventEntryUpdated, changes[0].Event)
+}
+
+func TestEntryTracker_FileMakerClock(t *testing.T) {
+	// service runs in Guangzhou, FileMaker wall clock is decoded as UTC
+	cst := time.FixedZone("CST", 8*60*60)
+	start := time.Date(2020, 5, 1, 18, 0, 0, 0, cst)
+	wall := time.Date(2020, 5, 1, 18, 0, 0, 0, time.UTC)
+	tracker := warehouse.NewEntryTracker()
+	assert.Empty(t, tracker.Track(nil, start))
+
+	edited := warehouse.Entry{ID: "EN1", DateOfEntry: wall.Add(-2 * time.Hour), DateModified: wall.Add(time.Second)}
+	created := warehouse.Entry{ID: "EN2", DateOfEntry: wall.Add(2 * time.Second), DateModified: wall.Add(2 * time.Second)}
+	changes := tracker.Track([]warehouse.Entry{edited, created}, start.Add(5*time.Second))
+	require.Len(t, changes, 2)
+	assert.Equal(t, warehouse.EventEntryUpdated, changes[0].Event)
+	assert.Equal(t, warehouse.EventEntryCreated, changes[1].Event)
+}
+
+func TestEntryTracker_UtilizedOnce(t *testing.T) {
+	start := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	tracker := warehouse.NewEntryTracker()
+	e := warehouse.Entry{ID: "EN1", DateOfEntry: start.Add(-time.Hour), DateModified: start, StockStatus: warehouse.EntryStatusReceived}
+	assert.Empty(t, tracker.Track([]warehouse.Entry{e}, start))
+
+	e.StockStatus = warehouse.EntryStatusUtilized
+	e.DateModified = start.Add(time.Minute)
+	changes := tracker.Track([]warehouse.Entry{e}, start.Add(5*time.Second))
+	require.Len(t, changes, 1)
+	assert.Equal(t, warehouse.EventEntryUtilized, changes[0].Event)
+
+	// newer entry moves since forward, EN1 is pruned
+	other := warehouse.Entry{ID: "EN2", DateOfEntry: start.Add(-time.Hour), DateModified: start.Add(2 * time.Minute)}
+	require.Len(t, tracker.Track([]warehouse.Entry{other}, start.Add(10*time.Second)), 1)
+
+	// utilized entry edited later is only updated
+	e.DateModified = start.Add(3 * time.Minute)
+	changes = tracker.Track([]warehouse.Entry{e}, start.Add(15*time.Second))
+	require.Len(t, changes, 1)
+	assert.Equal(t, warehouse.EventEntryUpdated, changes[0].Event)
+}
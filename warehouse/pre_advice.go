@@ -0,0 +1,175 @@
+package warehouse
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpectedParcel is a parcel customer told us to expect (pre-advice, ASN)
+type ExpectedParcel struct {
+	TrackCode    string     `json:"track_code"`
+	CustomerCode string     `json:"customer_code"`
+	ProductName  string     `json:"product_name"`
+	Quantity     int        `json:"quantity"`
+	BoxQty       int        `json:"box_qty"`
+	Note         string     `json:"note"`
+	CreatedAt    time.Time  `json:"created_at"`
+	EntryID      string     `json:"entry_id,omitempty"`
+	ArrivedAt    *time.Time `json:"arrived_at,omitempty"`
+}
+
+// NormalizeTrackCode removes spaces and uppercases track code,
+// so the code typed by clerk matches the code sent by customer
+func NormalizeTrackCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
+
+func (p ExpectedParcel) IsArrived() bool {
+	return p.EntryID != ""
+}
+
+func (p *ExpectedParcel) Validate() error {
+	p.TrackCode = NormalizeTrackCode(p.TrackCode)
+	p.CustomerCode = strings.TrimSpace(p.CustomerCode)
+	if p.TrackCode == "" {
+		return errors.New("track code is empty")
+	}
+	if p.CustomerCode == "" {
+		return errors.Errorf("customer code of %s is empty", p.TrackCode)
+	}
+	if p.Quantity < 0 || p.BoxQty < 0 {
+		return errors.Errorf("quantity of %s is negative", p.TrackCode)
+	}
+
+	return nil
+}
+
+// Arrive links parcel with the warehouse entry
+func (p *ExpectedParcel) Arrive(e Entry) {
+	p.EntryID = e.ID
+	arrivedAt := e.DateOfEntry
+	if arrivedAt.IsZero() {
+		arrivedAt = time.Now()
+	}
+	p.ArrivedAt = &arrivedAt
+}
+
+// ParseExpectedParcelsCSV parses pre-advice sent by customer.
+// Columns are: track_code, product_name, quantity, box_qty, note.
+// Only track_code is required, header row is optional
+func ParseExpectedParcelsCSV(r io.Reader, customerCode string) ([]ExpectedParcel, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var parcels []ExpectedParcel
+	for i, rec := range records {
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if i == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "track_code") {
+			continue
+		}
+
+		p := ExpectedParcel{
+			TrackCode:    rec[0],
+			CustomerCode: customerCode,
+		}
+		if len(rec) > 1 {
+			p.ProductName = strings.TrimSpace(rec[1])
+		}
+		if len(rec) > 2 && strings.TrimSpace(rec[2]) != "" {
+			p.Quantity, err = strconv.Atoi(strings.TrimSpace(rec[2]))
+			if err != nil {
+				return nil, errors.Errorf("line %d: wrong quantity %q", i+1, rec[2])
+			}
+		}
+		if len(rec) > 3 && strings.TrimSpace(rec[3]) != "" {
+			p.BoxQty, err = strconv.Atoi(strings.TrimSpace(rec[3]))
+			if err != nil {
+				return nil, errors.Errorf("line %d: wrong box quantity %q", i+1, rec[3])
+			}
+		}
+		if len(rec) > 4 {
+			p.Note = strings.TrimSpace(rec[4])
+		}
+
+		err = p.Validate()
+		if err != nil {
+			return nil, errors.Errorf("line %d: %v", i+1, err)
+		}
+		parcels = append(parcels, p)
+	}
+
+	return parcels, nil
+}
+
+// PreAdviceReport shows the difference between expected parcels
+// and warehouse entries
+type PreAdviceReport struct {
+	// Arrived are parcels which were matched with entries
+	Arrived []ExpectedParcel `json:"arrived"`
+	// NotArrived are expected parcels which have no entry yet
+	NotArrived []ExpectedParcel `json:"not_arrived"`
+	// NotExpected are entries of customers, which have no pre-advice
+	NotExpected []Entry `json:"not_expected"`
+}
+
+// MatchExpectedParcels matches parcels with the entries by customer code
+// and track code. Entry with another or empty customer code is matched if
+// only one customer expects its track code. Parcels arrived with the given
+// entries are updated in place. Only entries of customers who sent any
+// pre-advice are considered as not expected
+func MatchExpectedParcels(parcels []ExpectedParcel, entries []Entry) PreAdviceReport {
+	report := PreAdviceReport{
+		Arrived:     []ExpectedParcel{},
+		NotArrived:  []ExpectedParcel{},
+		NotExpected: []Entry{},
+	}
+
+	byCustomer := make(map[[2]string]int)
+	byTrackCode := make(map[string][]int)
+	customers := make(map[string]bool)
+	for i, p := range parcels {
+		trackCode := NormalizeTrackCode(p.TrackCode)
+		byCustomer[[2]string{p.CustomerCode, trackCode}] = i
+		byTrackCode[trackCode] = append(byTrackCode[trackCode], i)
+		customers[p.CustomerCode] = true
+	}
+
+	for _, e := range entries {
+		trackCode := NormalizeTrackCode(e.TrackCode)
+		i, ok := byCustomer[[2]string{strings.TrimSpace(e.CustomerCode), trackCode}]
+		if !ok && len(byTrackCode[trackCode]) == 1 {
+			i, ok = byTrackCode[trackCode][0], true
+		}
+		if !ok {
+			if customers[e.CustomerCode] {
+				report.NotExpected = append(report.NotExpected, e)
+			}
+			continue
+		}
+		if !parcels[i].IsArrived() {
+			parcels[i].Arrive(e)
+		}
+	}
+
+	for _, p := range parcels {
+		if p.IsArrived() {
+			report.Arrived = append(report.Arrived, p)
+		} else {
+			report.NotArrived = append(report.NotArrived, p)
+		}
+	}
+
+	return report
+}
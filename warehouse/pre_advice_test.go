@@ -0,0 +1,70 @@
+package warehouse_test
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseExpectedParcelsCSV(t *testing.T) {
+	csv := "track_code,product_name,quantity,box_qty,note\n" +
+		"sf 1241923123, LED 灯具, 100, 2, 易碎\n" +
+		"YT4536238912033,衣服,,\n" +
+		"\n"
+
+	parcels, err := warehouse.ParseExpectedParcelsCSV(strings.NewReader(csv), "77-00123")
+	if assert.NoError(t, err) && assert.Len(t, parcels, 2) {
+		assert.Equal(t, "SF1241923123", parcels[0].TrackCode)
+		assert.Equal(t, "77-00123", parcels[0].CustomerCode)
+		assert.Equal(t, 100, parcels[0].Quantity)
+		assert.Equal(t, 2, parcels[0].BoxQty)
+		assert.Equal(t, "易碎", parcels[0].Note)
+		assert.Equal(t, 0, parcels[1].Quantity)
+	}
+
+	_, err = warehouse.ParseExpectedParcelsCSV(strings.NewReader("SF123,衣服,many"), "77-00123")
+	assert.Error(t, err)
+}
+
+func TestMatchExpectedParcels(t *testing.T) {
+	parcels := []warehouse.ExpectedParcel{
+		{TrackCode: "SF1241923123", CustomerCode: "77-00123"},
+		{TrackCode: "YT4536238912033", CustomerCode: "77-00123"},
+	}
+	entries := []warehouse.Entry{
+		{ID: "EN0001", CustomerCode: "77-00123", TrackCode: "sf1241923123"},
+		{ID: "EN0002", CustomerCode: "77-00123", TrackCode: "JD000111"},
+		{ID: "EN0003", CustomerCode: "CON", TrackCode: "JD000112"},
+	}
+
+	report := warehouse.MatchExpectedParcels(parcels, entries)
+	if assert.Len(t, report.Arrived, 1) {
+		assert.Equal(t, "EN0001", report.Arrived[0].EntryID)
+		assert.NotNil(t, report.Arrived[0].ArrivedAt)
+	}
+	if assert.Len(t, report.NotArrived, 1) {
+		assert.Equal(t, "YT4536238912033", report.NotArrived[0].TrackCode)
+	}
+	if assert.Len(t, report.NotExpected, 1) {
+		assert.Equal(t, "EN0002", report.NotExpected[0].ID)
+	}
+}
+
+func TestMatchExpectedParcels_SameTrackCode(t *testing.T) {
+	parcels := []warehouse.ExpectedParcel{
+		{TrackCode: "SF1241923123", CustomerCode: "77-00123"},
+		{TrackCode: "SF1241923123", CustomerCode: "77-00456"},
+	}
+	entries := []warehouse.Entry{
+		{ID: "EN0001", CustomerCode: "77-00456", TrackCode: "SF1241923123"},
+		{ID: "EN0002", CustomerCode: "", TrackCode: "SF1241923123"},
+	}
+
+	report := warehouse.MatchExpectedParcels(parcels, entries)
+	if assert.Len(t, report.Arrived, 1) {
+		assert.Equal(t, "77-00456", report.Arrived[0].CustomerCode)
+		assert.Equal(t, "EN0001", report.Arrived[0].EntryID)
+	}
+	assert.Len(t, report.NotArrived, 1)
+}
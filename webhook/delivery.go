@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"time"
+)
+
+// HeaderEvent is the event type of the delivered payload
+const HeaderEvent = "X-Webhook-Event"
+
+// Payload is the body of the webhook request
+type Payload struct {
+	Event string          `json:"event"`
+	Time  time.Time       `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Delivery is the event sent to the subscription with all the attempts
+type Delivery struct {
+	ID             string             `json:"id"`
+	SubscriptionID string             `json:"subscription_id"`
+	Event          string             `json:"event"`
+	URL            string             `json:"url"`
+	Payload        string             `json:"payload"`
+	Status         delivery.Status    `json:"status"`
+	Attempts       []delivery.Attempt `json:"attempts"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// SubscriptionStore keeps subscriptions
+type SubscriptionStore interface {
+	ListSubscriptions() ([]Subscription, error)
+	GetSubscription(id string) (Subscription, error)
+}
+
+// DeliveryLog keeps deliveries, CreateDelivery sets delivery id.
+// RequeueDelivery sets status of the finished delivery back to pending
+// in one transaction, delivery.ErrInProgress is returned for pending one
+type DeliveryLog interface {
+	CreateDelivery(d Delivery) (Delivery, error)
+	SaveDelivery(d Delivery) error
+	GetDelivery(id string) (Delivery, error)
+	RequeueDelivery(id string, at time.Time) (Delivery, error)
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+var ErrSubscriptionDisabled = errors.New("webhook: subscription is disabled or removed")
+
+// DispatchConfig of webhook deliveries. Attempt n waits WebhookBackoff * 2^(n-2)
+// after the previous one failed
+type DispatchConfig struct {
+	WebhookTimeout time.Duration `split_words:"true" default:"10s"`
+	WebhookRetries int           `split_words:"true" default:"5"`
+	WebhookBackoff time.Duration `split_words:"true" default:"30s"`
+}
+
+// Dispatcher sends events to the subscriptions
+type Dispatcher struct {
+	sender        *delivery.Sender
+	subscriptions SubscriptionStore
+	log           DeliveryLog
+	logger        *logrus.Logger
+
+	// stops are closed to stop pending deliveries of the subscription
+	mu    sync.Mutex
+	stops map[string]chan struct{}
+}
+
+func NewDispatcher(config DispatchConfig, subscriptions SubscriptionStore, log DeliveryLog, logger *logrus.Logger) *Dispatcher {
+	return &Dispatcher{
+		sender: delivery.NewSender(delivery.Config{
+			Timeout: config.WebhookTimeout,
+			Retries: config.WebhookRetries,
+			Backoff: config.WebhookBackoff,
+		}),
+		subscriptions: subscriptions,
+		log:           log,
+		logger:        logger,
+		stops:         make(map[string]chan struct{}),
+	}
+}
+
+// Dispatch saves deliveries of the event to every matching
+// subscription and sends them in background. Only Events are dispatched
+func (d *Dispatcher) Dispatch(event string, data interface{}, at time.Time) ([]Delivery, error) {
+	if !IsEvent(event) {
+		return nil, errors.WithMessage(ErrInvalidEvent, event)
+	}
+	subscriptions, err := d.subscriptions.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	var deliveries []Delivery
+	for _, s := range subscriptions {
+		if !s.Matches(event) {
+			continue
+		}
+		if payload == nil {
+			raw, err := json.Marshal(data)
+			if err != nil {
+				return nil, err
+			}
+			payload, err = json.Marshal(Payload{Event: event, Time: at, Data: raw})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		dl, err := d.log.CreateDelivery(Delivery{
+			SubscriptionID: s.ID,
+			Event:          event,
+			URL:            s.URL,
+			Payload:        string(payload),
+			Status:         delivery.Pending,
+			Attempts:       []delivery.Attempt{},
+			CreatedAt:      at,
+			UpdatedAt:      at,
+		})
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, dl)
+		go d.Deliver(dl)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends failed or delivered event again in background
+func (d *Dispatcher) Redeliver(id string) (Delivery, error) {
+	dl, err := d.log.RequeueDelivery(id, time.Now())
+	if err != nil {
+		return Delivery{}, err
+	}
+	go d.Deliver(dl)
+
+	return dl, nil
+}
+
+// Stop fails pending deliveries of the removed or disabled subscription
+// right away, without waiting for their retries
+func (d *Dispatcher) Stop(subscriptionID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if stop, ok := d.stops[subscriptionID]; ok {
+		close(stop)
+		delete(d.stops, subscriptionID)
+	}
+}
+
+func (d *Dispatcher) stopOf(subscriptionID string) <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stop, ok := d.stops[subscriptionID]
+	if !ok {
+		stop = make(chan struct{})
+		d.stops[subscriptionID] = stop
+	}
+
+	return stop
+}
+
+// Deliver sends the event retrying on failures, every attempt is saved
+// to the delivery log. URL and secret are taken from the subscription
+// before every attempt, delivery fails once it's disabled or removed
+func (d *Dispatcher) Deliver(dl Delivery) Delivery {
+	req := delivery.Request{
+		ID:          dl.ID,
+		ContentType: "application/json",
+		Body:        []byte(dl.Payload),
+		Headers:     map[string]string{HeaderEvent: dl.Event},
+	}
+	d.sender.Send(req, func() (delivery.Target, error) {
+		s, err := d.subscriptions.GetSubscription(dl.SubscriptionID)
+		if err != nil || !s.Enabled {
+			return delivery.Target{}, ErrSubscriptionDisabled
+		}
+		return delivery.Target{URL: s.URL, Secret: s.Secret}, nil
+	}, d.stopOf(dl.SubscriptionID), func(t delivery.Target, a delivery.Attempt, status delivery.Status) {
+		if t.URL != "" {
+			dl.URL = t.URL
+		}
+		dl.Attempts = append(dl.Attempts, a)
+		dl.UpdatedAt = a.At
+		dl.Status = status
+
+		err := d.log.SaveDelivery(dl)
+		if err != nil {
+			d.logger.Errorf("failed to save webhook delivery %s: %v", dl.ID, err)
+		}
+	})
+
+	if dl.Status == delivery.Failed {
+		d.logger.Errorf("failed to deliver %s event to webhook %s", dl.Event, dl.SubscriptionID)
+	}
+
+	return dl
+}
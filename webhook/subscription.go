@@ -0,0 +1,133 @@
+// Package webhook sends warehouse events to the subscribed URLs
+package webhook
+
+import (
+	"github.com/amanbolat/ca-warehouse-client/warehouse"
+	"github.com/pkg/errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidURL           = errors.New("webhook: url should be absolute http or https url")
+	ErrNoEvents             = errors.New("webhook: subscription has no events")
+	ErrInvalidEvent         = errors.New("webhook: invalid event type")
+	ErrEmptySecret          = errors.New("webhook: secret is required")
+	ErrSubscriptionNotFound = errors.New("webhook: subscription not found")
+)
+
+// Business events delivered to webhooks, other events are
+// only published to realtime clients
+const (
+	EventEntryCreated          = warehouse.EventEntryCreated
+	EventShipmentStatusChanged = "shipment.status_changed"
+	EventPrintJobFailed        = "print_job.failed"
+)
+
+// Events are all the events webhooks can subscribe to
+var Events = []string{EventEntryCreated, EventShipmentStatusChanged, EventPrintJobFailed}
+
+// AllEvents subscribes to every event
+const AllEvents = "*"
+
+// eventRe matches event types, e.g. entry.created, or the
+// whole group of events, e.g. entry.*
+var eventRe = regexp.MustCompile(`^[a-z_]+\.([a-z_]+|\*)$`)
+
+// IsEvent checks if event is delivered to webhooks
+func IsEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Subscription is the URL events are sent to
+type Subscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Events are event types like shipment.status_changed, entry.* or *
+	Events []string `json:"events"`
+	// Secret is only set by clients, it's not returned by API
+	Secret      string    `json:"secret,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Normalize trims values and removes duplicated events
+func (s *Subscription) Normalize() {
+	s.URL = strings.TrimSpace(s.URL)
+	s.Description = strings.TrimSpace(s.Description)
+
+	seen := make(map[string]bool)
+	events := make([]string, 0, len(s.Events))
+	for _, e := range s.Events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || seen[e] {
+			continue
+		}
+		seen[e] = true
+		events = append(events, e)
+	}
+	s.Events = events
+}
+
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidURL
+	}
+	if len(s.Events) == 0 {
+		return ErrNoEvents
+	}
+	for _, e := range s.Events {
+		if (e != AllEvents && !eventRe.MatchString(e)) || !knownPattern(e) {
+			return errors.WithMessage(ErrInvalidEvent, e)
+		}
+	}
+	if s.Secret == "" {
+		return ErrEmptySecret
+	}
+
+	return nil
+}
+
+// Matches checks if subscription is enabled and subscribed to the event
+func (s Subscription) Matches(event string) bool {
+	if !s.Enabled {
+		return false
+	}
+	for _, e := range s.Events {
+		if matchEvent(e, event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// knownPattern checks if pattern matches any of Events
+func knownPattern(pattern string) bool {
+	for _, e := range Events {
+		if matchEvent(pattern, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchEvent(pattern string, event string) bool {
+	if pattern == AllEvents || pattern == event {
+		return true
+	}
+
+	return strings.HasSuffix(pattern, ".*") && strings.HasPrefix(event, strings.TrimSuffix(pattern, "*"))
+}
@@ -0,0 +1,192 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/amanbolat/ca-warehouse-client/delivery"
+	"github.com/amanbolat/ca-warehouse-client/webhook"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu            sync.Mutex
+	subscriptions []webhook.Subscription
+	deliveries    map[string]webhook.Delivery
+}
+
+func (s *memoryStore) ListSubscriptions() ([]webhook.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscriptions, nil
+}
+
+func (s *memoryStore) GetSubscription(id string) (webhook.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subscriptions {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return webhook.Subscription{}, webhook.ErrSubscriptionNotFound
+}
+
+func (s *memoryStore) CreateDelivery(d webhook.Delivery) (webhook.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.ID = fmt.Sprintf("WD%06d", len(s.deliveries)+1)
+	s.deliveries[d.ID] = d
+	return d, nil
+}
+
+func (s *memoryStore) SaveDelivery(d webhook.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *memoryStore) GetDelivery(id string) (webhook.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deliveries[id], nil
+}
+
+func (s *memoryStore) RequeueDelivery(id string, at time.Time) (webhook.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[id]
+	if d.Status == delivery.Pending {
+		return webhook.Delivery{}, delivery.ErrInProgress
+	}
+	d.Status = delivery.Pending
+	d.UpdatedAt = at
+	s.deliveries[id] = d
+	return d, nil
+}
+
+func (s *memoryStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, sub := range s.subscriptions {
+		if sub.ID == id {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *memoryStore) status(id string) delivery.Status {
+	d, _ := s.GetDelivery(id)
+	return d.Status
+}
+
+func TestDispatcher(t *testing.T) {
+	mock := delivery.NewMockReceiver("secret", 1)
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	store := &memoryStore{
+		subscriptions: []webhook.Subscription{
+			{ID: "WH000001", URL: srv.URL, Events: []string{"entry.*"}, Secret: "secret", Enabled: true},
+			{ID: "WH000002", URL: srv.URL, Events: []string{"*"}, Secret: "secret"},
+		},
+		deliveries: make(map[string]webhook.Delivery),
+	}
+	config := webhook.DispatchConfig{WebhookTimeout: time.Second, WebhookRetries: 2, WebhookBackoff: time.Millisecond}
+	d := webhook.NewDispatcher(config, store, store, logrus.New())
+
+	deliveries, err := d.Dispatch("entry.created", map[string]string{"id": "EN1"}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "WH000001", deliveries[0].SubscriptionID)
+
+	id := deliveries[0].ID
+	assert.Eventually(t, func() bool { return store.status(id) == delivery.Delivered }, time.Second, 5*time.Millisecond)
+	dl, _ := store.GetDelivery(id)
+	assert.Len(t, dl.Attempts, 2)
+
+	received := mock.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, id, received[0].DeliveryID)
+	var p webhook.Payload
+	require.NoError(t, json.Unmarshal(received[0].Body, &p))
+	assert.Equal(t, "entry.created", p.Event)
+	assert.JSONEq(t, `{"id":"EN1"}`, string(p.Data))
+
+	// not subscribed
+	deliveries, err = d.Dispatch("print_job.failed", nil, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// only business events are dispatched
+	_, err = d.Dispatch("entry.updated", nil, time.Now())
+	assert.Equal(t, webhook.ErrInvalidEvent, errors.Cause(err))
+
+	// wrong secret is rejected, delivery can be sent again
+	mock.Secret = "rotated"
+	deliveries, err = d.Dispatch("entry.created", nil, time.Now())
+	require.NoError(t, err)
+	id = deliveries[0].ID
+	assert.Eventually(t, func() bool { return store.status(id) == delivery.Failed }, time.Second, 5*time.Millisecond)
+
+	mock.Secret = "secret"
+	dl, err = d.Redeliver(id)
+	require.NoError(t, err)
+	assert.Equal(t, delivery.Pending, dl.Status)
+	assert.Eventually(t, func() bool { return store.status(id) == delivery.Delivered }, time.Second, 5*time.Millisecond)
+}
+
+func TestDispatcher_Stop(t *testing.T) {
+	mock := delivery.NewMockReceiver("rotated", 0)
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	store := &memoryStore{
+		subscriptions: []webhook.Subscription{
+			{ID: "WH000001", URL: srv.URL, Events: []string{"*"}, Secret: "secret", Enabled: true},
+		},
+		deliveries: make(map[string]webhook.Delivery),
+	}
+	config := webhook.DispatchConfig{WebhookTimeout: time.Second, WebhookRetries: 2, WebhookBackoff: time.Hour}
+	d := webhook.NewDispatcher(config, store, store, logrus.New())
+
+	deliveries, err := d.Dispatch("print_job.failed", nil, time.Now())
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	id := deliveries[0].ID
+	assert.Eventually(t, func() bool {
+		dl, _ := store.GetDelivery(id)
+		return len(dl.Attempts) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// removed subscription doesn't wait for the retry
+	store.remove("WH000001")
+	d.Stop("WH000001")
+	assert.Eventually(t, func() bool { return store.status(id) == delivery.Failed }, time.Second, 5*time.Millisecond)
+	dl, _ := store.GetDelivery(id)
+	require.Len(t, dl.Attempts, 2)
+	assert.Equal(t, delivery.ErrStopped.Error(), dl.Attempts[1].Error)
+}
+
+func TestSubscription(t *testing.T) {
+	s := webhook.Subscription{URL: " https://erp.local/hooks ", Events: []string{"Entry.Created", "entry.created", "shipment.*"}, Secret: "secret", Enabled: true}
+	s.Normalize()
+	assert.NoError(t, s.Validate())
+	assert.Equal(t, []string{"entry.created", "shipment.*"}, s.Events)
+	assert.True(t, s.Matches("shipment.status_changed"))
+	assert.False(t, s.Matches("entry.updated"))
+
+	assert.Equal(t, webhook.ErrInvalidURL, webhook.Subscription{URL: "erp.local", Events: []string{"*"}, Secret: "s"}.Validate())
+	assert.Equal(t, webhook.ErrNoEvents, webhook.Subscription{URL: "http://erp.local"}.Validate())
+	assert.Equal(t, webhook.ErrInvalidEvent, errors.Cause(webhook.Subscription{URL: "http://erp.local", Events: []string{"entry"}, Secret: "s"}.Validate()))
+	assert.Equal(t, webhook.ErrInvalidEvent, errors.Cause(webhook.Subscription{URL: "http://erp.local", Events: []string{"note.*"}, Secret: "s"}.Validate()))
+	assert.Equal(t, webhook.ErrEmptySecret, webhook.Subscription{URL: "http://erp.local", Events: []string{"*"}}.Validate())
+}